@@ -0,0 +1,161 @@
+// Package cgo prepares the environment for building Go code that uses cgo
+// with a discovered C/C++ toolchain, including cross builds.
+package cgo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/compiler/triplet"
+	"golang.org/x/exp/slices"
+)
+
+// ErrNoToolchain is returned when none of the toolchains matches the Go
+// target
+var ErrNoToolchain = errors.New("no matching C toolchain")
+
+// Select picks the toolchain for the Go target. Toolchains are matched with
+// triplet.Target.MatchGo, the compiler is chosen in the order of preference
+// (gcc, clang by default), newer versions are preferred.
+func Select(tt []*toolchain.Chain, g triplet.GoTarget, order_of_preference ...string) (*toolchain.Chain, error) {
+	sel := []*toolchain.Chain{}
+	for _, tc := range tt {
+		if tc.IsMSVC() || !tc.Target.MatchGo(g) {
+			continue
+		}
+		sel = append(sel, tc)
+	}
+	if len(sel) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoToolchain, g)
+	}
+	if order_of_preference == nil {
+		order_of_preference = []string{"gcc", "clang"}
+	}
+	slices.SortStableFunc(sel, toolchain.Compare)
+	for _, c := range order_of_preference {
+		for i := len(sel) - 1; i >= 0; i-- {
+			if sel[i].Compiler == c {
+				return sel[i], nil
+			}
+		}
+	}
+	return sel[len(sel)-1], nil
+}
+
+// Env returns the variables that make the go command build for the target
+// with the toolchain: GOOS, GOARCH, GOARM, CGO_ENABLED, CC, CXX, AR, and
+// CGO_CFLAGS, CGO_CXXFLAGS, CGO_LDFLAGS when the toolchain requires
+// --target or --sysroot.
+func Env(tc *toolchain.Chain, g triplet.GoTarget) (map[string]string, error) {
+	cc, cxx := tc.Tools[toolchain.CCompiler], tc.Tools[toolchain.CXXCompiler]
+	if cc == "" {
+		return nil, errors.New("toolchain has no C compiler")
+	}
+	ret := map[string]string{
+		"CGO_ENABLED": "1",
+		"GOOS":        g.GOOS,
+		"GOARCH":      g.GOARCH,
+		"CC":          commandLine(cc),
+	}
+	if g.GOARM != "" {
+		ret["GOARM"] = g.GOARM
+	}
+	if g.GO386 != "" {
+		ret["GO386"] = g.GO386
+	}
+	if cxx != "" {
+		ret["CXX"] = commandLine(cxx)
+	}
+	if ar := tc.Tools[toolchain.Archiver]; ar != "" {
+		ret["AR"] = commandLine(ar)
+	}
+
+	flags := []string{}
+	if tc.Compiler == "clang" && tc.Target.Original != "" {
+		flags = append(flags, "--target="+tc.Target.Original)
+	}
+	if tc.Sysroot != "" {
+		flags = append(flags, "--sysroot="+tc.Sysroot)
+	}
+	if len(flags) > 0 {
+		s := quote(flags...)
+		ret["CGO_CFLAGS"] = s
+		ret["CGO_CXXFLAGS"] = s
+		ret["CGO_LDFLAGS"] = s
+	}
+	return ret, nil
+}
+
+// Environ returns the process environment extended with the chain
+// environment and the cgo variables, suitable for exec.Cmd.Env
+func Environ(tc *toolchain.Chain, g triplet.GoTarget) ([]string, error) {
+	vars, err := Env(tc, g)
+	if err != nil {
+		return nil, err
+	}
+	ret := tc.Env()
+	for _, k := range sortedKeys(vars) {
+		ret = append(ret, k+"="+vars[k])
+	}
+	return ret, nil
+}
+
+// Format writes the variables in one of the formats:
+//   - env: KEY=VALUE lines, also accepted by `go env -w`
+//   - sh: export statements for POSIX shells
+//   - powershell: $env: assignments
+//   - cmd: set statements for cmd.exe
+func Format(vars map[string]string, format string) (string, error) {
+	sb := strings.Builder{}
+	for _, k := range sortedKeys(vars) {
+		v := vars[k]
+		switch format {
+		case "env":
+			fmt.Fprintf(&sb, "%s=%s\n", k, v)
+		case "sh":
+			fmt.Fprintf(&sb, "export %s='%s'\n", k, strings.ReplaceAll(v, "'", `'\''`))
+		case "powershell":
+			fmt.Fprintf(&sb, "$env:%s = '%s'\n", k, strings.ReplaceAll(v, "'", "''"))
+		case "cmd":
+			fmt.Fprintf(&sb, "set \"%s=%s\"\n", k, v)
+		default:
+			return "", fmt.Errorf("unsupported format '%s'", format)
+		}
+	}
+	return sb.String(), nil
+}
+
+// commandLine converts a tool path with subcommands (e.g. zig cc) into the
+// form accepted by the go command in CC and CXX
+func commandLine(tp toolchain.ToolPath) string {
+	return quote(append([]string{tp.Path()}, tp.Commands()...)...)
+}
+
+// quote joins arguments with spaces, quoting the ones that contain spaces
+// the way the go command splits CC and CGO_*FLAGS
+func quote(args ...string) string {
+	ss := make([]string, len(args))
+	for i, a := range args {
+		switch {
+		case !strings.ContainsAny(a, " \t'\""):
+			ss[i] = a
+		case !strings.Contains(a, "'"):
+			ss[i] = "'" + a + "'"
+		default:
+			ss[i] = `"` + a + `"`
+		}
+	}
+	return strings.Join(ss, " ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
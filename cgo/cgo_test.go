@@ -0,0 +1,69 @@
+package cgo
+
+import (
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+func chain(compiler, version, target string, tools toolchain.Toolset) *toolchain.Chain {
+	tc := &toolchain.Chain{Compiler: compiler, Version: version, Tools: tools}
+	tc.Target, _ = triplet.ParseFull(target)
+	return tc
+}
+
+func TestSelect(t *testing.T) {
+	tt := []*toolchain.Chain{
+		chain("gcc", "12.2.0", "aarch64-linux-gnu", nil),
+		chain("gcc", "13.1.0", "aarch64-linux-gnu", nil),
+		chain("clang", "17.0.6", "aarch64-linux-gnu", nil),
+		chain("gcc", "13.1.0", "x86_64-linux-gnu", nil),
+		chain("msvc", "19.38", "aarch64-windows-msvc", nil),
+	}
+	arm64 := triplet.GoTarget{GOOS: "linux", GOARCH: "arm64"}
+	tc, err := Select(tt, arm64)
+	if err != nil || tc != tt[1] {
+		t.Errorf("Select(%s) = %v, %v, want gcc 13.1.0", arm64, tc, err)
+	}
+	tc, err = Select(tt, arm64, "clang")
+	if err != nil || tc != tt[2] {
+		t.Errorf("Select(%s, clang) = %v, %v, want clang 17.0.6", arm64, tc, err)
+	}
+	win := triplet.GoTarget{GOOS: "windows", GOARCH: "arm64"}
+	if tc, err = Select(tt, win); err == nil {
+		t.Errorf("Select(%s) = %s %s, want an error", win, tc.Compiler, tc.Version)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	tc := chain("clang", "17.0.6", "aarch64-linux-gnu", toolchain.Toolset{
+		toolchain.CCompiler:   toolchain.NewToolPath("/opt/zig tools/zig", "cc"),
+		toolchain.CXXCompiler: toolchain.NewToolPath("/opt/zig tools/zig", "c++"),
+		toolchain.Archiver:    toolchain.NewToolPath("/opt/zig tools/zig", "ar"),
+	})
+	tc.Sysroot = "/opt/sysroot"
+	vars, err := Env(tc, triplet.GoTarget{GOOS: "linux", GOARCH: "arm64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"CGO_ENABLED":  "1",
+		"GOOS":         "linux",
+		"GOARCH":       "arm64",
+		"CC":           "'/opt/zig tools/zig' cc",
+		"CXX":          "'/opt/zig tools/zig' c++",
+		"AR":           "'/opt/zig tools/zig' ar",
+		"CGO_CFLAGS":   "--target=aarch64-linux-gnu --sysroot=/opt/sysroot",
+		"CGO_CXXFLAGS": "--target=aarch64-linux-gnu --sysroot=/opt/sysroot",
+		"CGO_LDFLAGS":  "--target=aarch64-linux-gnu --sysroot=/opt/sysroot",
+	}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("Env()[%s] = %q, want %q", k, vars[k], v)
+		}
+	}
+	if len(vars) != len(want) {
+		t.Errorf("Env() = %v", vars)
+	}
+}
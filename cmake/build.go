@@ -1,9 +1,13 @@
 package cmake
 
 import (
+	"fmt"
 	"io"
 	"os/exec"
+	"strconv"
 	"strings"
+
+	"github.com/adnsv/go-build/diag"
 )
 
 type BuildType int
@@ -30,6 +34,17 @@ func (b BuildType) String() string {
 	}
 }
 
+// ParseBuildType parses the CMAKE_BUILD_TYPE spelling of a build type, the
+// comparison is case-insensitive
+func ParseBuildType(s string) (BuildType, error) {
+	for _, b := range []BuildType{Release, Debug, MinSizeRel, RelWithDebInfo} {
+		if strings.EqualFold(s, b.String()) {
+			return b, nil
+		}
+	}
+	return Release, fmt.Errorf("unsupported build type '%s'", s)
+}
+
 type Builder struct {
 	CMakeCmd string
 	Stdout   io.Writer
@@ -40,11 +55,22 @@ type Builder struct {
 	BuildDir     string
 	BuildType    BuildType // injected as -DCMAKE_BUILD_TYPE:STRING=${BuildType}
 	BuildTargets []string
+	Jobs         int // --parallel <jobs>, the native tool default when 0
 
 	GenerateFlags []string // -DVAR=VALUE pairs
-	BuildFlags    []string
+	BuildFlags    []string // appended to the cmake --build arguments, native tool options follow "--"
 
 	Generator *Generator
+
+	// StaleCache selects what Generate does with a build directory that was
+	// configured with different settings, the zero value leaves it to cmake
+	StaleCache StaleCachePolicy
+
+	// Diagnostics, when set, parses the compiler diagnostics in the output
+	// of Build. The build then runs with the compiler locale forced to
+	// English, see diag.LocaleEnv. Set Stdout and Stderr to the same writer
+	// to parse the output in the order it was printed, see diag.Parser.Run.
+	Diagnostics *diag.Parser
 }
 
 type Generator = struct {
@@ -120,15 +146,25 @@ func (b *Builder) EffectiveBuildArgs() []string {
 		args = append(args, "--target", t)
 	}
 	args = append(args, "--parallel")
+	if b.Jobs > 0 {
+		args = append(args, strconv.Itoa(b.Jobs))
+	}
+	args = append(args, b.BuildFlags...)
 	return args
 }
 
+// Generate configures the build directory. An existing cache is checked
+// against the builder settings first, see StaleCache. The File API query is
+// written before cmake runs, so the replies are available through FileAPI
+// afterwards.
 func (b *Builder) Generate() error {
-	c := b.CMakeCmd
-	if c == "" {
-		c = "cmake"
+	if err := b.checkCache(); err != nil {
+		return err
+	}
+	if err := WriteFileAPIQuery(b.BuildDir); err != nil {
+		return err
 	}
-	cmd := exec.Command(c, b.EffectiveGenerateArgs()...)
+	cmd := exec.Command(b.cmakeCmd(), b.EffectiveGenerateArgs()...)
 	//cmd := exec.Command(c, "--version")
 	cmd.Stdout = b.Stdout
 	cmd.Stderr = b.Stderr
@@ -139,12 +175,13 @@ func (b *Builder) Generate() error {
 }
 
 func (b *Builder) Build() error {
-	c := b.CMakeCmd
-	if c == "" {
-		c = "cmake"
-	}
-	cmd := exec.Command(c, b.EffectiveBuildArgs()...)
+	cmd := exec.Command(b.cmakeCmd(), b.EffectiveBuildArgs()...)
 	cmd.Stdout = b.Stdout
 	cmd.Stderr = b.Stderr
+	cmd.Env = b.Env
+	if b.Diagnostics != nil {
+		cmd.Env = diag.ForceLocale(b.Env, nil)
+		return b.Diagnostics.Run(cmd)
+	}
 	return cmd.Run()
 }
@@ -0,0 +1,28 @@
+package cmake
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuilder_EffectiveBuildArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		b    Builder
+		want string
+	}{
+		{"defaults", Builder{BuildDir: "build"}, "--build build --config Release --parallel"},
+		{"jobs", Builder{BuildDir: "build", BuildType: Debug, Jobs: 8}, "--build build --config Debug --parallel 8"},
+		{"targets", Builder{BuildDir: "build", BuildTargets: []string{"app", "tests"}}, "--build build --config Release --target app --target tests --parallel"},
+		{"build flags", Builder{BuildDir: "build", BuildFlags: []string{"--clean-first", "--verbose"}}, "--build build --config Release --parallel --clean-first --verbose"},
+		{"jobs and build flags", Builder{BuildDir: "build", BuildType: RelWithDebInfo, Jobs: 4, BuildFlags: []string{"--clean-first", "--", "-k", "0"}},
+			"--build build --config RelWithDebInfo --parallel 4 --clean-first -- -k 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(tt.b.EffectiveBuildArgs(), " "); got != tt.want {
+				t.Errorf("EffectiveBuildArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package cmake
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// CacheFileName is the name of the cache in the build directory
+const CacheFileName = "CMakeCache.txt"
+
+// CacheVar is an entry of CMakeCache.txt
+type CacheVar struct {
+	Name     string `json:"name" yaml:"name"`
+	Type     string `json:"type" yaml:"type"` // BOOL, PATH, FILEPATH, STRING, INTERNAL, STATIC, UNINITIALIZED
+	Value    string `json:"value" yaml:"value"`
+	Help     string `json:"help,omitempty" yaml:"help,omitempty"`
+	Advanced bool   `json:"advanced,omitempty" yaml:"advanced,omitempty"`
+}
+
+// CacheFile is the contents of CMakeCache.txt. The NAME-ADVANCED:INTERNAL
+// markers are folded into the Advanced flag of the variables they mark.
+type CacheFile struct {
+	Vars []*CacheVar
+}
+
+// ReadCacheFile reads a CMakeCache.txt file
+func ReadCacheFile(fn string) (*CacheFile, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := ParseCacheFile(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return c, nil
+}
+
+// ParseCacheFile parses the contents of a CMakeCache.txt file
+func ParseCacheFile(r io.Reader) (*CacheFile, error) {
+	c := &CacheFile{}
+	advanced := []string{}
+	help := []string{}
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<24)
+	for n := 1; s.Scan(); n++ {
+		ln := strings.TrimRight(s.Text(), "\r")
+		switch {
+		case strings.HasPrefix(ln, "//"):
+			help = append(help, ln[2:])
+			continue
+		case strings.TrimSpace(ln) == "" || strings.HasPrefix(ln, "#"):
+			help = help[:0]
+			continue
+		}
+		v, err := parseCacheLine(ln)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		v.Help = strings.Join(help, "\n")
+		help = help[:0]
+		if name, ok := strings.CutSuffix(v.Name, "-ADVANCED"); ok && v.Type == "INTERNAL" {
+			if v.Value == "1" {
+				advanced = append(advanced, name)
+			}
+			continue
+		}
+		c.Vars = append(c.Vars, v)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	for _, name := range advanced {
+		if v := c.Get(name); v != nil {
+			v.Advanced = true
+		}
+	}
+	return c, nil
+}
+
+// parseCacheLine parses NAME:TYPE=VALUE, the name may be quoted
+func parseCacheLine(ln string) (*CacheVar, error) {
+	v := &CacheVar{}
+	rest := ln
+	if strings.HasPrefix(ln, `"`) {
+		end := strings.IndexByte(ln[1:], '"')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated name in '%s'", ln)
+		}
+		v.Name, rest = ln[1:end+1], ln[end+2:]
+	} else {
+		i := strings.IndexAny(ln, ":=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid entry '%s'", ln)
+		}
+		v.Name, rest = ln[:i], ln[i:]
+	}
+	if t, ok := strings.CutPrefix(rest, ":"); ok {
+		i := strings.IndexByte(t, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid entry '%s'", ln)
+		}
+		v.Type, rest = t[:i], t[i:]
+	} else {
+		v.Type = "UNINITIALIZED"
+	}
+	value, ok := strings.CutPrefix(rest, "=")
+	if !ok {
+		return nil, fmt.Errorf("invalid entry '%s'", ln)
+	}
+	// values with trailing spaces are quoted
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		value = value[1 : len(value)-1]
+	}
+	v.Value = value
+	return v, nil
+}
+
+// Get returns the variable with the specified name
+func (c *CacheFile) Get(name string) *CacheVar {
+	for _, v := range c.Vars {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// Set sets the type and the value of a variable, adding it if necessary
+func (c *CacheFile) Set(name, typ, value string) *CacheVar {
+	v := c.Get(name)
+	if v == nil {
+		v = &CacheVar{Name: name}
+		c.Vars = append(c.Vars, v)
+	}
+	v.Type, v.Value = typ, value
+	return v
+}
+
+// Remove removes a variable
+func (c *CacheFile) Remove(name string) bool {
+	for i, v := range c.Vars {
+		if v.Name == name {
+			c.Vars = append(c.Vars[:i], c.Vars[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes the cache in the CMakeCache.txt format: external entries
+// first, then the internal entries and the advanced markers
+func (c *CacheFile) Write(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	buf.WriteString("# This is the CMakeCache file.\n\n")
+	buf.WriteString("########################\n# EXTERNAL cache entries\n########################\n\n")
+	for _, v := range c.Vars {
+		if v.Type != "INTERNAL" {
+			writeCacheVar(buf, v)
+		}
+	}
+	buf.WriteString("\n########################\n# INTERNAL cache entries\n########################\n\n")
+	for _, v := range c.Vars {
+		if v.Advanced {
+			writeCacheVar(buf, &CacheVar{Name: v.Name + "-ADVANCED", Type: "INTERNAL", Value: "1", Help: "ADVANCED property for variable: " + v.Name})
+		}
+	}
+	for _, v := range c.Vars {
+		if v.Type == "INTERNAL" {
+			writeCacheVar(buf, v)
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeCacheVar(buf *bytes.Buffer, v *CacheVar) {
+	if v.Help != "" {
+		for _, ln := range strings.Split(v.Help, "\n") {
+			buf.WriteString("//" + ln + "\n")
+		}
+	}
+	name := v.Name
+	if strings.ContainsAny(name, ":=\"") {
+		name = `"` + name + `"`
+	}
+	value := v.Value
+	if strings.HasSuffix(value, " ") || strings.HasPrefix(value, "'") {
+		value = "'" + value + "'"
+	}
+	fmt.Fprintf(buf, "%s:%s=%s\n", name, v.Type, value)
+	if v.Type != "INTERNAL" {
+		buf.WriteByte('\n')
+	}
+}
+
+// WriteFile writes the cache to a file
+func (c *CacheFile) WriteFile(fn string) error {
+	buf := &bytes.Buffer{}
+	if err := c.Write(buf); err != nil {
+		return err
+	}
+	return os.WriteFile(fn, buf.Bytes(), 0666)
+}
+
+// StaleCachePolicy selects what Generate does when the build directory was
+// configured with settings that differ from the requested ones
+type StaleCachePolicy int
+
+const (
+	StaleCacheIgnore      = StaleCachePolicy(iota) // leave it to cmake, the default
+	StaleCacheFail                                 // fail with ErrStaleCache
+	StaleCacheReconfigure                          // remove the cache and configure from scratch
+)
+
+// CacheMismatch is a setting of the build directory that differs from the
+// requested one
+type CacheMismatch struct {
+	Name      string `json:"name" yaml:"name"`
+	Cached    string `json:"cached" yaml:"cached"`
+	Requested string `json:"requested" yaml:"requested"`
+}
+
+// ErrStaleCache is returned by Generate when the build directory was
+// configured with different settings
+type ErrStaleCache struct {
+	BuildDir   string
+	Mismatches []CacheMismatch
+}
+
+func (e *ErrStaleCache) Error() string {
+	ss := []string{}
+	for _, m := range e.Mismatches {
+		ss = append(ss, fmt.Sprintf("%s is '%s', requested '%s'", m.Name, m.Cached, m.Requested))
+	}
+	return fmt.Sprintf("%s was configured with different settings (%s), remove the build directory or use a different one", e.BuildDir, strings.Join(ss, "; "))
+}
+
+// requestedDefine returns the value of a -D flag in GenerateFlags, the last
+// one wins like on the cmake command line
+func (b *Builder) requestedDefine(name string) (string, bool) {
+	value, found := "", false
+	for _, fl := range b.GenerateFlags {
+		def, v, ok := strings.Cut(strings.TrimPrefix(fl, "-D"), "=")
+		if !ok || !strings.HasPrefix(fl, "-D") {
+			continue
+		}
+		if n, _, _ := strings.Cut(def, ":"); n == name {
+			value, found = v, true
+		}
+	}
+	return value, found
+}
+
+// CacheMismatches compares the cache of the build directory with the
+// builder settings: the source directory, the generator with its platform
+// and toolset, the compilers, the toolchain file and, for single-config
+// generators, the build type. It returns nil if the build directory has not
+// been configured yet.
+func (b *Builder) CacheMismatches() ([]CacheMismatch, error) {
+	fn := filepath.Join(b.BuildDir, CacheFileName)
+	if _, err := os.Stat(fn); os.IsNotExist(err) {
+		return nil, nil
+	}
+	c, err := ReadCacheFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	ret := []CacheMismatch{}
+	check := func(name, requested string, isPath bool) {
+		v := c.Get(name)
+		if v == nil {
+			return
+		}
+		same := v.Value == requested
+		if isPath {
+			same = v.Value != "" && requested != "" && samePath(v.Value, requested)
+		}
+		if !same {
+			ret = append(ret, CacheMismatch{Name: name, Cached: v.Value, Requested: requested})
+		}
+	}
+	if b.SourceDir != "" {
+		if src, err := filepath.Abs(b.SourceDir); err == nil {
+			check("CMAKE_HOME_DIRECTORY", src, true)
+		}
+	}
+	if b.Generator != nil && b.Generator.Name != "" {
+		check("CMAKE_GENERATOR", b.Generator.Name, false)
+		check("CMAKE_GENERATOR_PLATFORM", b.Generator.Arch, false)
+		check("CMAKE_GENERATOR_TOOLSET", b.Generator.Toolset, false)
+	}
+	for _, name := range []string{"CMAKE_C_COMPILER", "CMAKE_CXX_COMPILER", "CMAKE_TOOLCHAIN_FILE"} {
+		if v, ok := b.requestedDefine(name); ok {
+			// subcommand compilers are lists, the cache has the executable
+			v, _, _ = strings.Cut(v, ";")
+			if !filepath.IsAbs(v) {
+				if p, err := exec.LookPath(v); err == nil {
+					v = p
+				}
+			}
+			check(name, v, true)
+		}
+	}
+	if v := c.Get("CMAKE_CONFIGURATION_TYPES"); v == nil || v.Value == "" {
+		check("CMAKE_BUILD_TYPE", b.BuildType.String(), false)
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	return ret, nil
+}
+
+// checkCache applies the stale cache policy before generating
+func (b *Builder) checkCache() error {
+	if b.StaleCache == StaleCacheIgnore {
+		return nil
+	}
+	mm, err := b.CacheMismatches()
+	if err != nil || len(mm) == 0 {
+		return err
+	}
+	if b.StaleCache == StaleCacheFail {
+		return &ErrStaleCache{BuildDir: b.BuildDir, Mismatches: mm}
+	}
+	// what cmake --fresh does, which needs cmake 3.24
+	if err := os.Remove(filepath.Join(b.BuildDir, CacheFileName)); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(b.BuildDir, "CMakeFiles"))
+}
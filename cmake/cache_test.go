@@ -0,0 +1,172 @@
+package cmake
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testCache = `# This is the CMakeCache file.
+# For build in directory: /src/demo/build
+
+########################
+# EXTERNAL cache entries
+########################
+
+//Path to a program.
+CMAKE_AR:FILEPATH=/usr/bin/ar
+
+//Choose the type of build, options are: None Debug Release RelWithDebInfo
+// MinSizeRel ...
+CMAKE_BUILD_TYPE:STRING=Debug
+
+//CXX compiler
+CMAKE_CXX_COMPILER:FILEPATH=/usr/bin/c++
+
+//Value with a trailing space
+PADDED:STRING=' x '
+
+"WEIRD:NAME":BOOL=ON
+
+UNTYPED=1
+
+########################
+# INTERNAL cache entries
+########################
+
+//ADVANCED property for variable: CMAKE_AR
+CMAKE_AR-ADVANCED:INTERNAL=1
+//Name of generator.
+CMAKE_GENERATOR:INTERNAL=Ninja
+//Generator instance identifier.
+CMAKE_GENERATOR_INSTANCE:INTERNAL=
+//Name of generator platform.
+CMAKE_GENERATOR_PLATFORM:INTERNAL=
+//Name of generator toolset.
+CMAKE_GENERATOR_TOOLSET:INTERNAL=
+//Source directory with the top level CMakeLists.txt file for this
+// project
+CMAKE_HOME_DIRECTORY:INTERNAL=/src/demo
+`
+
+func TestParseCacheFile(t *testing.T) {
+	c, err := ParseCacheFile(strings.NewReader(testCache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []CacheVar{
+		{Name: "CMAKE_AR", Type: "FILEPATH", Value: "/usr/bin/ar", Help: "Path to a program.", Advanced: true},
+		{Name: "CMAKE_BUILD_TYPE", Type: "STRING", Value: "Debug", Help: "Choose the type of build, options are: None Debug Release RelWithDebInfo\n MinSizeRel ..."},
+		{Name: "PADDED", Type: "STRING", Value: " x ", Help: "Value with a trailing space"},
+		{Name: "WEIRD:NAME", Type: "BOOL", Value: "ON"},
+		{Name: "UNTYPED", Type: "UNINITIALIZED", Value: "1"},
+		{Name: "CMAKE_GENERATOR", Type: "INTERNAL", Value: "Ninja", Help: "Name of generator."},
+		{Name: "CMAKE_GENERATOR_PLATFORM", Type: "INTERNAL", Value: "", Help: "Name of generator platform."},
+	}
+	for _, tt := range tests {
+		v := c.Get(tt.Name)
+		if v == nil {
+			t.Errorf("Get(%s) = nil", tt.Name)
+			continue
+		}
+		if *v != tt {
+			t.Errorf("Get(%s) = %+v, want %+v", tt.Name, *v, tt)
+		}
+	}
+	if c.Get("CMAKE_AR-ADVANCED") != nil {
+		t.Errorf("advanced marker is listed as a variable")
+	}
+
+	// the written cache reads back the same
+	buf := &bytes.Buffer{}
+	if err := c.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	c2, err := ParseCacheFile(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c2.Vars) != len(c.Vars) {
+		t.Fatalf("read back %d variables, want %d", len(c2.Vars), len(c.Vars))
+	}
+	for _, v := range c.Vars {
+		if v2 := c2.Get(v.Name); v2 == nil || *v2 != *v {
+			t.Errorf("read back %+v, want %+v", v2, *v)
+		}
+	}
+
+	if _, err := ParseCacheFile(strings.NewReader("NOVALUE:STRING\n")); err == nil {
+		t.Errorf("ParseCacheFile() accepted an entry without a value")
+	}
+}
+
+func TestBuilder_CacheMismatches(t *testing.T) {
+	src, dir := t.TempDir(), t.TempDir()
+	c, err := ParseCacheFile(strings.NewReader(testCache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("CMAKE_HOME_DIRECTORY", "INTERNAL", src)
+	c.Set("CMAKE_C_COMPILER", "FILEPATH", "/usr/bin/cc")
+	c.Set("CMAKE_TOOLCHAIN_FILE", "FILEPATH", filepath.Join(dir, "toolchain.cmake"))
+	if err := c.WriteFile(filepath.Join(dir, CacheFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	b := &Builder{SourceDir: src, BuildDir: dir, BuildType: Debug, Generator: NinjaGenerator(), GenerateFlags: []string{
+		"-DCMAKE_C_COMPILER:FILEPATH=/usr/bin/cc",
+		"-DCMAKE_TOOLCHAIN_FILE:FILEPATH=" + filepath.Join(dir, "toolchain.cmake"),
+	}}
+	if mm, err := b.CacheMismatches(); err != nil || mm != nil {
+		t.Errorf("CacheMismatches() = %v, %v, want none", mm, err)
+	}
+
+	b.BuildType = Release
+	b.Generator = &Generator{Name: "Unix Makefiles"}
+	b.GenerateFlags = append(b.GenerateFlags, "-DCMAKE_C_COMPILER:FILEPATH=/opt/cross/bin/gcc")
+	mm, err := b.CacheMismatches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, m := range mm {
+		names = append(names, m.Name)
+	}
+	if got := strings.Join(names, ","); got != "CMAKE_GENERATOR,CMAKE_C_COMPILER,CMAKE_BUILD_TYPE" {
+		t.Errorf("CacheMismatches() = %s", got)
+	}
+
+	// the default policy leaves the cache to cmake
+	b.CMakeCmd = filepath.Join(dir, "no-such-cmake")
+	var stale *ErrStaleCache
+	if err := b.Generate(); err == nil || errors.As(err, &stale) {
+		t.Errorf("Generate() = %v, want the cmake error", err)
+	}
+
+	// failing is opted in to and happens before running cmake
+	b.StaleCache = StaleCacheFail
+	if err := b.Generate(); !errors.As(err, &stale) || len(stale.Mismatches) != 3 {
+		t.Errorf("Generate() = %v, want ErrStaleCache", err)
+	}
+
+	// reconfiguring removes the cache
+	truePath, err := exec.LookPath("true")
+	if err != nil {
+		t.Skip(err)
+	}
+	os.MkdirAll(filepath.Join(dir, "CMakeFiles"), 0777)
+	b.CMakeCmd = truePath
+	b.StaleCache = StaleCacheReconfigure
+	if err := b.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range []string{CacheFileName, "CMakeFiles"} {
+		if _, err := os.Stat(filepath.Join(dir, fn)); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", fn)
+		}
+	}
+}
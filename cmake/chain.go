@@ -0,0 +1,316 @@
+package cmake
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/env"
+	"github.com/adnsv/go-build/host"
+	"github.com/adnsv/go-utils/filesystem"
+)
+
+// Options controls how NewBuilderFor sets up the builder
+type Options struct {
+	CMakeCmd string
+	Stdout   io.Writer
+	Stderr   io.Writer
+
+	SourceDir    string
+	BuildDir     string
+	BuildType    BuildType
+	BuildTargets []string
+
+	GenerateFlags []string   // extra -DVAR=VALUE pairs
+	BuildFlags    []string   // extra build arguments
+	Generator     *Generator // overrides the generator derived from the chain
+
+	// ToolchainFile is where the cross toolchain file is written, defaults
+	// to toolchain.cmake in BuildDir
+	ToolchainFile string
+}
+
+// NewBuilderFor creates a builder that uses the toolchain:
+//   - msvc chains that belong to a Visual Studio instance use the Visual
+//     Studio generator with the platform (-A) and toolset (-T) of the chain,
+//     other chains use Ninja when it is available
+//   - the compilers, archiver and resource compiler are passed as cache
+//     variables
+//   - for targets that do not run on the host a toolchain file with the
+//     target system, processor and sysroot is written; emscripten and
+//     wasi-sdk chains use the toolchain files they come with
+//   - commands run with the chain environment
+func NewBuilderFor(tc *toolchain.Chain, opts Options) (*Builder, error) {
+	b := &Builder{
+		CMakeCmd:      opts.CMakeCmd,
+		Stdout:        opts.Stdout,
+		Stderr:        opts.Stderr,
+		Env:           tc.Env(),
+		SourceDir:     opts.SourceDir,
+		BuildDir:      opts.BuildDir,
+		BuildType:     opts.BuildType,
+		BuildTargets:  opts.BuildTargets,
+		BuildFlags:    opts.BuildFlags,
+		GenerateFlags: []string{},
+		Generator:     opts.Generator,
+	}
+	if b.Generator == nil {
+		b.Generator = ChainGenerator(tc)
+	}
+	isVS := strings.HasPrefix(b.Generator.Name, "Visual Studio")
+
+	if fn := bundledToolchainFile(tc); fn != "" {
+		b.GenerateFlags = append(b.GenerateFlags, "-DCMAKE_TOOLCHAIN_FILE:FILEPATH="+fn)
+	} else if !isVS && !host.Current().CanRun(tc.Target.Target) {
+		content, err := ToolchainFile(tc)
+		if err != nil {
+			return nil, err
+		}
+		fn := opts.ToolchainFile
+		if fn == "" {
+			fn = filepath.Join(opts.BuildDir, "toolchain.cmake")
+		}
+		if err := os.MkdirAll(filepath.Dir(fn), 0777); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(fn, []byte(content), 0666); err != nil {
+			return nil, err
+		}
+		b.GenerateFlags = append(b.GenerateFlags, "-DCMAKE_TOOLCHAIN_FILE:FILEPATH="+filepath.ToSlash(fn))
+	} else if !isVS {
+		for _, v := range toolVariables(tc) {
+			b.GenerateFlags = append(b.GenerateFlags, "-D"+v[0]+":FILEPATH="+v[1])
+		}
+	}
+	b.GenerateFlags = append(b.GenerateFlags, opts.GenerateFlags...)
+	return b, nil
+}
+
+// visualStudioGenerators maps Visual Studio major versions to the CMake
+// generator names
+var visualStudioGenerators = map[string]string{
+	"14": "Visual Studio 14 2015",
+	"15": "Visual Studio 15 2017",
+	"16": "Visual Studio 16 2019",
+	"17": "Visual Studio 17 2022",
+	"18": "Visual Studio 18 2026",
+}
+
+// ChainGenerator picks the generator for the toolchain. Visual Studio
+// generators are only used for msvc chains of a Visual Studio instance, as
+// they ignore the compilers passed in cache variables.
+func ChainGenerator(tc *toolchain.Chain) *Generator {
+	if tc.IsMSVC() && tc.VisualStudioID != "" {
+		major, _, _ := strings.Cut(tc.Version, ".")
+		if name, ok := visualStudioGenerators[major]; ok {
+			g := MSVCGenerator(name, tc.VisualStudioArch, "")
+			if tc.ToolsetVersion != "" {
+				g.Toolset = "version=" + tc.ToolsetVersion
+			}
+			return g
+		}
+	}
+	if _, err := exec.LookPath("ninja"); err == nil {
+		return NinjaGenerator()
+	}
+	if runtime.GOOS == "windows" && !tc.IsMSVC() {
+		return &Generator{Name: "MinGW Makefiles"}
+	}
+	if tc.IsMSVC() {
+		return &Generator{Name: "NMake Makefiles"}
+	}
+	return &Generator{Name: "Unix Makefiles"}
+}
+
+// cmakeTools lists the cache variables that select chain tools
+var cmakeTools = []struct {
+	name string
+	tool toolchain.Tool
+}{
+	{"CMAKE_C_COMPILER", toolchain.CCompiler},
+	{"CMAKE_CXX_COMPILER", toolchain.CXXCompiler},
+	{"CMAKE_AR", toolchain.Archiver},
+	{"CMAKE_RANLIB", toolchain.Ranlib},
+	{"CMAKE_RC_COMPILER", toolchain.ResourceCompiler},
+	{"CMAKE_MT", toolchain.ManifestTool},
+}
+
+// toolVariables returns the cache variables for the chain tools. Tools that
+// are subcommands (zig cc) are spelled as lists, which CMake accepts only for
+// the compilers.
+func toolVariables(tc *toolchain.Chain) [][2]string {
+	ret := [][2]string{}
+	for _, v := range cmakeTools {
+		tp := tc.Tools[v.tool]
+		if tp == "" {
+			continue
+		}
+		isCompiler := v.tool == toolchain.CCompiler || v.tool == toolchain.CXXCompiler
+		if tp.HasCommands() && !isCompiler {
+			continue
+		}
+		ret = append(ret, [2]string{v.name, strings.Join(append([]string{filepath.ToSlash(tp.Path())}, tp.Commands()...), ";")})
+	}
+	return ret
+}
+
+// ToolchainFile returns the contents of a CMake toolchain file for cross
+// compiling with the chain
+func ToolchainFile(tc *toolchain.Chain) (string, error) {
+	sys, err := tc.Target.CMake()
+	if err != nil {
+		return "", err
+	}
+	w := &strings.Builder{}
+	fmt.Fprintf(w, "# generated by go-build for %s %s targeting %s\n", tc.Compiler, tc.Version, tc.Target.Original)
+	fmt.Fprintf(w, "set(CMAKE_SYSTEM_NAME %s)\n", sys.Name)
+	fmt.Fprintf(w, "set(CMAKE_SYSTEM_PROCESSOR %s)\n", sys.Processor)
+	if v := tc.Target.AndroidAPI(); v > 0 {
+		fmt.Fprintf(w, "set(CMAKE_SYSTEM_VERSION %d)\n", v)
+	}
+	if tc.Sysroot != "" {
+		fmt.Fprintf(w, "set(CMAKE_SYSROOT \"%s\")\n", filepath.ToSlash(tc.Sysroot))
+	}
+	for _, v := range toolVariables(tc) {
+		fmt.Fprintf(w, "set(%s \"%s\")\n", v[0], v[1])
+	}
+	if tc.Compiler == "clang" && tc.Target.Original != "" {
+		fmt.Fprintf(w, "set(CMAKE_C_COMPILER_TARGET %s)\n", tc.Target.Original)
+		fmt.Fprintf(w, "set(CMAKE_CXX_COMPILER_TARGET %s)\n", tc.Target.Original)
+	}
+	if tc.Sysroot != "" {
+		fmt.Fprintf(w, "set(CMAKE_FIND_ROOT_PATH \"%s\")\n", filepath.ToSlash(tc.Sysroot))
+	}
+	w.WriteString("set(CMAKE_FIND_ROOT_PATH_MODE_PROGRAM NEVER)\n")
+	w.WriteString("set(CMAKE_FIND_ROOT_PATH_MODE_LIBRARY ONLY)\n")
+	w.WriteString("set(CMAKE_FIND_ROOT_PATH_MODE_INCLUDE ONLY)\n")
+	w.WriteString("set(CMAKE_FIND_ROOT_PATH_MODE_PACKAGE ONLY)\n")
+	return w.String(), nil
+}
+
+// bundledToolchainFile returns the toolchain file that comes with
+// emscripten and wasi-sdk chains
+func bundledToolchainFile(tc *toolchain.Chain) string {
+	cc := tc.Tools[toolchain.CCompiler].Path()
+	if cc == "" {
+		return ""
+	}
+	var fn string
+	switch tc.Implementation {
+	case "emscripten":
+		fn = filepath.Join(filepath.Dir(cc), "cmake", "Modules", "Platform", "Emscripten.cmake")
+	case "wasi-sdk":
+		name := "wasi-sdk.cmake"
+		switch {
+		case tc.Target.HasThreads():
+			name = "wasi-sdk-pthread.cmake"
+		case tc.Target.WASIPreview() == 2:
+			name = "wasi-sdk-p2.cmake"
+		}
+		fn = filepath.Join(filepath.Dir(filepath.Dir(cc)), "share", "cmake", name)
+	default:
+		return ""
+	}
+	if !filesystem.FileExists(fn) {
+		return ""
+	}
+	return filepath.ToSlash(fn)
+}
+
+// ChainPresets returns a presets file with a configure, a build and a test
+// preset for each combination of chain and build type. The build
+// directories are ${sourceDir}/build/<preset>, and the toolchain files of
+// cross chains are written to build/toolchains in the source directory.
+func ChainPresets(chains []*toolchain.Chain, types []BuildType, sourceDir string) (*PresetsFile, error) {
+	sourceDir, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+	f := &PresetsFile{Version: 3, CMakeMinimumRequired: &PresetsCMake{Major: 3, Minor: 21}}
+	used := map[string]bool{}
+	for _, tc := range chains {
+		base := chainPresetName(tc)
+		name := base
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[name] = true
+
+		for _, bt := range types {
+			pn := name + "-" + strings.ToLower(bt.String())
+			b, err := NewBuilderFor(tc, Options{
+				SourceDir:     sourceDir,
+				BuildDir:      filepath.Join(sourceDir, "build", pn),
+				BuildType:     bt,
+				ToolchainFile: filepath.Join(sourceDir, "build", "toolchains", name+".cmake"),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", pn, err)
+			}
+			cp := &ConfigurePreset{
+				PresetCommon: PresetCommon{
+					Name:        pn,
+					DisplayName: fmt.Sprintf("%s %s %s (%s)", tc.Compiler, tc.Version, tc.Target.Original, bt),
+					Description: "go-build toolchain " + name,
+				},
+				Generator:      b.Generator.Name,
+				BinaryDir:      "${sourceDir}/build/" + pn,
+				CacheVariables: map[string]*CacheVariable{"CMAKE_BUILD_TYPE": {Type: "STRING", Value: bt.String()}},
+			}
+			if b.Generator.Arch != "" {
+				cp.Architecture = &PresetValue{Value: b.Generator.Arch}
+			}
+			if b.Generator.Toolset != "" {
+				cp.Toolset = &PresetValue{Value: b.Generator.Toolset}
+			}
+			for _, fl := range b.GenerateFlags {
+				def, value, _ := strings.Cut(strings.TrimPrefix(fl, "-D"), "=")
+				n, typ, _ := strings.Cut(def, ":")
+				if rel, err := filepath.Rel(sourceDir, filepath.FromSlash(value)); err == nil && filepath.IsAbs(value) && !strings.HasPrefix(rel, "..") {
+					value = "${sourceDir}/" + filepath.ToSlash(rel)
+				}
+				if n == "CMAKE_TOOLCHAIN_FILE" {
+					cp.ToolchainFile = value
+					continue
+				}
+				cp.CacheVariables[n] = &CacheVariable{Type: typ, Value: value}
+			}
+			if len(tc.Environment) > 0 {
+				cp.Environment = map[string]*string{}
+				for k, v := range env.Split(tc.Environment) {
+					cp.Environment[k] = &v
+				}
+			}
+			f.ConfigurePresets = append(f.ConfigurePresets, cp)
+			f.BuildPresets = append(f.BuildPresets, &BuildPreset{
+				PresetCommon:    PresetCommon{Name: pn, DisplayName: cp.DisplayName},
+				ConfigurePreset: pn,
+				Configuration:   bt.String(),
+			})
+			f.TestPresets = append(f.TestPresets, &TestPreset{
+				PresetCommon:    PresetCommon{Name: pn, DisplayName: cp.DisplayName},
+				ConfigurePreset: pn,
+				Configuration:   bt.String(),
+			})
+		}
+	}
+	return f, nil
+}
+
+// chainPresetName names the presets of a chain after its registry name, or
+// after the compiler, version and target
+func chainPresetName(tc *toolchain.Chain) string {
+	if tc.Name != "" {
+		return presetName(tc.Name)
+	}
+	t := tc.Target.Original
+	if t == "" {
+		t = tc.Target.Text()
+	}
+	return presetName(tc.Compiler + "-" + tc.Version + "-" + t)
+}
@@ -0,0 +1,121 @@
+package cmake
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/adnsv/go-build/host"
+)
+
+func TestNewBuilderFor_MSVC(t *testing.T) {
+	tc := &toolchain.Chain{
+		Compiler:         "msvc",
+		Version:          "17.8.34330.188",
+		Target:           triplet.Full{Target: triplet.Target{Arch: "arm64", OS: "windows", ObjectFormat: triplet.PE, Environment: "msvc", LibC: "msvcrt"}},
+		VisualStudioID:   "a1b2c3",
+		VisualStudioArch: "x64_arm64",
+		ToolsetVersion:   "14.38.33130",
+		Environment:      []string{"INCLUDE=C:/include"},
+	}
+	b, err := NewBuilderFor(tc, Options{SourceDir: "src", BuildDir: "build"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(b.EffectiveGenerateArgs(), " ")
+	want := "-S src -B build -DCMAKE_BUILD_TYPE:STRING=Release -G Visual Studio 17 2022 -A ARM64 -T version=14.38.33130"
+	if got != want {
+		t.Errorf("EffectiveGenerateArgs() = %q, want %q", got, want)
+	}
+	found := false
+	for _, v := range b.Env {
+		found = found || v == "INCLUDE=C:/include"
+	}
+	if !found {
+		t.Errorf("Env does not include the chain environment")
+	}
+}
+
+func TestNewBuilderFor_Native(t *testing.T) {
+	tc := &toolchain.Chain{
+		Compiler: "gcc",
+		Version:  "12.2.0",
+		Target:   triplet.Full{Target: host.Current().Target()},
+		Tools: toolchain.Toolset{
+			toolchain.CCompiler:   "/usr/bin/gcc-12",
+			toolchain.CXXCompiler: "/usr/bin/g++-12",
+			toolchain.Archiver:    "/usr/bin/gcc-ar-12",
+		},
+	}
+	b, err := NewBuilderFor(tc, Options{SourceDir: "src", BuildDir: "build", BuildType: Debug, Generator: NinjaGenerator()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(b.EffectiveGenerateArgs(), " ")
+	want := "-S src -B build -DCMAKE_BUILD_TYPE:STRING=Debug" +
+		" -DCMAKE_C_COMPILER:FILEPATH=/usr/bin/gcc-12 -DCMAKE_CXX_COMPILER:FILEPATH=/usr/bin/g++-12" +
+		" -DCMAKE_AR:FILEPATH=/usr/bin/gcc-ar-12 -G Ninja"
+	if got != want {
+		t.Errorf("EffectiveGenerateArgs() = %q, want %q", got, want)
+	}
+
+	zig := &toolchain.Chain{
+		Compiler: "clang",
+		Target:   tc.Target,
+		Tools: toolchain.Toolset{
+			toolchain.CCompiler: toolchain.NewToolPath("/opt/zig/zig", "cc"),
+			toolchain.Archiver:  toolchain.NewToolPath("/opt/zig/zig", "ar"),
+		},
+	}
+	vars := toolVariables(zig)
+	if len(vars) != 1 || vars[0] != [2]string{"CMAKE_C_COMPILER", "/opt/zig/zig;cc"} {
+		t.Errorf("toolVariables() = %v", vars)
+	}
+}
+
+func TestNewBuilderFor_Cross(t *testing.T) {
+	target, _ := triplet.ParseFull("aarch64-linux-musl")
+	if host.Current().CanRun(target.Target) {
+		target, _ = triplet.ParseFull("riscv64-linux-gnu")
+	}
+	tc := &toolchain.Chain{
+		Compiler: "clang",
+		Version:  "18.1.8",
+		Target:   target,
+		Sysroot:  "/opt/sysroot",
+		Tools: toolchain.Toolset{
+			toolchain.CCompiler:   "/usr/bin/clang",
+			toolchain.CXXCompiler: "/usr/bin/clang++",
+		},
+	}
+	dir := t.TempDir()
+	b, err := NewBuilderFor(tc, Options{SourceDir: "src", BuildDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(dir, "toolchain.cmake")
+	if want := "-DCMAKE_TOOLCHAIN_FILE:FILEPATH=" + filepath.ToSlash(fn); len(b.GenerateFlags) != 1 || b.GenerateFlags[0] != want {
+		t.Errorf("GenerateFlags = %v, want %s", b.GenerateFlags, want)
+	}
+	buf, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(buf)
+	sys, _ := target.CMake()
+	for _, s := range []string{
+		"set(CMAKE_SYSTEM_NAME Linux)",
+		"set(CMAKE_SYSTEM_PROCESSOR " + sys.Processor + ")",
+		`set(CMAKE_SYSROOT "/opt/sysroot")`,
+		`set(CMAKE_C_COMPILER "/usr/bin/clang")`,
+		"set(CMAKE_C_COMPILER_TARGET " + target.Original + ")",
+		"set(CMAKE_FIND_ROOT_PATH_MODE_PROGRAM NEVER)",
+	} {
+		if !strings.Contains(content, s) {
+			t.Errorf("toolchain file does not contain %q:\n%s", s, content)
+		}
+	}
+}
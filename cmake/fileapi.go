@@ -0,0 +1,476 @@
+package cmake
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+)
+
+// The CMake File API (cmake-file-api(7)) lets clients ask CMake for a
+// description of the generated build system. Generate writes a query into
+// <build>/.cmake/api/v1/query, CMake writes the replies into
+// <build>/.cmake/api/v1/reply, and ReadFileAPI reads them back.
+
+// fileAPIClient is the name of the stateful query client
+const fileAPIClient = "client-go-build"
+
+// File API object kinds and the major versions requested
+var fileAPIRequests = []fileAPIVersionedKind{
+	{Kind: "codemodel", Version: fileAPIVersion{Major: 2}},
+	{Kind: "cache", Version: fileAPIVersion{Major: 2}},
+	{Kind: "toolchains", Version: fileAPIVersion{Major: 1}},
+	{Kind: "cmakeFiles", Version: fileAPIVersion{Major: 1}},
+}
+
+type fileAPIVersion struct {
+	Major int `json:"major"`
+	Minor int `json:"minor,omitempty"`
+}
+
+type fileAPIVersionedKind struct {
+	Kind    string         `json:"kind"`
+	Version fileAPIVersion `json:"version"`
+}
+
+// FileAPI holds the replies to the File API query
+type FileAPI struct {
+	CMake      CMakeInfo   `json:"cmake"`
+	CodeModel  *CodeModel  `json:"codemodel,omitempty"`
+	Cache      *Cache      `json:"cache,omitempty"`
+	Toolchains *Toolchains `json:"toolchains,omitempty"`
+	CMakeFiles *CMakeFiles `json:"cmakeFiles,omitempty"`
+}
+
+// CMakeInfo describes the cmake that generated the build system
+type CMakeInfo struct {
+	Version struct {
+		Major  int    `json:"major"`
+		Minor  int    `json:"minor"`
+		Patch  int    `json:"patch"`
+		Suffix string `json:"suffix"`
+		String string `json:"string"`
+	} `json:"version"`
+	Paths struct {
+		CMake string `json:"cmake"`
+		CTest string `json:"ctest"`
+		CPack string `json:"cpack"`
+		Root  string `json:"root"`
+	} `json:"paths"`
+	Generator struct {
+		Name        string `json:"name"`
+		Platform    string `json:"platform,omitempty"`
+		MultiConfig bool   `json:"multiConfig"`
+	} `json:"generator"`
+}
+
+// CodeModel is the codemodel-v2 object, with the target and directory
+// objects that it references already loaded
+type CodeModel struct {
+	Paths struct {
+		Source string `json:"source"`
+		Build  string `json:"build"`
+	} `json:"paths"`
+	Configurations []*Configuration `json:"configurations"`
+}
+
+// Configuration is a build configuration (Debug, Release, ...) of the code
+// model
+type Configuration struct {
+	Name        string       `json:"name"`
+	Directories []*Directory `json:"directories"`
+	Projects    []struct {
+		Name             string `json:"name"`
+		ParentIndex      *int   `json:"parentIndex,omitempty"`
+		DirectoryIndexes []int  `json:"directoryIndexes"`
+		TargetIndexes    []int  `json:"targetIndexes,omitempty"`
+	} `json:"projects"`
+	Targets []*Target `json:"targets"`
+}
+
+// Directory is a build system directory with its install rules
+type Directory struct {
+	Source         string       `json:"source"`
+	Build          string       `json:"build"`
+	ParentIndex    *int         `json:"parentIndex,omitempty"`
+	ChildIndexes   []int        `json:"childIndexes,omitempty"`
+	ProjectIndex   int          `json:"projectIndex"`
+	TargetIndexes  []int        `json:"targetIndexes,omitempty"`
+	HasInstallRule bool         `json:"hasInstallRule,omitempty"`
+	JSONFile       string       `json:"jsonFile"`
+	Installers     []*Installer `json:"installers,omitempty"` // loaded from JSONFile
+}
+
+// Installer is an install() rule
+type Installer struct {
+	Component   string            `json:"component"`
+	Destination string            `json:"destination,omitempty"`
+	Paths       []json.RawMessage `json:"paths,omitempty"` // strings, or objects with from and to
+	Type        string            `json:"type"`            // file, directory, target, export, script, code, importedRuntimeArtifacts, runtimeDependencySet, fileSet, cxxModuleBmi
+	TargetID    string            `json:"targetId,omitempty"`
+	TargetIndex *int              `json:"targetIndex,omitempty"`
+	IsOptional  bool              `json:"isOptional,omitempty"`
+}
+
+// Target is a build system target, the codemodel reference merged with the
+// target object
+type Target struct {
+	Name           string `json:"name"`
+	ID             string `json:"id"`
+	Type           string `json:"type"` // EXECUTABLE, STATIC_LIBRARY, SHARED_LIBRARY, MODULE_LIBRARY, OBJECT_LIBRARY, INTERFACE_LIBRARY, UTILITY
+	DirectoryIndex int    `json:"directoryIndex"`
+	ProjectIndex   int    `json:"projectIndex"`
+	JSONFile       string `json:"jsonFile"`
+	NameOnDisk     string `json:"nameOnDisk,omitempty"`
+	Paths          struct {
+		Source string `json:"source"`
+		Build  string `json:"build"`
+	} `json:"paths"`
+	Artifacts []struct {
+		Path string `json:"path"` // relative to the top-level build directory unless absolute
+	} `json:"artifacts,omitempty"`
+	IsGeneratorProvided bool `json:"isGeneratorProvided,omitempty"`
+	Install             *struct {
+		Prefix struct {
+			Path string `json:"path"`
+		} `json:"prefix"`
+		Destinations []struct {
+			Path string `json:"path"`
+		} `json:"destinations"`
+	} `json:"install,omitempty"`
+	Link *struct {
+		Language         string            `json:"language"`
+		CommandFragments []CommandFragment `json:"commandFragments,omitempty"`
+		LTO              bool              `json:"lto,omitempty"`
+		Sysroot          *struct {
+			Path string `json:"path"`
+		} `json:"sysroot,omitempty"`
+	} `json:"link,omitempty"`
+	Archive *struct {
+		CommandFragments []CommandFragment `json:"commandFragments,omitempty"`
+		LTO              bool              `json:"lto,omitempty"`
+	} `json:"archive,omitempty"`
+	Dependencies []struct {
+		ID string `json:"id"`
+	} `json:"dependencies,omitempty"`
+	Sources []struct {
+		Path              string `json:"path"`
+		CompileGroupIndex *int   `json:"compileGroupIndex,omitempty"`
+		SourceGroupIndex  *int   `json:"sourceGroupIndex,omitempty"`
+		IsGenerated       bool   `json:"isGenerated,omitempty"`
+	} `json:"sources,omitempty"`
+	CompileGroups []*CompileGroup `json:"compileGroups,omitempty"`
+}
+
+// CompileGroup is a group of sources compiled with the same settings
+type CompileGroup struct {
+	SourceIndexes    []int  `json:"sourceIndexes"`
+	Language         string `json:"language"`
+	LanguageStandard *struct {
+		Standard string `json:"standard"`
+	} `json:"languageStandard,omitempty"`
+	CompileCommandFragments []CommandFragment `json:"compileCommandFragments,omitempty"`
+	Includes                []struct {
+		Path     string `json:"path"`
+		IsSystem bool   `json:"isSystem,omitempty"`
+	} `json:"includes,omitempty"`
+	Defines []struct {
+		Define string `json:"define"`
+	} `json:"defines,omitempty"`
+	Sysroot *struct {
+		Path string `json:"path"`
+	} `json:"sysroot,omitempty"`
+}
+
+// CommandFragment is a piece of a compile or link command line
+type CommandFragment struct {
+	Fragment string `json:"fragment"`
+	Role     string `json:"role,omitempty"` // flags, libraries, libraryPath, frameworkPath
+}
+
+// Cache is the cache-v2 object
+type Cache struct {
+	Entries []CacheEntry `json:"entries"`
+}
+
+// CacheEntry is a CMake cache variable
+type CacheEntry struct {
+	Name       string `json:"name"`
+	Value      string `json:"value"`
+	Type       string `json:"type"`
+	Properties []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"properties,omitempty"`
+}
+
+// Toolchains is the toolchains-v1 object, one entry per enabled language
+type Toolchains struct {
+	Toolchains []LanguageToolchain `json:"toolchains"`
+}
+
+// LanguageToolchain is the compiler that CMake chose for a language
+type LanguageToolchain struct {
+	Language string `json:"language"`
+	Compiler struct {
+		Path     string `json:"path,omitempty"`
+		ID       string `json:"id,omitempty"` // GNU, Clang, AppleClang, MSVC, ...
+		Version  string `json:"version,omitempty"`
+		Target   string `json:"target,omitempty"`
+		Implicit struct {
+			IncludeDirectories       []string `json:"includeDirectories,omitempty"`
+			LinkDirectories          []string `json:"linkDirectories,omitempty"`
+			LinkFrameworkDirectories []string `json:"linkFrameworkDirectories,omitempty"`
+			LinkLibraries            []string `json:"linkLibraries,omitempty"`
+		} `json:"implicit"`
+	} `json:"compiler"`
+	SourceFileExtensions []string `json:"sourceFileExtensions,omitempty"`
+}
+
+// CMakeFiles is the cmakeFiles-v1 object, the inputs that CMake read to
+// generate the build system
+type CMakeFiles struct {
+	Paths struct {
+		Source string `json:"source"`
+		Build  string `json:"build"`
+	} `json:"paths"`
+	Inputs []struct {
+		Path        string `json:"path"`
+		IsGenerated bool   `json:"isGenerated,omitempty"`
+		IsExternal  bool   `json:"isExternal,omitempty"`
+		IsCMake     bool   `json:"isCMake,omitempty"`
+	} `json:"inputs"`
+}
+
+func fileAPIDir(buildDir string) string {
+	return filepath.Join(buildDir, ".cmake", "api", "v1")
+}
+
+// WriteFileAPIQuery asks CMake to write the codemodel, cache, toolchains
+// and cmakeFiles replies during the next generation
+func WriteFileAPIQuery(buildDir string) error {
+	dir := filepath.Join(fileAPIDir(buildDir), "query", fileAPIClient)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(struct {
+		Requests []fileAPIVersionedKind `json:"requests"`
+	}{fileAPIRequests}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "query.json"), buf, 0666)
+}
+
+// ReadFileAPI reads the File API replies from the build directory
+func ReadFileAPI(buildDir string) (*FileAPI, error) {
+	replyDir := filepath.Join(fileAPIDir(buildDir), "reply")
+	indexes, err := filepath.Glob(filepath.Join(replyDir, "index-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(indexes) == 0 {
+		return nil, fmt.Errorf("no CMake File API reply in %s", buildDir)
+	}
+	// the names sort by time, the newest index is the valid one
+	sort.Strings(indexes)
+
+	var index struct {
+		CMake CMakeInfo `json:"cmake"`
+		Reply map[string]struct {
+			Query struct {
+				Responses []struct {
+					fileAPIVersionedKind
+					JSONFile string `json:"jsonFile"`
+					Error    string `json:"error"`
+				} `json:"responses"`
+			} `json:"query.json"`
+		} `json:"reply"`
+	}
+	if err := readJSON(indexes[len(indexes)-1], &index); err != nil {
+		return nil, err
+	}
+	client, ok := index.Reply[fileAPIClient]
+	if !ok {
+		return nil, fmt.Errorf("CMake did not reply to the File API query in %s", buildDir)
+	}
+
+	ret := &FileAPI{CMake: index.CMake}
+	errs := []error{}
+	for _, r := range client.Query.Responses {
+		if r.Error != "" {
+			errs = append(errs, fmt.Errorf("%s: %s", r.Kind, r.Error))
+			continue
+		}
+		fn := filepath.Join(replyDir, r.JSONFile)
+		switch r.Kind {
+		case "codemodel":
+			ret.CodeModel = &CodeModel{}
+			err = readCodeModel(replyDir, fn, ret.CodeModel)
+		case "cache":
+			ret.Cache = &Cache{}
+			err = readJSON(fn, ret.Cache)
+		case "toolchains":
+			ret.Toolchains = &Toolchains{}
+			err = readJSON(fn, ret.Toolchains)
+		case "cmakeFiles":
+			ret.CMakeFiles = &CMakeFiles{}
+			err = readJSON(fn, ret.CMakeFiles)
+		default:
+			err = nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return ret, errors.Join(errs...)
+}
+
+// FileAPI reads the File API replies written by Generate
+func (b *Builder) FileAPI() (*FileAPI, error) {
+	return ReadFileAPI(b.BuildDir)
+}
+
+// readCodeModel reads the codemodel object and the target and directory
+// objects it references
+func readCodeModel(replyDir, fn string, cm *CodeModel) error {
+	if err := readJSON(fn, cm); err != nil {
+		return err
+	}
+	for _, c := range cm.Configurations {
+		for _, t := range c.Targets {
+			ref := *t
+			if err := readJSON(filepath.Join(replyDir, ref.JSONFile), t); err != nil {
+				return err
+			}
+			// the reference fields are not repeated in the target object
+			t.DirectoryIndex, t.ProjectIndex, t.JSONFile = ref.DirectoryIndex, ref.ProjectIndex, ref.JSONFile
+		}
+		for _, d := range c.Directories {
+			if d.JSONFile == "" {
+				continue
+			}
+			var obj struct {
+				Installers []*Installer `json:"installers"`
+			}
+			if err := readJSON(filepath.Join(replyDir, d.JSONFile), &obj); err != nil {
+				return err
+			}
+			d.Installers = obj.Installers
+		}
+	}
+	return nil
+}
+
+func readJSON(fn string, v any) error {
+	buf, err := os.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(buf, v); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	return nil
+}
+
+// Configuration returns the named configuration, or the only one for
+// single-configuration generators when name is empty
+func (cm *CodeModel) Configuration(name string) *Configuration {
+	for _, c := range cm.Configurations {
+		if c.Name == name || (name == "" && len(cm.Configurations) == 1) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Target returns the target with the specified name
+func (c *Configuration) Target(name string) *Target {
+	for _, t := range c.Targets {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// ArtifactPaths returns the absolute paths of the files the target produces
+func (t *Target) ArtifactPaths(buildDir string) []string {
+	ret := []string{}
+	for _, a := range t.Artifacts {
+		p := a.Path
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(buildDir, p)
+		}
+		ret = append(ret, filepath.ToSlash(p))
+	}
+	return ret
+}
+
+// SourceCompileGroup returns the compile group of the i-th source, or nil
+// for sources that are not compiled
+func (t *Target) SourceCompileGroup(i int) *CompileGroup {
+	if i < 0 || i >= len(t.Sources) || t.Sources[i].CompileGroupIndex == nil {
+		return nil
+	}
+	if g := *t.Sources[i].CompileGroupIndex; g < len(t.CompileGroups) {
+		return t.CompileGroups[g]
+	}
+	return nil
+}
+
+// Get returns the value of the cache entry
+func (c *Cache) Get(name string) (string, bool) {
+	for _, e := range c.Entries {
+		if e.Name == name {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// Language returns the toolchain for the language: C, CXX, RC, ...
+func (t *Toolchains) Language(lang string) *LanguageToolchain {
+	for i := range t.Toolchains {
+		if t.Toolchains[i].Language == lang {
+			return &t.Toolchains[i]
+		}
+	}
+	return nil
+}
+
+// CheckChain verifies that CMake chose the C and C++ compilers of the
+// chain. Symbolic links are resolved before the paths are compared.
+func (t *Toolchains) CheckChain(tc *toolchain.Chain) error {
+	for lang, tool := range map[string]toolchain.Tool{"C": toolchain.CCompiler, "CXX": toolchain.CXXCompiler} {
+		want := tc.Tools[tool].Path()
+		lt := t.Language(lang)
+		if want == "" || lt == nil || lt.Compiler.Path == "" {
+			continue
+		}
+		if !samePath(lt.Compiler.Path, want) {
+			return fmt.Errorf("CMake uses %s for %s, want %s", lt.Compiler.Path, lang, want)
+		}
+	}
+	return nil
+}
+
+func samePath(a, b string) bool {
+	norm := func(p string) string {
+		if r, err := filepath.EvalSymlinks(p); err == nil {
+			p = r
+		}
+		p = filepath.ToSlash(filepath.Clean(p))
+		if runtime.GOOS == "windows" {
+			p = strings.ToLower(p)
+		}
+		return p
+	}
+	return norm(a) == norm(b)
+}
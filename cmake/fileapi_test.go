@@ -0,0 +1,194 @@
+package cmake
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+)
+
+// writeFileAPIReply writes a File API reply, as CMake does, for a project
+// with a single executable
+func writeFileAPIReply(t *testing.T, buildDir, cc string) {
+	t.Helper()
+	replyDir := filepath.Join(buildDir, ".cmake", "api", "v1", "reply")
+	if err := os.MkdirAll(replyDir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"index-2024-01-01T00-00-00-0000.json": `{"reply": {}}`,
+		"index-2024-06-01T00-00-00-0000.json": `{
+			"cmake": {
+				"version": {"major": 3, "minor": 28, "patch": 1, "suffix": "", "string": "3.28.1"},
+				"paths": {"cmake": "/usr/bin/cmake", "ctest": "/usr/bin/ctest", "cpack": "/usr/bin/cpack", "root": "/usr/share/cmake-3.28"},
+				"generator": {"multiConfig": false, "name": "Ninja"}
+			},
+			"objects": [],
+			"reply": {
+				"client-go-build": {
+					"query.json": {
+						"requests": [],
+						"responses": [
+							{"kind": "codemodel", "version": {"major": 2, "minor": 6}, "jsonFile": "codemodel-v2-1.json"},
+							{"kind": "cache", "version": {"major": 2, "minor": 0}, "jsonFile": "cache-v2-1.json"},
+							{"kind": "toolchains", "version": {"major": 1, "minor": 0}, "jsonFile": "toolchains-v1-1.json"},
+							{"kind": "cmakeFiles", "version": {"major": 1, "minor": 0}, "jsonFile": "cmakeFiles-v1-1.json"}
+						]
+					}
+				}
+			}
+		}`,
+		"codemodel-v2-1.json": `{
+			"kind": "codemodel",
+			"version": {"major": 2, "minor": 6},
+			"paths": {"source": "/src/demo", "build": "/src/demo/build"},
+			"configurations": [{
+				"name": "Release",
+				"directories": [{"source": ".", "build": ".", "projectIndex": 0, "targetIndexes": [0], "hasInstallRule": true, "jsonFile": "directory-.-Release-1.json"}],
+				"projects": [{"name": "demo", "directoryIndexes": [0], "targetIndexes": [0]}],
+				"targets": [{"name": "app", "id": "app::@6890427a1f51a3e7e1df", "directoryIndex": 0, "projectIndex": 0, "jsonFile": "target-app-Release-1.json"}]
+			}]
+		}`,
+		"directory-.-Release-1.json": `{
+			"paths": {"source": ".", "build": "."},
+			"installers": [{"component": "Unspecified", "destination": "bin", "paths": ["app"], "type": "target", "targetId": "app::@6890427a1f51a3e7e1df", "targetIndex": 0}]
+		}`,
+		"target-app-Release-1.json": `{
+			"name": "app",
+			"id": "app::@6890427a1f51a3e7e1df",
+			"type": "EXECUTABLE",
+			"nameOnDisk": "app",
+			"paths": {"source": ".", "build": "."},
+			"artifacts": [{"path": "app"}],
+			"install": {"prefix": {"path": "/usr/local"}, "destinations": [{"path": "bin"}]},
+			"link": {"language": "C", "commandFragments": [{"fragment": "-O3", "role": "flags"}]},
+			"sources": [
+				{"path": "main.c", "compileGroupIndex": 0, "sourceGroupIndex": 0},
+				{"path": "app.h", "sourceGroupIndex": 1}
+			],
+			"compileGroups": [{
+				"sourceIndexes": [0],
+				"language": "C",
+				"languageStandard": {"standard": "11"},
+				"compileCommandFragments": [{"fragment": "-O3 -DNDEBUG"}],
+				"includes": [{"path": "/src/demo/include"}],
+				"defines": [{"define": "DEMO=1"}]
+			}]
+		}`,
+		"cache-v2-1.json": `{
+			"entries": [
+				{"name": "CMAKE_BUILD_TYPE", "value": "Release", "type": "STRING", "properties": [{"name": "HELPSTRING", "value": "Build type"}]},
+				{"name": "CMAKE_C_COMPILER", "value": "` + filepath.ToSlash(cc) + `", "type": "FILEPATH"}
+			]
+		}`,
+		"toolchains-v1-1.json": `{
+			"toolchains": [{
+				"language": "C",
+				"compiler": {"path": "` + filepath.ToSlash(cc) + `", "id": "GNU", "version": "12.2.0", "implicit": {"includeDirectories": ["/usr/include"]}},
+				"sourceFileExtensions": ["c", "m"]
+			}]
+		}`,
+		"cmakeFiles-v1-1.json": `{
+			"paths": {"source": "/src/demo", "build": "/src/demo/build"},
+			"inputs": [{"path": "CMakeLists.txt"}, {"path": "/usr/share/cmake-3.28/Modules/CMakeCInformation.cmake", "isExternal": true, "isCMake": true}]
+		}`,
+	}
+	for fn, content := range files {
+		if !json.Valid([]byte(content)) {
+			t.Fatalf("%s is not valid JSON", fn)
+		}
+		if err := os.WriteFile(filepath.Join(replyDir, fn), []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriteFileAPIQuery(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteFileAPIQuery(dir); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := os.ReadFile(filepath.Join(dir, ".cmake", "api", "v1", "query", "client-go-build", "query.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q struct {
+		Requests []fileAPIVersionedKind `json:"requests"`
+	}
+	if err := json.Unmarshal(buf, &q); err != nil {
+		t.Fatal(err)
+	}
+	kinds := []string{}
+	for _, r := range q.Requests {
+		kinds = append(kinds, r.Kind)
+	}
+	if got := strings.Join(kinds, ","); got != "codemodel,cache,toolchains,cmakeFiles" {
+		t.Errorf("query kinds = %s", got)
+	}
+}
+
+func TestReadFileAPI(t *testing.T) {
+	dir := t.TempDir()
+	cc := filepath.Join(dir, "gcc")
+	writeFileAPIReply(t, dir, cc)
+
+	api, err := ReadFileAPI(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if api.CMake.Version.String != "3.28.1" || api.CMake.Generator.Name != "Ninja" {
+		t.Errorf("CMake = %s %s", api.CMake.Version.String, api.CMake.Generator.Name)
+	}
+
+	c := api.CodeModel.Configuration("")
+	if c == nil || c.Name != "Release" {
+		t.Fatalf("Configuration(\"\") = %v", c)
+	}
+	app := c.Target("app")
+	if app == nil {
+		t.Fatal("target app not found")
+	}
+	if app.Type != "EXECUTABLE" || app.JSONFile != "target-app-Release-1.json" {
+		t.Errorf("app = %s %s", app.Type, app.JSONFile)
+	}
+	if got := app.ArtifactPaths(dir); len(got) != 1 || got[0] != filepath.ToSlash(filepath.Join(dir, "app")) {
+		t.Errorf("ArtifactPaths() = %v", got)
+	}
+	if g := app.SourceCompileGroup(0); g == nil || g.Language != "C" || g.LanguageStandard.Standard != "11" || g.Defines[0].Define != "DEMO=1" {
+		t.Errorf("SourceCompileGroup(0) = %+v", g)
+	}
+	if g := app.SourceCompileGroup(1); g != nil {
+		t.Errorf("SourceCompileGroup(1) = %+v, want nil for a header", g)
+	}
+	if ins := c.Directories[0].Installers; len(ins) != 1 || ins[0].Type != "target" || ins[0].Destination != "bin" {
+		t.Errorf("Installers = %+v", ins)
+	}
+
+	if v, ok := api.Cache.Get("CMAKE_BUILD_TYPE"); !ok || v != "Release" {
+		t.Errorf("Cache.Get(CMAKE_BUILD_TYPE) = %q, %v", v, ok)
+	}
+	if lt := api.Toolchains.Language("C"); lt == nil || lt.Compiler.ID != "GNU" {
+		t.Errorf("Toolchains.Language(C) = %+v", lt)
+	}
+	if len(api.CMakeFiles.Inputs) != 2 || !api.CMakeFiles.Inputs[1].IsCMake {
+		t.Errorf("CMakeFiles.Inputs = %+v", api.CMakeFiles.Inputs)
+	}
+
+	tc := &toolchain.Chain{Tools: toolchain.Toolset{toolchain.CCompiler: toolchain.ToolPath(cc)}}
+	if err := api.Toolchains.CheckChain(tc); err != nil {
+		t.Errorf("CheckChain() = %v", err)
+	}
+	tc.Tools[toolchain.CCompiler] = toolchain.ToolPath(filepath.Join(dir, "clang"))
+	if err := api.Toolchains.CheckChain(tc); err == nil {
+		t.Errorf("CheckChain() accepted a different compiler")
+	}
+}
+
+func TestReadFileAPI_NoReply(t *testing.T) {
+	if _, err := ReadFileAPI(t.TempDir()); err == nil {
+		t.Errorf("ReadFileAPI() succeeded without a reply")
+	}
+}
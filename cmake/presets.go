@@ -0,0 +1,711 @@
+package cmake
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adnsv/go-utils/filesystem"
+)
+
+// PresetsFileName and UserPresetsFileName are the preset files that CMake
+// reads from the top-level source directory
+const (
+	PresetsFileName     = "CMakePresets.json"
+	UserPresetsFileName = "CMakeUserPresets.json"
+)
+
+// PresetsFile is the layout of a CMakePresets.json or CMakeUserPresets.json
+// file, see cmake-presets(7)
+type PresetsFile struct {
+	Version              int                `json:"version"`
+	CMakeMinimumRequired *PresetsCMake      `json:"cmakeMinimumRequired,omitempty"`
+	Include              []string           `json:"include,omitempty"`
+	ConfigurePresets     []*ConfigurePreset `json:"configurePresets,omitempty"`
+	BuildPresets         []*BuildPreset     `json:"buildPresets,omitempty"`
+	TestPresets          []*TestPreset      `json:"testPresets,omitempty"`
+}
+
+// PresetsCMake is the minimum cmake version of a presets file
+type PresetsCMake struct {
+	Major int `json:"major"`
+	Minor int `json:"minor,omitempty"`
+	Patch int `json:"patch,omitempty"`
+}
+
+// PresetCommon holds the fields shared by all preset kinds
+type PresetCommon struct {
+	Name        string             `json:"name"`
+	Hidden      bool               `json:"hidden,omitempty"`
+	Inherits    StringList         `json:"inherits,omitempty"`
+	Condition   *Condition         `json:"condition,omitempty"`
+	DisplayName string             `json:"displayName,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Environment map[string]*string `json:"environment,omitempty"` // nil values unset inherited variables
+
+	// Enabled is the value of the condition on this host, disabled presets
+	// can not be used
+	Enabled bool `json:"-"`
+
+	file string // the file the preset comes from
+	env  []string
+}
+
+// ConfigurePreset describes how to generate a build directory
+type ConfigurePreset struct {
+	PresetCommon
+	Generator       string                    `json:"generator,omitempty"`
+	Architecture    *PresetValue              `json:"architecture,omitempty"`
+	Toolset         *PresetValue              `json:"toolset,omitempty"`
+	ToolchainFile   string                    `json:"toolchainFile,omitempty"`
+	BinaryDir       string                    `json:"binaryDir,omitempty"`
+	InstallDir      string                    `json:"installDir,omitempty"`
+	CMakeExecutable string                    `json:"cmakeExecutable,omitempty"`
+	CacheVariables  map[string]*CacheVariable `json:"cacheVariables,omitempty"` // nil values unset inherited variables
+}
+
+// BuildPreset describes how to build a configured build directory
+type BuildPreset struct {
+	PresetCommon
+	ConfigurePreset             string     `json:"configurePreset,omitempty"`
+	InheritConfigureEnvironment *bool      `json:"inheritConfigureEnvironment,omitempty"`
+	Jobs                        int        `json:"jobs,omitempty"`
+	Targets                     StringList `json:"targets,omitempty"`
+	Configuration               string     `json:"configuration,omitempty"`
+	CleanFirst                  bool       `json:"cleanFirst,omitempty"`
+	Verbose                     bool       `json:"verbose,omitempty"`
+	NativeToolOptions           []string   `json:"nativeToolOptions,omitempty"`
+}
+
+// TestPreset describes how to run ctest in a configured build directory
+type TestPreset struct {
+	PresetCommon
+	ConfigurePreset             string   `json:"configurePreset,omitempty"`
+	InheritConfigureEnvironment *bool    `json:"inheritConfigureEnvironment,omitempty"`
+	Configuration               string   `json:"configuration,omitempty"`
+	OverwriteConfigurationFile  []string `json:"overwriteConfigurationFile,omitempty"`
+	Output                      *struct {
+		ShortProgress   bool   `json:"shortProgress,omitempty"`
+		Verbosity       string `json:"verbosity,omitempty"` // default, verbose, extra
+		Debug           bool   `json:"debug,omitempty"`
+		OutputOnFailure bool   `json:"outputOnFailure,omitempty"`
+		Quiet           bool   `json:"quiet,omitempty"`
+		OutputLogFile   string `json:"outputLogFile,omitempty"`
+	} `json:"output,omitempty"`
+	Filter *struct {
+		Include *struct {
+			Name     string `json:"name,omitempty"`
+			Label    string `json:"label,omitempty"`
+			UseUnion bool   `json:"useUnion,omitempty"`
+		} `json:"include,omitempty"`
+		Exclude *struct {
+			Name  string `json:"name,omitempty"`
+			Label string `json:"label,omitempty"`
+		} `json:"exclude,omitempty"`
+	} `json:"filter,omitempty"`
+	Execution *struct {
+		StopOnFailure  bool   `json:"stopOnFailure,omitempty"`
+		Jobs           int    `json:"jobs,omitempty"`
+		TestLoad       int    `json:"testLoad,omitempty"`
+		ScheduleRandom bool   `json:"scheduleRandom,omitempty"`
+		Timeout        int    `json:"timeout,omitempty"`
+		NoTestsAction  string `json:"noTestsAction,omitempty"` // default, error, ignore
+		Repeat         *struct {
+			Mode  string `json:"mode"` // until-fail, until-pass, after-timeout
+			Count int    `json:"count"`
+		} `json:"repeat,omitempty"`
+	} `json:"execution,omitempty"`
+}
+
+// StringList is a list that may be spelled as a single string
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = StringList{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(l))
+}
+
+// PresetValue is an architecture or toolset, spelled as a string or as an
+// object with a strategy. The "external" strategy means the value is set up
+// by the IDE and is not passed to cmake.
+type PresetValue struct {
+	Value    string `json:"value"`
+	Strategy string `json:"strategy,omitempty"` // set, external
+}
+
+func (v *PresetValue) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &v.Value); err == nil {
+		return nil
+	}
+	type plain PresetValue
+	return json.Unmarshal(b, (*plain)(v))
+}
+
+func (v PresetValue) MarshalJSON() ([]byte, error) {
+	if v.Strategy == "" {
+		return json.Marshal(v.Value)
+	}
+	type plain PresetValue
+	return json.Marshal(plain(v))
+}
+
+// CacheVariable is a cache variable of a configure preset, spelled as a
+// string, a boolean or an object with a type
+type CacheVariable struct {
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value"`
+}
+
+func (v *CacheVariable) UnmarshalJSON(b []byte) error {
+	var obj struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	}
+	raw := b
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		if err := json.Unmarshal(b, &obj); err != nil {
+			return err
+		}
+		v.Type, raw = obj.Type, obj.Value
+	}
+	var flag bool
+	if err := json.Unmarshal(raw, &flag); err == nil {
+		v.Value = "FALSE"
+		if flag {
+			v.Value = "TRUE"
+		}
+		if v.Type == "" {
+			v.Type = "BOOL"
+		}
+		return nil
+	}
+	return json.Unmarshal(raw, &v.Value)
+}
+
+func (v CacheVariable) MarshalJSON() ([]byte, error) {
+	if v.Type == "" {
+		return json.Marshal(v.Value)
+	}
+	type plain CacheVariable
+	return json.Marshal(plain(v))
+}
+
+// WriteFile writes the presets file
+func (f *PresetsFile) WriteFile(fn string) error {
+	buf, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fn, append(buf, '\n'), 0666)
+}
+
+// Presets are the presets of a source directory with the included files
+// loaded, inheritance resolved and macros expanded. Hidden presets are
+// dropped.
+type Presets struct {
+	SourceDir string
+	Files     []string // the files that were read
+	Configure []*ConfigurePreset
+	Build     []*BuildPreset
+	Test      []*TestPreset
+}
+
+// rawPreset is a preset before inheritance is resolved
+type rawPreset struct {
+	fields map[string]json.RawMessage
+	file   string
+}
+
+type presetLoader struct {
+	files []string
+	seen  map[string]bool
+	raw   map[string]map[string]*rawPreset // kind -> name -> preset
+	order map[string][]string
+}
+
+var presetKinds = []string{"configurePresets", "buildPresets", "testPresets"}
+
+// LoadPresets reads CMakePresets.json and CMakeUserPresets.json from the
+// source directory. It is not an error if neither exists.
+func LoadPresets(sourceDir string) (*Presets, error) {
+	sourceDir, err := filepath.Abs(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+	l := &presetLoader{
+		seen:  map[string]bool{},
+		raw:   map[string]map[string]*rawPreset{},
+		order: map[string][]string{},
+	}
+	for _, k := range presetKinds {
+		l.raw[k] = map[string]*rawPreset{}
+	}
+	// the user file implicitly includes the project file
+	for _, n := range []string{PresetsFileName, UserPresetsFileName} {
+		fn := filepath.Join(sourceDir, n)
+		if !filesystem.FileExists(fn) {
+			continue
+		}
+		if err := l.load(fn); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &Presets{SourceDir: sourceDir, Files: l.files}
+	for _, k := range presetKinds {
+		for _, name := range l.order[k] {
+			if hidden, _ := l.raw[k][name].bool("hidden"); hidden {
+				continue
+			}
+			fields, err := l.resolve(k, name, map[string]bool{})
+			if err != nil {
+				return nil, err
+			}
+			buf, err := json.Marshal(fields)
+			if err != nil {
+				return nil, err
+			}
+			file := l.raw[k][name].file
+			switch k {
+			case "configurePresets":
+				cp := &ConfigurePreset{}
+				err = json.Unmarshal(buf, cp)
+				cp.file = file
+				p.Configure = append(p.Configure, cp)
+			case "buildPresets":
+				bp := &BuildPreset{}
+				err = json.Unmarshal(buf, bp)
+				bp.file = file
+				p.Build = append(p.Build, bp)
+			case "testPresets":
+				tp := &TestPreset{}
+				err = json.Unmarshal(buf, tp)
+				tp.file = file
+				p.Test = append(p.Test, tp)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: preset '%s': %w", file, name, err)
+			}
+		}
+	}
+	if err := p.expand(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (l *presetLoader) load(fn string) error {
+	abs, err := filepath.Abs(fn)
+	if err != nil {
+		return err
+	}
+	if l.seen[abs] {
+		return nil
+	}
+	l.seen[abs] = true
+
+	buf, err := os.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+	var f struct {
+		Version          int                          `json:"version"`
+		Include          []string                     `json:"include"`
+		ConfigurePresets []map[string]json.RawMessage `json:"configurePresets"`
+		BuildPresets     []map[string]json.RawMessage `json:"buildPresets"`
+		TestPresets      []map[string]json.RawMessage `json:"testPresets"`
+	}
+	if err := json.Unmarshal(buf, &f); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if f.Version < 1 {
+		return fmt.Errorf("%s: missing presets version", fn)
+	}
+	l.files = append(l.files, fn)
+
+	for _, inc := range f.Include {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(fn), inc)
+		}
+		if err := l.load(inc); err != nil {
+			return err
+		}
+	}
+	for i, pp := range [][]map[string]json.RawMessage{f.ConfigurePresets, f.BuildPresets, f.TestPresets} {
+		k := presetKinds[i]
+		for _, fields := range pp {
+			substituteFileDir(fields, filepath.Dir(abs))
+			rp := &rawPreset{fields: fields, file: fn}
+			name, _ := rp.string("name")
+			if name == "" {
+				return fmt.Errorf("%s: %s entry without a name", fn, k)
+			}
+			if prev, ok := l.raw[k][name]; ok {
+				return fmt.Errorf("%s: duplicate preset '%s', also defined in %s", fn, name, prev.file)
+			}
+			l.raw[k][name] = rp
+			l.order[k] = append(l.order[k], name)
+		}
+	}
+	return nil
+}
+
+// substituteFileDir expands ${fileDir} before inheritance is resolved, as it
+// refers to the file that contains the macro rather than the file of the
+// inheriting preset
+func substituteFileDir(fields map[string]json.RawMessage, dir string) {
+	quoted, _ := json.Marshal(filepath.ToSlash(dir))
+	repl := []byte(quoted[1 : len(quoted)-1])
+	for k, v := range fields {
+		fields[k] = bytes.ReplaceAll(v, []byte("${fileDir}"), repl)
+	}
+}
+
+func (rp *rawPreset) string(key string) (string, bool) {
+	var s string
+	err := json.Unmarshal(rp.fields[key], &s)
+	return s, err == nil
+}
+
+func (rp *rawPreset) bool(key string) (bool, bool) {
+	var b bool
+	err := json.Unmarshal(rp.fields[key], &b)
+	return b, err == nil
+}
+
+// mergedPresetFields are the objects that are merged key by key with the
+// inherited ones instead of being replaced
+var mergedPresetFields = map[string]bool{
+	"cacheVariables": true,
+	"environment":    true,
+	"output":         true,
+	"filter":         true,
+	"execution":      true,
+}
+
+// resolve returns the fields of a preset merged with those of the presets
+// it inherits from. Earlier parents take precedence over later ones, and
+// name, hidden and inherits are not inherited.
+func (l *presetLoader) resolve(kind, name string, visiting map[string]bool) (map[string]json.RawMessage, error) {
+	rp, ok := l.raw[kind][name]
+	if !ok {
+		return nil, fmt.Errorf("preset '%s' not found", name)
+	}
+	if visiting[name] {
+		return nil, fmt.Errorf("%s: preset '%s' inherits from itself", rp.file, name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	ret := map[string]json.RawMessage{}
+	for k, v := range rp.fields {
+		ret[k] = v
+	}
+	var parents StringList
+	if v, ok := rp.fields["inherits"]; ok {
+		if err := json.Unmarshal(v, &parents); err != nil {
+			return nil, fmt.Errorf("%s: preset '%s': %w", rp.file, name, err)
+		}
+	}
+	for _, pn := range parents {
+		pf, err := l.resolve(kind, pn, visiting)
+		if err != nil {
+			return nil, fmt.Errorf("%s: preset '%s': %w", rp.file, name, err)
+		}
+		for k, v := range pf {
+			switch {
+			case k == "name" || k == "hidden" || k == "inherits":
+			case mergedPresetFields[k] && ret[k] != nil:
+				merged, err := mergeObjects(ret[k], v)
+				if err != nil {
+					return nil, fmt.Errorf("%s: preset '%s': %s: %w", rp.file, name, k, err)
+				}
+				ret[k] = merged
+			case ret[k] == nil:
+				ret[k] = v
+			}
+		}
+	}
+	delete(ret, "inherits")
+	return ret, nil
+}
+
+// mergeObjects adds the keys of the inherited object that the preset does
+// not set itself
+func mergeObjects(own, inherited json.RawMessage) (json.RawMessage, error) {
+	var a, b map[string]json.RawMessage
+	if err := json.Unmarshal(own, &a); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(inherited, &b); err != nil {
+		return nil, err
+	}
+	for k, v := range b {
+		if _, ok := a[k]; !ok {
+			a[k] = v
+		}
+	}
+	return json.Marshal(a)
+}
+
+// FindConfigure returns the configure preset with the specified name
+func (p *Presets) FindConfigure(name string) *ConfigurePreset {
+	for _, cp := range p.Configure {
+		if cp.Name == name {
+			return cp
+		}
+	}
+	return nil
+}
+
+// FindBuild returns the build preset with the specified name
+func (p *Presets) FindBuild(name string) *BuildPreset {
+	for _, bp := range p.Build {
+		if bp.Name == name {
+			return bp
+		}
+	}
+	return nil
+}
+
+// FindTest returns the test preset with the specified name
+func (p *Presets) FindTest(name string) *TestPreset {
+	for _, tp := range p.Test {
+		if tp.Name == name {
+			return tp
+		}
+	}
+	return nil
+}
+
+// ConfigureBuilder returns a builder that generates the build directory as
+// the configure preset describes. The build type comes from the
+// CMAKE_BUILD_TYPE cache variable.
+func (p *Presets) ConfigureBuilder(name string) (*Builder, error) {
+	cp := p.FindConfigure(name)
+	if cp == nil {
+		return nil, fmt.Errorf("configure preset '%s' not found", name)
+	}
+	if !cp.Enabled {
+		return nil, fmt.Errorf("configure preset '%s' is disabled by its condition", name)
+	}
+	if cp.BinaryDir == "" {
+		return nil, fmt.Errorf("configure preset '%s' does not specify binaryDir", name)
+	}
+	b := &Builder{
+		CMakeCmd:      cp.CMakeExecutable,
+		Env:           cp.env,
+		SourceDir:     p.SourceDir,
+		BuildDir:      p.sourcePath(cp.BinaryDir),
+		GenerateFlags: []string{},
+	}
+	if cp.Generator != "" {
+		b.Generator = &Generator{Name: cp.Generator}
+		if cp.Architecture != nil && cp.Architecture.Strategy != "external" {
+			b.Generator.Arch = cp.Architecture.Value
+		}
+		if cp.Toolset != nil && cp.Toolset.Strategy != "external" {
+			b.Generator.Toolset = cp.Toolset.Value
+		}
+	}
+	names := make([]string, 0, len(cp.CacheVariables))
+	for n, v := range cp.CacheVariables {
+		if v != nil {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		v := cp.CacheVariables[n]
+		if n == "CMAKE_BUILD_TYPE" {
+			bt, err := ParseBuildType(v.Value)
+			if err != nil {
+				return nil, fmt.Errorf("configure preset '%s': %w", name, err)
+			}
+			b.BuildType = bt
+			continue
+		}
+		if v.Type != "" {
+			n += ":" + v.Type
+		}
+		b.GenerateFlags = append(b.GenerateFlags, "-D"+n+"="+v.Value)
+	}
+	if cp.ToolchainFile != "" {
+		b.GenerateFlags = append(b.GenerateFlags, "-DCMAKE_TOOLCHAIN_FILE:FILEPATH="+filepath.ToSlash(p.sourcePath(cp.ToolchainFile)))
+	}
+	if cp.InstallDir != "" {
+		b.GenerateFlags = append(b.GenerateFlags, "-DCMAKE_INSTALL_PREFIX:PATH="+filepath.ToSlash(p.sourcePath(cp.InstallDir)))
+	}
+	return b, nil
+}
+
+// BuildBuilder returns a builder for the build preset, generating with its
+// configure preset
+func (p *Presets) BuildBuilder(name string) (*Builder, error) {
+	bp := p.FindBuild(name)
+	if bp == nil {
+		return nil, fmt.Errorf("build preset '%s' not found", name)
+	}
+	if !bp.Enabled {
+		return nil, fmt.Errorf("build preset '%s' is disabled by its condition", name)
+	}
+	if bp.ConfigurePreset == "" {
+		return nil, fmt.Errorf("build preset '%s' does not specify configurePreset", name)
+	}
+	b, err := p.ConfigureBuilder(bp.ConfigurePreset)
+	if err != nil {
+		return nil, fmt.Errorf("build preset '%s': %w", name, err)
+	}
+	b.Env = bp.env
+	b.BuildTargets = bp.Targets
+	b.Jobs = bp.Jobs
+	if bp.Configuration != "" {
+		if b.BuildType, err = ParseBuildType(bp.Configuration); err != nil {
+			return nil, fmt.Errorf("build preset '%s': %w", name, err)
+		}
+	}
+	if bp.CleanFirst {
+		b.BuildFlags = append(b.BuildFlags, "--clean-first")
+	}
+	if bp.Verbose {
+		b.BuildFlags = append(b.BuildFlags, "--verbose")
+	}
+	if len(bp.NativeToolOptions) > 0 {
+		b.BuildFlags = append(append(b.BuildFlags, "--"), bp.NativeToolOptions...)
+	}
+	return b, nil
+}
+
+// TestConfig is a test preset turned into the builder of the tested build
+// directory and the ctest arguments that select and run the tests, to be
+// passed to Builder.Test in TestOptions.Args
+type TestConfig struct {
+	Builder   *Builder
+	CTestArgs []string
+}
+
+// TestConfig returns the builder and the ctest arguments for the test preset
+func (p *Presets) TestConfig(name string) (*TestConfig, error) {
+	tp := p.FindTest(name)
+	if tp == nil {
+		return nil, fmt.Errorf("test preset '%s' not found", name)
+	}
+	if !tp.Enabled {
+		return nil, fmt.Errorf("test preset '%s' is disabled by its condition", name)
+	}
+	if tp.ConfigurePreset == "" {
+		return nil, fmt.Errorf("test preset '%s' does not specify configurePreset", name)
+	}
+	b, err := p.ConfigureBuilder(tp.ConfigurePreset)
+	if err != nil {
+		return nil, fmt.Errorf("test preset '%s': %w", name, err)
+	}
+	b.Env = tp.env
+	if tp.Configuration != "" {
+		if b.BuildType, err = ParseBuildType(tp.Configuration); err != nil {
+			return nil, fmt.Errorf("test preset '%s': %w", name, err)
+		}
+	}
+
+	args := []string{}
+	for _, o := range tp.OverwriteConfigurationFile {
+		args = append(args, "--overwrite", o)
+	}
+	if o := tp.Output; o != nil {
+		if o.ShortProgress {
+			args = append(args, "--progress")
+		}
+		switch o.Verbosity {
+		case "verbose":
+			args = append(args, "-V")
+		case "extra":
+			args = append(args, "-VV")
+		}
+		if o.Debug {
+			args = append(args, "--debug")
+		}
+		if o.OutputOnFailure {
+			args = append(args, "--output-on-failure")
+		}
+		if o.Quiet {
+			args = append(args, "-Q")
+		}
+		if o.OutputLogFile != "" {
+			args = append(args, "--output-log", p.sourcePath(o.OutputLogFile))
+		}
+	}
+	if f := tp.Filter; f != nil {
+		if in := f.Include; in != nil {
+			if in.Name != "" {
+				args = append(args, "-R", in.Name)
+			}
+			if in.Label != "" {
+				args = append(args, "-L", in.Label)
+			}
+			if in.UseUnion {
+				args = append(args, "-U")
+			}
+		}
+		if ex := f.Exclude; ex != nil {
+			if ex.Name != "" {
+				args = append(args, "-E", ex.Name)
+			}
+			if ex.Label != "" {
+				args = append(args, "-LE", ex.Label)
+			}
+		}
+	}
+	if x := tp.Execution; x != nil {
+		if x.StopOnFailure {
+			args = append(args, "--stop-on-failure")
+		}
+		if x.Jobs > 0 {
+			args = append(args, "-j", strconv.Itoa(x.Jobs))
+		}
+		if x.TestLoad > 0 {
+			args = append(args, "--test-load", strconv.Itoa(x.TestLoad))
+		}
+		if x.ScheduleRandom {
+			args = append(args, "--schedule-random")
+		}
+		if x.Timeout > 0 {
+			args = append(args, "--timeout", strconv.Itoa(x.Timeout))
+		}
+		if x.NoTestsAction == "error" || x.NoTestsAction == "ignore" {
+			args = append(args, "--no-tests="+x.NoTestsAction)
+		}
+		if x.Repeat != nil {
+			args = append(args, "--repeat", x.Repeat.Mode+":"+strconv.Itoa(x.Repeat.Count))
+		}
+	}
+	return &TestConfig{Builder: b, CTestArgs: args}, nil
+}
+
+// sourcePath resolves paths that are relative to the source directory
+func (p *Presets) sourcePath(fn string) string {
+	if filepath.IsAbs(fn) {
+		return filepath.Clean(fn)
+	}
+	return filepath.Join(p.SourceDir, fn)
+}
+
+// presetName replaces the characters that are not safe in preset names
+// and directory names
+func presetName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '-'
+	}, s)
+}
@@ -0,0 +1,317 @@
+package cmake
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/adnsv/go-build/env"
+	"github.com/adnsv/go-build/host"
+)
+
+// Condition is a preset condition, evaluated after macro expansion. The
+// JSON literals true, false and null are accepted as constants.
+type Condition struct {
+	Type       string       `json:"type"` // const, equals, notEquals, inList, notInList, matches, notMatches, anyOf, allOf, not
+	Value      *bool        `json:"value,omitempty"`
+	LHS        string       `json:"lhs,omitempty"`
+	RHS        string       `json:"rhs,omitempty"`
+	String     string       `json:"string,omitempty"`
+	List       []string     `json:"list,omitempty"`
+	Regex      string       `json:"regex,omitempty"`
+	Conditions []*Condition `json:"conditions,omitempty"`
+	Condition  *Condition   `json:"condition,omitempty"`
+}
+
+func (c *Condition) UnmarshalJSON(b []byte) error {
+	var v bool
+	if err := json.Unmarshal(b, &v); err == nil {
+		*c = Condition{Type: "const", Value: &v}
+		return nil
+	}
+	type plain Condition
+	return json.Unmarshal(b, (*plain)(c))
+}
+
+// evaluate evaluates the condition, a nil condition is true
+func (c *Condition) evaluate(m *macroContext) (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+	switch c.Type {
+	case "const":
+		return c.Value != nil && *c.Value, nil
+	case "equals", "notEquals":
+		lhs, err := m.expand(c.LHS)
+		if err != nil {
+			return false, err
+		}
+		rhs, err := m.expand(c.RHS)
+		if err != nil {
+			return false, err
+		}
+		return (lhs == rhs) == (c.Type == "equals"), nil
+	case "inList", "notInList":
+		s, err := m.expand(c.String)
+		if err != nil {
+			return false, err
+		}
+		found := false
+		for _, it := range c.List {
+			it, err := m.expand(it)
+			if err != nil {
+				return false, err
+			}
+			found = found || it == s
+		}
+		return found == (c.Type == "inList"), nil
+	case "matches", "notMatches":
+		s, err := m.expand(c.String)
+		if err != nil {
+			return false, err
+		}
+		re, err := regexp.Compile(c.Regex)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(s) == (c.Type == "matches"), nil
+	case "anyOf", "allOf":
+		for _, sub := range c.Conditions {
+			v, err := sub.evaluate(m)
+			if err != nil {
+				return false, err
+			}
+			if v == (c.Type == "anyOf") {
+				return v, nil
+			}
+		}
+		return c.Type == "allOf", nil
+	case "not":
+		if c.Condition == nil {
+			return false, fmt.Errorf("condition 'not' without a nested condition")
+		}
+		v, err := c.Condition.evaluate(m)
+		return !v, err
+	}
+	return false, fmt.Errorf("unsupported condition type '%s'", c.Type)
+}
+
+// macroContext expands the macros of a preset
+type macroContext struct {
+	sourceDir  string
+	presetName string
+	generator  string
+	env        map[string]string // the environment of the preset
+	penv       map[string]string // the parent environment
+}
+
+// expand expands ${...}, $env{...} and $penv{...} macros. Presets that use
+// $vendor{...} macros can not be used outside of the vendor tool.
+func (m *macroContext) expand(s string) (string, error) {
+	return m.expandWith(s, func(name string) (string, error) {
+		return m.env[name], nil
+	})
+}
+
+func (m *macroContext) expandWith(s string, envLookup func(string) (string, error)) (string, error) {
+	w := &strings.Builder{}
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 {
+			w.WriteString(s)
+			return w.String(), nil
+		}
+		w.WriteString(s[:i])
+		s = s[i:]
+		open := strings.IndexByte(s, '{')
+		end := strings.IndexByte(s, '}')
+		ns := s[1:max(open, 1)]
+		if open < 0 || end < open || (ns != "" && ns != "env" && ns != "penv" && ns != "vendor") {
+			w.WriteByte('$')
+			s = s[1:]
+			continue
+		}
+		name := s[open+1 : end]
+		s = s[end+1:]
+		var v string
+		var err error
+		switch ns {
+		case "":
+			v, err = m.builtin(name)
+		case "env":
+			v, err = envLookup(name)
+		case "penv":
+			v = m.penv[name]
+		case "vendor":
+			err = fmt.Errorf("vendor macro '$vendor{%s}' is not supported", name)
+		}
+		if err != nil {
+			return "", err
+		}
+		w.WriteString(v)
+	}
+}
+
+func (m *macroContext) builtin(name string) (string, error) {
+	switch name {
+	case "sourceDir":
+		return filepath.ToSlash(m.sourceDir), nil
+	case "sourceParentDir":
+		return filepath.ToSlash(filepath.Dir(m.sourceDir)), nil
+	case "sourceDirName":
+		return filepath.Base(m.sourceDir), nil
+	case "presetName":
+		return m.presetName, nil
+	case "generator":
+		return m.generator, nil
+	case "hostSystemName":
+		return hostSystemName(), nil
+	case "dollar":
+		return "$", nil
+	case "pathListSep":
+		return string(os.PathListSeparator), nil
+	}
+	return "", fmt.Errorf("unsupported macro '${%s}'", name)
+}
+
+// hostSystemName returns the CMAKE_HOST_SYSTEM_NAME of the host
+func hostSystemName() string {
+	if sys, err := host.Current().Target().CMake(); err == nil {
+		return sys.Name
+	}
+	return runtime.GOOS
+}
+
+// environment applies the preset environment to the base environment and
+// returns it with the expanded preset variables. Values may refer to other
+// variables of the preset with $env{...}, which are expanded first.
+func (m *macroContext) environment(base map[string]string, vars map[string]*string) (map[string]string, map[string]string, error) {
+	ret := env.Merge(base, nil)
+	resolved := map[string]string{}
+	visiting := map[string]bool{}
+	var lookup func(string) (string, error)
+	lookup = func(name string) (string, error) {
+		v, ok := vars[name]
+		if !ok {
+			return base[name], nil
+		}
+		if v == nil {
+			return "", nil
+		}
+		if r, ok := resolved[name]; ok {
+			return r, nil
+		}
+		if visiting[name] {
+			return "", fmt.Errorf("environment variable '%s' refers to itself", name)
+		}
+		visiting[name] = true
+		r, err := m.expandWith(*v, lookup)
+		if err != nil {
+			return "", err
+		}
+		resolved[name] = r
+		return r, nil
+	}
+	for name, v := range vars {
+		for k := range ret {
+			if k == name || (runtime.GOOS == "windows" && strings.EqualFold(k, name)) {
+				delete(ret, k)
+			}
+		}
+		if v == nil {
+			continue
+		}
+		r, err := lookup(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		ret = env.Merge(ret, map[string]string{name: r})
+	}
+	return ret, resolved, nil
+}
+
+// expand resolves the environment, the macros and the conditions of all
+// presets
+func (p *Presets) expand() error {
+	parent := env.Split(os.Environ())
+	configureEnv := map[string]map[string]string{}
+	configureGenerator := map[string]string{}
+
+	for _, cp := range p.Configure {
+		m := &macroContext{sourceDir: p.SourceDir, presetName: cp.Name, generator: cp.Generator, penv: parent}
+		err := m.apply(&cp.PresetCommon, parent)
+		for _, s := range []*string{&cp.BinaryDir, &cp.InstallDir, &cp.ToolchainFile, &cp.CMakeExecutable} {
+			if err == nil {
+				*s, err = m.expand(*s)
+			}
+		}
+		for _, v := range cp.CacheVariables {
+			if err == nil && v != nil {
+				v.Value, err = m.expand(v.Value)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s: configure preset '%s': %w", cp.file, cp.Name, err)
+		}
+		configureEnv[cp.Name] = m.env
+		configureGenerator[cp.Name] = cp.Generator
+	}
+
+	// build and test presets start from the configure preset environment
+	// unless inheritConfigureEnvironment is false
+	base := func(configure string, inherit *bool) map[string]string {
+		if e, ok := configureEnv[configure]; ok && (inherit == nil || *inherit) {
+			return e
+		}
+		return parent
+	}
+	for _, bp := range p.Build {
+		m := &macroContext{sourceDir: p.SourceDir, presetName: bp.Name, generator: configureGenerator[bp.ConfigurePreset], penv: parent}
+		err := m.apply(&bp.PresetCommon, base(bp.ConfigurePreset, bp.InheritConfigureEnvironment))
+		for i := range bp.Targets {
+			if err == nil {
+				bp.Targets[i], err = m.expand(bp.Targets[i])
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s: build preset '%s': %w", bp.file, bp.Name, err)
+		}
+	}
+	for _, tp := range p.Test {
+		m := &macroContext{sourceDir: p.SourceDir, presetName: tp.Name, generator: configureGenerator[tp.ConfigurePreset], penv: parent}
+		err := m.apply(&tp.PresetCommon, base(tp.ConfigurePreset, tp.InheritConfigureEnvironment))
+		for i := range tp.OverwriteConfigurationFile {
+			if err == nil {
+				tp.OverwriteConfigurationFile[i], err = m.expand(tp.OverwriteConfigurationFile[i])
+			}
+		}
+		if err == nil && tp.Output != nil {
+			tp.Output.OutputLogFile, err = m.expand(tp.Output.OutputLogFile)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: test preset '%s': %w", tp.file, tp.Name, err)
+		}
+	}
+	return nil
+}
+
+// apply computes the environment of the preset and evaluates its condition
+func (m *macroContext) apply(pc *PresetCommon, base map[string]string) error {
+	e, resolved, err := m.environment(base, pc.Environment)
+	if err != nil {
+		return err
+	}
+	m.env = e
+	pc.env = env.Join(e)
+	for k, v := range pc.Environment {
+		if v != nil {
+			*v = resolved[k]
+		}
+	}
+	pc.Enabled, err = pc.Condition.evaluate(m)
+	return err
+}
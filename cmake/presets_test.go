@@ -0,0 +1,296 @@
+package cmake
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+const testPresets = `{
+  "version": 6,
+  "include": ["cmake/common.json"],
+  "configurePresets": [
+    {
+      "name": "release",
+      "inherits": ["base", "ninja"],
+      "displayName": "Release",
+      "cacheVariables": {
+        "CMAKE_BUILD_TYPE": "Release",
+        "WITH_TESTS": {"type": "BOOL", "value": false},
+        "REMOVED": null
+      },
+      "environment": {
+        "PATH": "$env{TOOLS}$penv{PATH}",
+        "UNSET_ME": null
+      }
+    },
+    {
+      "name": "never",
+      "inherits": "base",
+      "condition": {"type": "notEquals", "lhs": "${hostSystemName}", "rhs": "${hostSystemName}"}
+    },
+    {
+      "name": "vs",
+      "inherits": "base",
+      "generator": "Visual Studio 17 2022",
+      "architecture": {"value": "x64", "strategy": "set"},
+      "toolset": {"value": "host=x64", "strategy": "external"}
+    }
+  ],
+  "buildPresets": [
+    {
+      "name": "release",
+      "configurePreset": "release",
+      "jobs": 4,
+      "targets": "app",
+      "cleanFirst": true,
+      "nativeToolOptions": ["-k", "0"],
+      "environment": {"BUILD_ONLY": "1"}
+    }
+  ],
+  "testPresets": [
+    {
+      "name": "release",
+      "configurePreset": "release",
+      "inheritConfigureEnvironment": false,
+      "output": {"outputOnFailure": true},
+      "filter": {"include": {"name": "^unit"}, "exclude": {"label": "slow"}},
+      "execution": {"jobs": 2, "timeout": 60, "stopOnFailure": true}
+    }
+  ]
+}`
+
+const testCommonPresets = `{
+  "version": 6,
+  "configurePresets": [
+    {
+      "name": "base",
+      "hidden": true,
+      "binaryDir": "${sourceDir}/build/${presetName}",
+      "installDir": "${fileDir}/../install",
+      "cacheVariables": {
+        "CMAKE_BUILD_TYPE": "Debug",
+        "WITH_TESTS": true,
+        "REMOVED": "x",
+        "PROJECT_DIR": "${sourceDirName}"
+      },
+      "environment": {
+        "TOOLS": "/opt/tools/bin${pathListSep}",
+        "UNSET_ME": "1"
+      }
+    },
+    {
+      "name": "ninja",
+      "hidden": true,
+      "generator": "Ninja",
+      "environment": {"UNSET_ME": "2"}
+    }
+  ]
+}`
+
+func writePresets(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for fn, content := range files {
+		fn = filepath.Join(dir, fn)
+		if err := os.MkdirAll(filepath.Dir(fn), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func envValue(e []string, name string) (string, bool) {
+	for _, kv := range e {
+		if k, v, _ := strings.Cut(kv, "="); strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func TestLoadPresets(t *testing.T) {
+	dir := writePresets(t, map[string]string{
+		PresetsFileName:     testPresets,
+		"cmake/common.json": testCommonPresets,
+	})
+	t.Setenv("PATH", "/usr/bin")
+	p, err := LoadPresets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Files) != 2 || len(p.Configure) != 3 {
+		t.Fatalf("LoadPresets() read %d files and %d configure presets, want 2 and 3", len(p.Files), len(p.Configure))
+	}
+	if p.FindConfigure("base") != nil {
+		t.Errorf("hidden preset 'base' is listed")
+	}
+	if cp := p.FindConfigure("never"); cp == nil || cp.Enabled {
+		t.Errorf("preset 'never' should be disabled")
+	}
+	if _, err := p.ConfigureBuilder("never"); err == nil {
+		t.Errorf("ConfigureBuilder(never) succeeded for a disabled preset")
+	}
+
+	b, err := p.ConfigureBuilder("release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.ToSlash(p.SourceDir)
+	if b.BuildType != Release || filepath.ToSlash(b.BuildDir) != src+"/build/release" || b.Generator == nil || b.Generator.Name != "Ninja" {
+		t.Errorf("ConfigureBuilder(release) = %s %s %v", b.BuildType, b.BuildDir, b.Generator)
+	}
+	got := strings.Join(b.GenerateFlags, " ")
+	want := "-DPROJECT_DIR=" + filepath.Base(dir) + " -DWITH_TESTS:BOOL=FALSE -DCMAKE_INSTALL_PREFIX:PATH=" + src + "/install"
+	if got != want {
+		t.Errorf("GenerateFlags = %q, want %q", got, want)
+	}
+	if v, _ := envValue(b.Env, "PATH"); v != "/opt/tools/bin"+string(os.PathListSeparator)+"/usr/bin" {
+		t.Errorf("PATH = %q", v)
+	}
+	if _, ok := envValue(b.Env, "UNSET_ME"); ok {
+		t.Errorf("UNSET_ME is set")
+	}
+
+	b, err = p.ConfigureBuilder("vs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.BuildType != Debug || b.Generator.Arch != "x64" || b.Generator.Toolset != "" {
+		t.Errorf("ConfigureBuilder(vs) = %s %+v", b.BuildType, b.Generator)
+	}
+
+	b, err = p.BuildBuilder("release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = strings.Join(b.EffectiveBuildArgs(), " ")
+	want = "--build " + b.BuildDir + " --config Release --target app --parallel 4 --clean-first -- -k 0"
+	if got != want {
+		t.Errorf("EffectiveBuildArgs() = %q, want %q", got, want)
+	}
+	if _, ok := envValue(b.Env, "BUILD_ONLY"); !ok {
+		t.Errorf("build preset environment is not applied")
+	}
+	if _, ok := envValue(b.Env, "TOOLS"); !ok {
+		t.Errorf("build preset does not inherit the configure environment")
+	}
+
+	tc, err := p.TestConfig("release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = strings.Join(tc.CTestArgs, " ")
+	want = "--output-on-failure -R ^unit -LE slow --stop-on-failure -j 2 --timeout 60"
+	if got != want {
+		t.Errorf("CTestArgs = %q, want %q", got, want)
+	}
+	if tc.Builder.BuildType != Release {
+		t.Errorf("test preset build type = %s", tc.Builder.BuildType)
+	}
+	if _, ok := envValue(tc.Builder.Env, "TOOLS"); ok {
+		t.Errorf("test preset inherits the configure environment")
+	}
+}
+
+func TestLoadPresets_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"cycle", `{"version": 3, "configurePresets": [{"name": "a", "inherits": "b", "binaryDir": "x"}, {"name": "b", "inherits": "a"}]}`},
+		{"missing parent", `{"version": 3, "configurePresets": [{"name": "a", "inherits": "b"}]}`},
+		{"duplicate", `{"version": 3, "configurePresets": [{"name": "a"}, {"name": "a"}]}`},
+		{"macro", `{"version": 3, "configurePresets": [{"name": "a", "binaryDir": "${nope}"}]}`},
+		{"vendor macro", `{"version": 3, "configurePresets": [{"name": "a", "binaryDir": "$vendor{x}"}]}`},
+		{"env cycle", `{"version": 3, "configurePresets": [{"name": "a", "environment": {"A": "$env{B}", "B": "$env{A}"}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writePresets(t, map[string]string{PresetsFileName: tt.content})
+			if _, err := LoadPresets(dir); err == nil {
+				t.Errorf("LoadPresets() succeeded")
+			}
+		})
+	}
+}
+
+func TestCondition(t *testing.T) {
+	m := &macroContext{presetName: "p", env: map[string]string{"CC": "clang"}}
+	tests := []struct {
+		json string
+		want bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`{"type": "const", "value": true}`, true},
+		{`{"type": "equals", "lhs": "$env{CC}", "rhs": "clang"}`, true},
+		{`{"type": "inList", "string": "${presetName}", "list": ["a", "p"]}`, true},
+		{`{"type": "notInList", "string": "${presetName}", "list": ["a", "p"]}`, false},
+		{`{"type": "matches", "string": "$env{CC}", "regex": "^cl"}`, true},
+		{`{"type": "anyOf", "conditions": [false, {"type": "notMatches", "string": "x", "regex": "y"}]}`, true},
+		{`{"type": "allOf", "conditions": [true, false]}`, false},
+		{`{"type": "not", "condition": false}`, true},
+	}
+	for _, tt := range tests {
+		var c Condition
+		if err := c.UnmarshalJSON([]byte(tt.json)); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := c.evaluate(m); err != nil || got != tt.want {
+			t.Errorf("%s = %v, %v, want %v", tt.json, got, err, tt.want)
+		}
+	}
+}
+
+func TestChainPresets(t *testing.T) {
+	dir := t.TempDir()
+	cc := filepath.Join(dir, "cc")
+	tc := &toolchain.Chain{
+		Name:        "my gcc",
+		Compiler:    "gcc",
+		Version:     "12.2.0",
+		Target:      triplet.Full{Original: "riscv64-linux-gnu", Target: triplet.Target{Arch: "riscv64", OS: "linux", ObjectFormat: triplet.ELF, Environment: "gnu", LibC: "glibc"}},
+		Tools:       toolchain.Toolset{toolchain.CCompiler: toolchain.ToolPath(cc)},
+		Environment: []string{"FOO=bar"},
+	}
+	f, err := ChainPresets([]*toolchain.Chain{tc, tc}, []BuildType{Debug, Release}, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, cp := range f.ConfigurePresets {
+		names = append(names, cp.Name)
+	}
+	if got := strings.Join(names, ","); got != "my-gcc-debug,my-gcc-release,my-gcc-2-debug,my-gcc-2-release" {
+		t.Errorf("preset names = %s", got)
+	}
+	if err := f.WriteFile(filepath.Join(dir, UserPresetsFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadPresets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.BuildBuilder("my-gcc-release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.BuildType != Release || filepath.ToSlash(b.BuildDir) != filepath.ToSlash(dir)+"/build/my-gcc-release" {
+		t.Errorf("BuildBuilder() = %s %s", b.BuildType, b.BuildDir)
+	}
+	if _, ok := envValue(b.Env, "FOO"); !ok {
+		t.Errorf("chain environment is not in the preset")
+	}
+	if cp := p.FindConfigure("my-gcc-release"); cp.ToolchainFile != filepath.ToSlash(dir)+"/build/toolchains/my-gcc.cmake" {
+		t.Errorf("toolchainFile = %s", cp.ToolchainFile)
+	}
+}
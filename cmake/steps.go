@@ -0,0 +1,285 @@
+package cmake
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InstallOptions controls Builder.Install
+type InstallOptions struct {
+	Prefix     string   // overrides CMAKE_INSTALL_PREFIX when not empty
+	Components []string // installs only these components, all when empty
+	Strip      bool     // strips the installed binaries
+}
+
+// InstallResult lists the installed files, as recorded by cmake in the
+// install manifests
+type InstallResult struct {
+	Prefix string   `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Files  []string `json:"files" yaml:"files"`
+}
+
+// TestOptions controls Builder.Test
+type TestOptions struct {
+	Filter      string        // regular expression that selects tests by name (-R)
+	Parallel    int           // number of tests run concurrently, ctest default when 0
+	Timeout     time.Duration // per-test timeout
+	JUnitOutput string        // keeps the JUnit report at this path when not empty
+	Args        []string      // extra ctest arguments, see TestConfig
+}
+
+// TestStatus is the outcome of a test
+type TestStatus string
+
+const (
+	TestPassed  = TestStatus("passed")
+	TestFailed  = TestStatus("failed")
+	TestSkipped = TestStatus("skipped")
+)
+
+// TestCase is the result of a single test
+type TestCase struct {
+	Name     string        `json:"name" yaml:"name"`
+	Status   TestStatus    `json:"status" yaml:"status"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+	Message  string        `json:"message,omitempty" yaml:"message,omitempty"`
+	Output   string        `json:"output,omitempty" yaml:"output,omitempty"`
+}
+
+// TestResult lists the results of a ctest run
+type TestResult struct {
+	Tests   []*TestCase `json:"tests" yaml:"tests"`
+	Passed  int         `json:"passed" yaml:"passed"`
+	Failed  int         `json:"failed" yaml:"failed"`
+	Skipped int         `json:"skipped" yaml:"skipped"`
+}
+
+// PackageResult lists the packages that cpack produced
+type PackageResult struct {
+	Files []string `json:"files" yaml:"files"`
+}
+
+func (b *Builder) cmakeCmd() string {
+	if b.CMakeCmd == "" {
+		return "cmake"
+	}
+	return b.CMakeCmd
+}
+
+// companionCmd returns ctest or cpack from the directory of the cmake
+// executable, or from PATH when CMakeCmd is not a path
+func (b *Builder) companionCmd(name string) string {
+	c := b.cmakeCmd()
+	if filepath.Base(c) == c {
+		return name
+	}
+	return filepath.Join(filepath.Dir(c), name+filepath.Ext(c))
+}
+
+// Install runs cmake --install and reads the install manifests that it
+// writes into the build directory
+func (b *Builder) Install(opts InstallOptions) (*InstallResult, error) {
+	args := []string{"--install", b.BuildDir, "--config", b.BuildType.String()}
+	if opts.Prefix != "" {
+		args = append(args, "--prefix", opts.Prefix)
+	}
+	if opts.Strip {
+		args = append(args, "--strip")
+	}
+	manifests := []string{"install_manifest.txt"}
+	runs := [][]string{args}
+	if len(opts.Components) > 0 {
+		manifests, runs = nil, nil
+		for _, c := range opts.Components {
+			manifests = append(manifests, "install_manifest_"+c+".txt")
+			runs = append(runs, append(append([]string{}, args...), "--component", c))
+		}
+	}
+
+	ret := &InstallResult{Prefix: opts.Prefix, Files: []string{}}
+	seen := map[string]bool{}
+	for i, a := range runs {
+		fn := filepath.Join(b.BuildDir, manifests[i])
+		os.Remove(fn)
+		cmd := exec.Command(b.cmakeCmd(), a...)
+		cmd.Stdout = b.Stdout
+		cmd.Stderr = b.Stderr
+		cmd.Env = b.Env
+		if err := cmd.Run(); err != nil {
+			return nil, err
+		}
+		files, err := readLines(fn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the install manifest: %w", err)
+		}
+		for _, f := range files {
+			if !seen[f] {
+				seen[f] = true
+				ret.Files = append(ret.Files, f)
+			}
+		}
+	}
+	return ret, nil
+}
+
+func readLines(fn string) ([]string, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ret := []string{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if ln := strings.TrimSpace(s.Text()); ln != "" {
+			ret = append(ret, ln)
+		}
+	}
+	return ret, s.Err()
+}
+
+// Test runs ctest in the build directory. The results are read from the
+// JUnit report that ctest writes (ctest 3.21 or later). Failing tests are
+// reported in the result and as an error.
+func (b *Builder) Test(opts TestOptions) (*TestResult, error) {
+	junit := opts.JUnitOutput
+	if junit == "" {
+		f, err := os.CreateTemp("", "ctest-*.xml")
+		if err != nil {
+			return nil, err
+		}
+		f.Close()
+		junit = f.Name()
+		defer os.Remove(junit)
+	}
+	junit, err := filepath.Abs(junit)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--test-dir", b.BuildDir, "-C", b.BuildType.String(), "--output-junit", junit}
+	if opts.Filter != "" {
+		args = append(args, "-R", opts.Filter)
+	}
+	if opts.Parallel > 0 {
+		args = append(args, "-j", strconv.Itoa(opts.Parallel))
+	}
+	if opts.Timeout > 0 {
+		args = append(args, "--timeout", strconv.Itoa(int(math.Ceil(opts.Timeout.Seconds()))))
+	}
+	args = append(args, opts.Args...)
+
+	cmd := exec.Command(b.companionCmd("ctest"), args...)
+	cmd.Stdout = b.Stdout
+	cmd.Stderr = b.Stderr
+	cmd.Env = b.Env
+	runErr := cmd.Run()
+
+	buf, err := os.ReadFile(junit)
+	if err != nil || len(buf) == 0 {
+		if runErr != nil {
+			return nil, runErr
+		}
+		return nil, fmt.Errorf("ctest did not write the JUnit report")
+	}
+	ret, err := ParseJUnit(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case ret.Failed > 0:
+		return ret, fmt.Errorf("%d of %d tests failed", ret.Failed, len(ret.Tests))
+	case runErr != nil && !errors.As(runErr, &exitErr):
+		return ret, runErr
+	}
+	return ret, nil
+}
+
+// ParseJUnit reads the results from a ctest JUnit report
+func ParseJUnit(r io.Reader) (*TestResult, error) {
+	var suite struct {
+		Cases []struct {
+			Name    string  `xml:"name,attr"`
+			Time    float64 `xml:"time,attr"`
+			Status  string  `xml:"status,attr"`
+			Failure *struct {
+				Message string `xml:"message,attr"`
+			} `xml:"failure"`
+			Skipped *struct {
+				Message string `xml:"message,attr"`
+			} `xml:"skipped"`
+			Output string `xml:"system-out"`
+		} `xml:"testcase"`
+	}
+	if err := xml.NewDecoder(r).Decode(&suite); err != nil {
+		return nil, fmt.Errorf("invalid JUnit report: %w", err)
+	}
+	ret := &TestResult{Tests: []*TestCase{}}
+	for _, c := range suite.Cases {
+		tc := &TestCase{
+			Name:     c.Name,
+			Status:   TestPassed,
+			Duration: time.Duration(c.Time * float64(time.Second)),
+			Output:   c.Output,
+		}
+		switch {
+		case c.Failure != nil:
+			tc.Status, tc.Message = TestFailed, c.Failure.Message
+			ret.Failed++
+		case c.Skipped != nil || c.Status == "notrun" || c.Status == "disabled":
+			tc.Status = TestSkipped
+			if c.Skipped != nil {
+				tc.Message = c.Skipped.Message
+			}
+			ret.Skipped++
+		default:
+			ret.Passed++
+		}
+		ret.Tests = append(ret.Tests, tc)
+	}
+	return ret, nil
+}
+
+// cpackGenerated matches the lines in which cpack reports the packages
+var cpackGenerated = regexp.MustCompile(`^CPack: - package: (.+) generated\.$`)
+
+// Package runs cpack in the build directory with the specified generators
+// (TGZ, ZIP, DEB, NSIS, ...), or with those of CPACK_GENERATOR when none are
+// specified
+func (b *Builder) Package(generators ...string) (*PackageResult, error) {
+	args := []string{"--config", filepath.Join(b.BuildDir, "CPackConfig.cmake"), "-C", b.BuildType.String()}
+	if len(generators) > 0 {
+		args = append(args, "-G", strings.Join(generators, ";"))
+	}
+	out := &bytes.Buffer{}
+	cmd := exec.Command(b.companionCmd("cpack"), args...)
+	cmd.Dir = b.BuildDir
+	cmd.Stdout = out
+	if b.Stdout != nil {
+		cmd.Stdout = io.MultiWriter(out, b.Stdout)
+	}
+	cmd.Stderr = b.Stderr
+	cmd.Env = b.Env
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	ret := &PackageResult{Files: []string{}}
+	for _, ln := range strings.Split(out.String(), "\n") {
+		if m := cpackGenerated.FindStringSubmatch(strings.TrimSpace(ln)); m != nil {
+			ret.Files = append(ret.Files, m[1])
+		}
+	}
+	return ret, nil
+}
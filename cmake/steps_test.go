@@ -0,0 +1,136 @@
+package cmake
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adnsv/go-build/diag"
+	"github.com/adnsv/go-build/internal/testutil"
+)
+
+// fakeTools writes cmake, ctest and cpack shell scripts that mimic the
+// outputs the steps read
+func fakeTools(t *testing.T) string {
+	return testutil.Scripts(t, map[string]string{
+		// cmake --install <dir> ... [--component <c>]
+		"cmake": `#!/bin/sh
+dir=$2
+manifest=install_manifest.txt
+while [ $# -gt 0 ]; do
+  [ "$1" = "--component" ] && manifest=install_manifest_$2.txt
+  shift
+done
+case $manifest in
+  *_dev.txt) printf '/usr/local/include/demo.h\n/usr/local/lib/libdemo.a\n' > "$dir/$manifest" ;;
+  *) printf '/usr/local/bin/demo\n/usr/local/lib/libdemo.a\n' > "$dir/$manifest" ;;
+esac
+`,
+		"ctest": testutil.CTest(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="Linux-c++" tests="3" failures="1" disabled="0" skipped="1" hostname="" time="1" timestamp="2024-01-01T00:00:00">
+	<testcase name="unit" classname="unit" time="0.25" status="run">
+		<system-out>ok</system-out>
+	</testcase>
+	<testcase name="broken" classname="broken" time="1.5" status="fail">
+		<failure message="Failed"/>
+		<system-out>assertion failed</system-out>
+	</testcase>
+	<testcase name="gpu" classname="gpu" time="0" status="notrun">
+		<skipped message="Disabled"/>
+	</testcase>
+</testsuite>`, 8),
+		"cpack": `#!/bin/sh
+echo "CPack: Create package using TGZ"
+echo "CPack: - package: $PWD/demo-1.0-Linux.tar.gz generated."
+echo "CPack: - checksum file: $PWD/demo-1.0-Linux.tar.gz.sha256 generated."
+echo "CPack: - package: $PWD/demo-1.0-Linux.zip generated."
+`,
+	})
+}
+
+func TestBuilder_Install(t *testing.T) {
+	tools := fakeTools(t)
+	b := &Builder{CMakeCmd: filepath.Join(tools, "cmake"), BuildDir: t.TempDir()}
+
+	r, err := b.Install(InstallOptions{Prefix: "/usr/local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(r.Files, ","); got != "/usr/local/bin/demo,/usr/local/lib/libdemo.a" {
+		t.Errorf("Install() files = %s", got)
+	}
+
+	r, err = b.Install(InstallOptions{Components: []string{"runtime", "dev"}, Strip: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(r.Files, ","); got != "/usr/local/bin/demo,/usr/local/lib/libdemo.a,/usr/local/include/demo.h" {
+		t.Errorf("Install(components) files = %s", got)
+	}
+}
+
+func TestBuilder_Test(t *testing.T) {
+	tools := fakeTools(t)
+	b := &Builder{CMakeCmd: filepath.Join(tools, "cmake"), BuildDir: t.TempDir(), BuildType: Debug}
+	junit := filepath.Join(t.TempDir(), "report.xml")
+
+	r, err := b.Test(TestOptions{Filter: "^u", Parallel: 4, Timeout: 1500 * time.Millisecond, JUnitOutput: junit, Args: []string{"--output-on-failure"}})
+	if err == nil || r == nil {
+		t.Fatalf("Test() = %v, %v, want a result with an error", r, err)
+	}
+	if r.Passed != 1 || r.Failed != 1 || r.Skipped != 1 || len(r.Tests) != 3 {
+		t.Errorf("Test() = %d passed, %d failed, %d skipped", r.Passed, r.Failed, r.Skipped)
+	}
+	if tc := r.Tests[1]; tc.Name != "broken" || tc.Status != TestFailed || tc.Duration != 1500*time.Millisecond || tc.Output != "assertion failed" {
+		t.Errorf("Tests[1] = %+v", tc)
+	}
+	if _, err := os.Stat(junit); err != nil {
+		t.Errorf("JUnit report was not kept: %v", err)
+	}
+	args, _ := os.ReadFile(filepath.Join(tools, "ctest.args"))
+	want := "--test-dir " + b.BuildDir + " -C Debug --output-junit " + junit + " -R ^u -j 4 --timeout 2 --output-on-failure\n"
+	if string(args) != want {
+		t.Errorf("ctest args = %q, want %q", args, want)
+	}
+}
+
+func TestBuilder_Package(t *testing.T) {
+	tools := fakeTools(t)
+	b := &Builder{CMakeCmd: filepath.Join(tools, "cmake"), BuildDir: t.TempDir()}
+	r, err := b.Package("TGZ", "ZIP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Files) != 2 || !strings.HasSuffix(r.Files[0], "/demo-1.0-Linux.tar.gz") || !strings.HasSuffix(r.Files[1], ".zip") {
+		t.Errorf("Package() files = %v", r.Files)
+	}
+}
+
+func TestBuilder_Diagnostics(t *testing.T) {
+	dir := testutil.Scripts(t, map[string]string{"cmake": `#!/bin/sh
+echo "[1/2] Building C object CMakeFiles/demo.dir/main.c.o"
+echo "main.c:3:9: warning: unused variable 'x' ($LC_ALL) [-Wunused-variable]"
+echo "main.c:5:1: error: expected ';' before '}' token" >&2
+exit 1
+`})
+	// both streams go to one writer, so they share a pipe and keep their
+	// order
+	out := &strings.Builder{}
+	b := &Builder{CMakeCmd: filepath.Join(dir, "cmake"), BuildDir: dir, Stdout: out, Stderr: out, Diagnostics: &diag.Parser{}}
+	if err := b.Build(); err == nil {
+		t.Errorf("Build() succeeded")
+	}
+	got := []string{}
+	for _, d := range b.Diagnostics.Diagnostics() {
+		got = append(got, d.Severity.String()+": "+d.Message)
+	}
+	want := "warning: unused variable 'x' (C)|error: expected ';' before '}' token"
+	if strings.Join(got, "|") != want {
+		t.Errorf("Diagnostics() = %v", got)
+	}
+	if !strings.Contains(out.String(), "[1/2] Building C object") {
+		t.Errorf("output = %q", out.String())
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/adnsv/go-build/cgo"
+	"github.com/adnsv/go-build/compiler/discover"
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/alecthomas/kong"
+)
+
+// GoTarget selects the Go target and the C toolchain for cgo builds
+type GoTarget struct {
+	Verbose   bool     `help:"Show verbose output"`
+	GOOS      string   `name:"goos" help:"Target operating system (defaults to GOOS or the host)"`
+	GOARCH    string   `name:"goarch" help:"Target architecture (defaults to GOARCH or the host)"`
+	GOARM     string   `name:"goarm" help:"Target arm version (defaults to GOARM)"`
+	GO386     string   `name:"go386" help:"Target 386 floating point mode (defaults to GO386)"`
+	Type      []string `short:"t" enum:"clang,gcc" help:"Comma separated toolchain types in the order of preference (clang|gcc)"`
+	Toolchain string   `short:"c" help:"Use the toolchain registered under this name"`
+}
+
+func (cmd *GoTarget) target() triplet.GoTarget {
+	pick := func(v, envname, def string) string {
+		if v != "" {
+			return v
+		}
+		if v = os.Getenv(envname); v != "" {
+			return v
+		}
+		return def
+	}
+	return triplet.GoTarget{
+		GOOS:   pick(cmd.GOOS, "GOOS", runtime.GOOS),
+		GOARCH: pick(cmd.GOARCH, "GOARCH", runtime.GOARCH),
+		GOARM:  pick(cmd.GOARM, "GOARM", ""),
+		GO386:  pick(cmd.GO386, "GO386", ""),
+	}
+}
+
+// choose discovers toolchains and picks the one for the Go target
+func (cmd *GoTarget) choose() (*toolchain.Chain, triplet.GoTarget, error) {
+	var feedback func(string)
+	if cmd.Verbose {
+		feedback = func(s string) {
+			log.Println(s)
+		}
+	}
+	g := cmd.target()
+	if _, err := triplet.FromGo(g.GOOS, g.GOARCH, g.GOARM, g.GO386); err != nil {
+		return nil, g, err
+	}
+	tt := discover.Toolchains(cmd.Type, feedback)
+	if cmd.Toolchain != "" {
+		for _, tc := range tt {
+			if tc.HasName(cmd.Toolchain) {
+				if !tc.Target.MatchGo(g) {
+					return nil, g, fmt.Errorf("toolchain '%s' targets %s, not %s", cmd.Toolchain, tc.Target.Original, g)
+				}
+				return tc, g, nil
+			}
+		}
+		return nil, g, fmt.Errorf("toolchain '%s' not found", cmd.Toolchain)
+	}
+	tc, err := cgo.Select(tt, g, cmd.Type...)
+	if err != nil {
+		return nil, g, err
+	}
+	if feedback != nil {
+		feedback(fmt.Sprintf("using %s for %s", chainLabel(tc), g))
+	}
+	return tc, g, nil
+}
+
+type CgoEnv struct {
+	GoTarget
+	Format string `short:"f" enum:"env,sh,powershell,cmd,json" placeholder:"env|sh|powershell|cmd|json" default:"env" help:"Output format (defaults to env, compatible with go env -w)"`
+}
+
+func (cmd *CgoEnv) Run(ctx *kong.Context) error {
+	tc, g, err := cmd.choose()
+	if err != nil {
+		return err
+	}
+	vars, err := cgo.Env(tc, g)
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	if cmd.Format == "json" {
+		buf, err = json.MarshalIndent(vars, "", "  ")
+		buf = append(buf, '\n')
+	} else {
+		var s string
+		s, err = cgo.Format(vars, cmd.Format)
+		buf = []byte(s)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(buf)
+	return err
+}
+
+type GoBuild struct {
+	GoTarget
+	Args []string `arg:"" optional:"" passthrough:"" help:"Arguments passed to go build"`
+}
+
+func (cmd *GoBuild) Run(ctx *kong.Context) error {
+	tc, g, err := cmd.choose()
+	if err != nil {
+		return err
+	}
+	environ, err := cgo.Environ(tc, g)
+	if err != nil {
+		return err
+	}
+	c := exec.Command("go", append([]string{"build"}, cmd.Args...)...)
+	c.Env = environ
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if cmd.Verbose {
+		log.Println(c)
+	}
+	return c.Run()
+}
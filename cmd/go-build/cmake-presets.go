@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/adnsv/go-build/cmake"
+	"github.com/adnsv/go-build/compiler/discover"
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-utils/filesystem"
+	"github.com/alecthomas/kong"
+)
+
+type CMakePresets struct {
+	Verbose   bool     `help:"Show verbose output"`
+	Source    string   `short:"s" type:"path" default:"." help:"CMake source directory"`
+	Output    string   `short:"o" type:"path" help:"Write presets to the specified file (defaults to CMakeUserPresets.json in the source directory)"`
+	Force     bool     `help:"Overwrite an existing presets file"`
+	Type      []string `short:"t" enum:"msvc,clang,gcc" help:"Comma separated toolchain types (msvc|clang|gcc)"`
+	Native    bool     `short:"n" help:"Do not include cross compiling toolchains"`
+	BuildType []string `short:"b" enum:"Debug,Release,MinSizeRel,RelWithDebInfo" default:"Debug,Release" help:"Comma separated build types (Debug|Release|MinSizeRel|RelWithDebInfo)"`
+	Name      []string `arg:"" optional:"" help:"Include only the toolchains registered under these names"`
+}
+
+func (cmd *CMakePresets) Run(ctx *kong.Context) error {
+	var feedback func(string)
+	if cmd.Verbose {
+		feedback = func(s string) {
+			log.Println(s)
+		}
+	}
+	tt := discover.Toolchains(cmd.Type, feedback)
+	if cmd.Native {
+		tt = discover.Natives(tt)
+	}
+	if len(cmd.Name) > 0 {
+		sel := []*toolchain.Chain{}
+		for _, tc := range tt {
+			for _, n := range cmd.Name {
+				if tc.HasName(n) {
+					sel = append(sel, tc)
+					break
+				}
+			}
+		}
+		tt = sel
+	}
+	if len(tt) == 0 {
+		return fmt.Errorf("no toolchains found")
+	}
+
+	types := []cmake.BuildType{}
+	for _, s := range cmd.BuildType {
+		bt, err := cmake.ParseBuildType(s)
+		if err != nil {
+			return err
+		}
+		types = append(types, bt)
+	}
+
+	f, err := cmake.ChainPresets(tt, types, cmd.Source)
+	if err != nil {
+		return err
+	}
+	fn := cmd.Output
+	if fn == "" {
+		fn = filepath.Join(cmd.Source, cmake.UserPresetsFileName)
+	}
+	if !cmd.Force && filesystem.FileExists(fn) {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", fn)
+	}
+	fmt.Fprintf(os.Stderr, "writing %d presets to %s ... ", len(f.ConfigurePresets), fn)
+	err = f.WriteFile(fn)
+	if err == nil {
+		fmt.Fprintf(os.Stderr, "SUCCEEDED\n")
+	} else {
+		fmt.Fprintf(os.Stderr, "FAILED\n")
+	}
+	return err
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/adnsv/go-build/diag"
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+type Diagnostics struct {
+	Format  string   `short:"f" enum:"summary,json,yaml,sarif,github" placeholder:"summary|json|yaml|sarif|github" default:"summary" help:"Output format (defaults to summary)"`
+	Output  string   `short:"o" type:"path" help:"Write the diagnostics to the specified file instead of stdout"`
+	Tool    string   `help:"Tool name recorded in SARIF output" default:"compiler"`
+	BaseDir string   `type:"path" default:"." help:"Source root, files under it are reported relative to it in SARIF and GitHub annotations"`
+	Werror  bool     `help:"Fail on warnings as well as errors"`
+	Logs    []string `arg:"" optional:"" type:"existingfile" help:"Build logs to parse (defaults to stdin)"`
+}
+
+func (cmd *Diagnostics) Run(ctx *kong.Context) error {
+	dd := []*diag.Diagnostic{}
+	parse := func(r io.Reader) error {
+		v, err := diag.Parse(r)
+		dd = append(dd, v...)
+		return err
+	}
+	if len(cmd.Logs) == 0 {
+		if err := parse(os.Stdin); err != nil {
+			return err
+		}
+	}
+	for _, fn := range cmd.Logs {
+		f, err := os.Open(fn)
+		if err != nil {
+			return err
+		}
+		err = parse(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if cmd.Output != "" {
+		f, err := os.Create(cmd.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	var err error
+	switch cmd.Format {
+	case "json":
+		err = diag.WriteJSON(w, dd)
+	case "yaml":
+		var buf []byte
+		if buf, err = yaml.Marshal(dd); err == nil {
+			_, err = w.Write(buf)
+		}
+	case "sarif":
+		err = diag.WriteSARIF(w, dd, diag.SARIFOptions{Tool: cmd.Tool, BaseDir: cmd.BaseDir})
+	case "github":
+		for _, d := range dd {
+			if err = diag.WriteGitHubAnnotation(w, d, cmd.BaseDir); err != nil {
+				break
+			}
+		}
+	case "summary":
+		for _, d := range dd {
+			fmt.Fprintln(w, d)
+			for _, n := range d.Notes {
+				fmt.Fprintf(w, "  %s\n", n)
+			}
+		}
+		fmt.Fprintf(w, "%s, %s\n", plural(diag.Count(dd, diag.Error), "error"), plural(diag.Count(dd, diag.Warning)-diag.Count(dd, diag.Error), "warning"))
+	default:
+		return fmt.Errorf("unsupported format '%s'", cmd.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	level := diag.Error
+	if cmd.Werror {
+		level = diag.Warning
+	}
+	if n := diag.Count(dd, level); n > 0 {
+		return errors.New(plural(n, "diagnostic") + " at or above " + level.String())
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ type DiscoverToolchains struct {
 	Type          []string `short:"t" enum:"msvc,clang,gcc" help:"Comma separated toolchain types (msvc|clang|gcc)"`
 	Native        bool     `short:"n" help:"Do not return cross compiling toolchains"`
 	Installations bool     `short:"i" help:"Show compiler installations instead of toolchains"`
+	ISA           bool     `help:"Probe supported -march/-mcpu/-mtune values and the native ISA"`
 }
 
 func (cmd *DiscoverToolchains) Run(ctx *kong.Context) error {
@@ -60,6 +61,18 @@ func (cmd *DiscoverToolchains) Run(ctx *kong.Context) error {
 		if cmd.Native {
 			tt = discover.Natives(tt)
 		}
+		if cmd.ISA {
+			for _, tc := range tt {
+				isa, err := tc.ProbeISA()
+				if err != nil {
+					if feedback != nil {
+						feedback(fmt.Sprintf("failed to probe ISA for %s %s: %s", tc.Compiler, tc.Version, err))
+					}
+					continue
+				}
+				tc.ISA = isa
+			}
+		}
 		switch cmd.Format {
 		case "json":
 			buf, err = json.MarshalIndent(tt, "", "  ")
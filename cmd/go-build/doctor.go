@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/discover"
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+type Doctor struct {
+	Verbose bool     `help:"Show verbose output, including diagnostics of passed steps"`
+	Format  string   `short:"f" enum:"summary,json,yaml" placeholder:"summary|json|yaml" default:"summary" help:"Output format (defaults to summary)"`
+	Type    []string `short:"t" enum:"msvc,clang,gcc" help:"Comma separated toolchain types (msvc|clang|gcc)"`
+	Native  bool     `short:"n" help:"Do not check cross compiling toolchains"`
+	Name    string   `arg:"" optional:"" help:"Check only the toolchain registered under this name"`
+}
+
+type chainReport struct {
+	Name   string            `json:"name" yaml:"name"`
+	Passed bool              `json:"passed" yaml:"passed"`
+	Report *toolchain.Report `json:"report" yaml:"report"`
+}
+
+func (cmd *Doctor) Run(ctx *kong.Context) error {
+	var feedback func(string)
+	if cmd.Verbose {
+		feedback = func(s string) {
+			log.Println(s)
+		}
+	}
+	tt := discover.Toolchains(cmd.Type, feedback)
+	if cmd.Native {
+		tt = discover.Natives(tt)
+	}
+	if cmd.Name != "" {
+		sel := []*toolchain.Chain{}
+		for _, tc := range tt {
+			if tc.HasName(cmd.Name) {
+				sel = append(sel, tc)
+			}
+		}
+		if len(sel) == 0 {
+			return fmt.Errorf("toolchain '%s' not found", cmd.Name)
+		}
+		tt = sel
+	}
+
+	results := []*chainReport{}
+	failed := 0
+	for _, tc := range tt {
+		r := toolchain.Verify(tc)
+		results = append(results, &chainReport{Name: chainLabel(tc), Passed: r.Passed(), Report: r})
+		if !r.Passed() {
+			failed++
+		}
+	}
+
+	var buf []byte
+	var err error
+	switch cmd.Format {
+	case "json":
+		buf, err = json.MarshalIndent(results, "", "  ")
+	case "yaml":
+		buf, err = yaml.Marshal(results)
+	case "summary":
+		w := &bytes.Buffer{}
+		for _, cr := range results {
+			status := "OK"
+			if !cr.Passed {
+				status = "FAILED"
+			}
+			fmt.Fprintf(w, "%s: %s\n", cr.Name, status)
+			for _, s := range cr.Report.Steps {
+				switch {
+				case s.Skipped:
+					fmt.Fprintf(w, "- %s: skipped (%s)\n", s.Name, s.Error)
+				case s.Passed:
+					fmt.Fprintf(w, "- %s: passed\n", s.Name)
+				default:
+					fmt.Fprintf(w, "- %s: FAILED (%s)\n", s.Name, s.Error)
+				}
+				if s.Output != "" && (!s.Passed || cmd.Verbose) {
+					if len(s.Command) > 0 {
+						fmt.Fprintf(w, "  $ %s\n", strings.Join(s.Command, " "))
+					}
+					for _, line := range strings.Split(strings.TrimRight(s.Output, "\r\n"), "\n") {
+						fmt.Fprintf(w, "  | %s\n", line)
+					}
+				}
+			}
+			for _, h := range cr.Report.Hints {
+				fmt.Fprintf(w, "- hint: %s\n", h)
+			}
+		}
+		if len(results) == 0 {
+			fmt.Fprintln(w, "no compilers found")
+		}
+		buf = w.Bytes()
+	default:
+		return fmt.Errorf("unsupported format '%s'", cmd.Format)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stdout.Write(buf); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.New(plural(failed, "toolchain") + " failed verification")
+	}
+	return nil
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, s)
+	}
+	return fmt.Sprintf("%d %ss", n, s)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/adnsv/go-build/compiler/discover"
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+type Features struct {
+	Verbose bool     `help:"Show verbose output"`
+	Format  string   `short:"f" enum:"summary,json,yaml" placeholder:"summary|json|yaml" default:"summary" help:"Output format (defaults to summary)"`
+	Type    []string `short:"t" enum:"msvc,clang,gcc" help:"Comma separated toolchain types (msvc|clang|gcc)"`
+	Native  bool     `short:"n" help:"Do not return cross compiling toolchains"`
+	Macro   []string `short:"m" help:"Comma separated feature-test macros to show in the matrix (e.g. __cpp_lib_format,__cpp_lib_expected)"`
+}
+
+type chainFeatures struct {
+	Name     string              `json:"name" yaml:"name"`
+	Features *toolchain.Features `json:"features,omitempty" yaml:"features,omitempty"`
+	Error    string              `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func (cmd *Features) Run(ctx *kong.Context) error {
+	var feedback func(string)
+	if cmd.Verbose {
+		feedback = func(s string) {
+			log.Println(s)
+		}
+	}
+	tt := discover.Toolchains(cmd.Type, feedback)
+	if cmd.Native {
+		tt = discover.Natives(tt)
+	}
+	results := []*chainFeatures{}
+	for _, tc := range tt {
+		r := &chainFeatures{Name: chainLabel(tc)}
+		if feedback != nil {
+			feedback(fmt.Sprintf("probing %s", r.Name))
+		}
+		f, err := tc.Features()
+		if err != nil {
+			r.Error = err.Error()
+		} else {
+			r.Features = f
+		}
+		results = append(results, r)
+	}
+
+	var buf []byte
+	var err error
+	switch cmd.Format {
+	case "json":
+		buf, err = json.MarshalIndent(results, "", "  ")
+	case "yaml":
+		buf, err = yaml.Marshal(results)
+	case "summary":
+		buf = cmd.matrix(results)
+	default:
+		return fmt.Errorf("unsupported format '%s'", cmd.Format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(buf)
+	return err
+}
+
+// matrix prints chains as rows and standards (and the requested macros) as
+// columns
+func (cmd *Features) matrix(results []*chainFeatures) []byte {
+	w := &bytes.Buffer{}
+	if len(results) == 0 {
+		fmt.Fprintln(w, "no compilers found")
+		return w.Bytes()
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	cols := append(append([]string{"TOOLCHAIN"}, toolchain.CStandards...), toolchain.CXXStandards...)
+	cols = append(cols, "STDLIB")
+	cols = append(cols, cmd.Macro...)
+	fmt.Fprintln(tw, strings.Join(cols, "\t"))
+	for _, r := range results {
+		row := []string{r.Name}
+		if r.Features == nil {
+			row = append(row, "error: "+r.Error)
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+			continue
+		}
+		for _, s := range append(append([]string{}, toolchain.CStandards...), toolchain.CXXStandards...) {
+			if st := r.Features.Get(s); st != nil && st.Partial {
+				row = append(row, "partial")
+			} else if st != nil {
+				row = append(row, "yes")
+			} else {
+				row = append(row, "-")
+			}
+		}
+		if lib := r.Features.Library; lib != nil {
+			row = append(row, strings.TrimSpace(lib.Name+" "+lib.Version))
+		} else {
+			row = append(row, "-")
+		}
+		for _, m := range cmd.Macro {
+			if v := r.Features.Macro(m); v != "" {
+				row = append(row, v)
+			} else {
+				row = append(row, "-")
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+	return w.Bytes()
+}
+
+func chainLabel(tc *toolchain.Chain) string {
+	if tc.Name != "" {
+		return tc.Name
+	}
+	return fmt.Sprintf("%s-%s %s", tc.Compiler, tc.Version, tc.Target.Original)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adnsv/go-build/host"
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+type Host struct {
+	Format string `short:"f" enum:"summary,json,yaml" placeholder:"summary|json|yaml" default:"summary" help:"Output format (defaults to summary)"`
+}
+
+func (cmd *Host) Run(ctx *kong.Context) error {
+	h := host.Detect()
+	var buf []byte
+	var err error
+	switch cmd.Format {
+	case "json":
+		buf, err = json.MarshalIndent(h, "", "  ")
+	case "yaml":
+		buf, err = yaml.Marshal(h)
+	case "summary":
+		w := &bytes.Buffer{}
+		fmt.Fprintf(w, "host %s\n", h.Target())
+		fmt.Fprintf(w, "- os: %s\n", h.OS)
+		fmt.Fprintf(w, "- arch: %s\n", h.Arch)
+		if h.LibC != "" {
+			fmt.Fprintf(w, "- libc: %s %s\n", h.LibC, h.LibCVersion)
+		}
+		if h.Interpreter != "" {
+			fmt.Fprintf(w, "- interpreter: %s\n", h.Interpreter)
+		}
+		if h.Kernel != "" {
+			fmt.Fprintf(w, "- kernel: %s\n", h.Kernel)
+		}
+		if h.Distro != nil {
+			fmt.Fprintf(w, "- distro: %s %s (%s)\n", h.Distro.ID, h.Distro.Version, h.Distro.Name)
+		}
+		if h.CPU.Model != "" {
+			fmt.Fprintf(w, "- cpu: %s\n", h.CPU.Model)
+		}
+		if len(h.CPU.Features) > 0 {
+			fmt.Fprintf(w, "- cpu features: %s\n", strings.Join(h.CPU.Features, " "))
+		}
+		buf = w.Bytes()
+	default:
+		return fmt.Errorf("unsupported format '%s'", cmd.Format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(buf)
+	return err
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/adnsv/go-build/inspect"
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+type Inspect struct {
+	Format string   `short:"f" enum:"summary,json,yaml" placeholder:"summary|json|yaml" default:"summary" help:"Output format (defaults to summary)"`
+	Expect string   `short:"e" placeholder:"PATTERN" help:"Fail unless every file matches the target pattern, e.g. arm64-linux-*-musl"`
+	Files  []string `arg:"" help:"Executables, libraries or object files to inspect"`
+}
+
+type inspectResult struct {
+	File string        `json:"file" yaml:"file"`
+	Info *inspect.Info `json:"info" yaml:"info"`
+}
+
+func (cmd *Inspect) Run(ctx *kong.Context) error {
+	var expect *triplet.Pattern
+	if cmd.Expect != "" {
+		p, err := triplet.ParsePattern(cmd.Expect)
+		if err != nil {
+			return err
+		}
+		expect = &p
+	}
+
+	results := []*inspectResult{}
+	mismatched := []string{}
+	for _, fn := range cmd.Files {
+		info, err := inspect.File(fn)
+		if err != nil {
+			return err
+		}
+		results = append(results, &inspectResult{File: fn, Info: info})
+		if expect != nil && !matchesPattern(info, *expect) {
+			mismatched = append(mismatched, fn)
+		}
+	}
+
+	var buf []byte
+	var err error
+	switch cmd.Format {
+	case "json":
+		buf, err = json.MarshalIndent(results, "", "  ")
+	case "yaml":
+		buf, err = yaml.Marshal(results)
+	case "summary":
+		w := &bytes.Buffer{}
+		for _, r := range results {
+			fmt.Fprintf(w, "%s: %s %s\n", r.File, r.Info.Target.Text(), r.Info.Kind)
+			printInspectInfo(w, r.Info, "- ")
+			for _, s := range r.Info.Slices {
+				fmt.Fprintf(w, "- slice %s\n", s.Target.Text())
+				printInspectInfo(w, s, "  - ")
+			}
+		}
+		buf = w.Bytes()
+	default:
+		return fmt.Errorf("unsupported format '%s'", cmd.Format)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = os.Stdout.Write(buf); err != nil {
+		return err
+	}
+	if len(mismatched) > 0 {
+		return errors.New(strings.Join(mismatched, ", ") + " did not match " + cmd.Expect)
+	}
+	return nil
+}
+
+func printInspectInfo(w *bytes.Buffer, i *inspect.Info, indent string) {
+	if i.Interpreter != "" {
+		fmt.Fprintf(w, "%sinterpreter: %s\n", indent, i.Interpreter)
+	}
+	if i.Static {
+		fmt.Fprintf(w, "%sstatically linked\n", indent)
+	}
+	if i.Subsystem != "" {
+		fmt.Fprintf(w, "%ssubsystem: %s\n", indent, i.Subsystem)
+	}
+	if i.Target.OSVersion != "" {
+		fmt.Fprintf(w, "%sminimum os version: %s\n", indent, i.Target.OSVersion)
+	}
+	if i.Target.LibCVersion != "" {
+		fmt.Fprintf(w, "%sminimum libc version: %s\n", indent, i.Target.LibCVersion)
+	}
+	if i.SDKVersion != "" {
+		fmt.Fprintf(w, "%ssdk version: %s\n", indent, i.SDKVersion)
+	}
+	if len(i.Libraries) > 0 {
+		fmt.Fprintf(w, "%slibraries: %s\n", indent, strings.Join(i.Libraries, " "))
+	}
+}
+
+// matchesPattern checks the artifact, or any slice of a universal binary
+func matchesPattern(i *inspect.Info, p triplet.Pattern) bool {
+	if len(i.Slices) == 0 {
+		return p.Match(i.Target)
+	}
+	for _, s := range i.Slices {
+		if p.Match(s.Target) {
+			return true
+		}
+	}
+	return false
+}
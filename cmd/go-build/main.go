@@ -4,6 +4,16 @@ import "github.com/alecthomas/kong"
 
 var cli struct {
 	DiscoverToolchains DiscoverToolchains `cmd:"" help:"Show available C/C++ toolchains."`
+	Toolchain          Toolchain          `cmd:"" help:"Manage the toolchain registry."`
+	Host               Host               `cmd:"" help:"Describe the host platform."`
+	Doctor             Doctor             `cmd:"" help:"Check that toolchains can compile, link, and run programs."`
+	CgoEnv             CgoEnv             `cmd:"" name:"cgo-env" help:"Print the cgo environment for building a Go target with a matching C toolchain."`
+	GoBuild            GoBuild            `cmd:"" name:"go-build" help:"Run go build with the cgo environment for the Go target."`
+	Features           Features           `cmd:"" help:"Show language standards and library features supported by toolchains."`
+	Inspect            Inspect            `cmd:"" help:"Show the target that binaries, libraries and object files were built for."`
+	CMakePresets       CMakePresets       `cmd:"" name:"cmake-presets" help:"Write CMake presets for discovered toolchains and build types."`
+	Matrix             Matrix             `cmd:"" help:"Build a CMake project with combinations of toolchains, build types and options."`
+	Diagnostics        Diagnostics        `cmd:"" help:"Parse compiler diagnostics from build logs and report them as JSON, SARIF or CI annotations."`
 	Version            kong.VersionFlag   `short:"v" help:"Print version information and quit."`
 }
 
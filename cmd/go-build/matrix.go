@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/adnsv/go-build/compiler/discover"
+	"github.com/adnsv/go-build/matrix"
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+type Matrix struct {
+	Verbose     bool   `help:"Show verbose output"`
+	Format      string `short:"f" enum:"summary,json,yaml" placeholder:"summary|json|yaml" default:"summary" help:"Output format (defaults to summary)"`
+	Concurrency int    `short:"j" help:"Number of combinations built at the same time (overrides the spec)"`
+	FailFast    bool   `help:"Skip the remaining combinations after a failure"`
+	JUnit       string `type:"path" name:"junit" help:"Write a JUnit XML report to the specified file"`
+	JSON        string `type:"path" name:"json" help:"Write a JSON report to the specified file"`
+	DryRun      bool   `help:"List the combinations without building them"`
+	Spec        string `arg:"" type:"existingfile" help:"Matrix spec file (YAML)"`
+}
+
+func (cmd *Matrix) Run(ctx *kong.Context) error {
+	var feedback func(string)
+	if cmd.Verbose {
+		feedback = func(s string) {
+			log.Println(s)
+		}
+	}
+	spec, err := matrix.LoadSpec(cmd.Spec)
+	if err != nil {
+		return err
+	}
+	if cmd.Concurrency > 0 {
+		spec.Concurrency = cmd.Concurrency
+	}
+	spec.FailFast = spec.FailFast || cmd.FailFast
+
+	jobs, err := spec.Expand(discover.Toolchains(nil, feedback))
+	if err != nil {
+		return err
+	}
+	if cmd.DryRun {
+		for _, j := range jobs {
+			fmt.Printf("%s: %s\n", j.Name, j.BuildDir)
+		}
+		return nil
+	}
+
+	report := spec.Run(jobs, func(s string) { log.Println(s) })
+
+	if cmd.JUnit != "" {
+		if err := writeReport(cmd.JUnit, report.WriteJUnit); err != nil {
+			return err
+		}
+	}
+	if cmd.JSON != "" {
+		if err := writeReport(cmd.JSON, report.WriteJSON); err != nil {
+			return err
+		}
+	}
+
+	switch cmd.Format {
+	case "json":
+		buf, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(buf))
+	case "yaml":
+		buf, err := yaml.Marshal(report)
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(buf)
+	case "summary":
+		report.PrintSummary(os.Stdout)
+	default:
+		return fmt.Errorf("unsupported format '%s'", cmd.Format)
+	}
+	if n := report.Failed(); n > 0 {
+		return errors.New(plural(n, "combination") + " failed")
+	}
+	return nil
+}
+
+func writeReport(fn string, write func(w io.Writer) error) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
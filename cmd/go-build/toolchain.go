@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/discover"
+	"github.com/adnsv/go-build/compiler/registry"
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/env"
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+type Toolchain struct {
+	Add    ToolchainAdd    `cmd:"" help:"Register a toolchain."`
+	Remove ToolchainRemove `cmd:"" help:"Remove a registered toolchain."`
+	List   ToolchainList   `cmd:"" help:"List registered toolchains and overrides."`
+}
+
+type ToolchainAdd struct {
+	Name    string   `arg:"" help:"Stable toolchain name"`
+	Path    string   `arg:"" help:"Path to the C compiler executable"`
+	CXX     string   `help:"Path to the C++ compiler executable"`
+	Type    string   `short:"t" enum:"gcc,clang," default:"" help:"Toolchain type (gcc|clang), detected if not specified"`
+	Alias   []string `short:"a" help:"Alternative names"`
+	Env     []string `short:"e" help:"Environment variables (KEY=VALUE)"`
+	User    bool     `short:"u" help:"Write to the user registry instead of the project registry"`
+	NoProbe bool     `help:"Do not query the compiler, store the entry as specified"`
+	Verbose bool     `help:"Show verbose output"`
+}
+
+func (cmd *ToolchainAdd) Run(ctx *kong.Context) error {
+	fn, err := registryPath(cmd.User)
+	if err != nil {
+		return err
+	}
+	reg, err := registry.LoadFile(fn)
+	if err != nil {
+		return err
+	}
+
+	tc := &toolchain.Chain{
+		Name:     cmd.Name,
+		Aliases:  cmd.Alias,
+		Compiler: cmd.Type,
+		Tools:    toolchain.Toolset{toolchain.CCompiler: toolchain.ToolPath(cmd.Path)},
+	}
+	if cmd.CXX != "" {
+		tc.Tools[toolchain.CXXCompiler] = toolchain.ToolPath(cmd.CXX)
+	}
+	if len(cmd.Env) > 0 {
+		tc.Environment = env.Join(env.Split(cmd.Env))
+	}
+	if !cmd.NoProbe {
+		var feedback func(string)
+		if cmd.Verbose {
+			feedback = func(s string) { log.Println(s) }
+		}
+		if err := discover.Complete(tc, feedback); err != nil {
+			return fmt.Errorf("failed to probe '%s': %w", cmd.Path, err)
+		}
+	}
+	if err = reg.Add(tc); err != nil {
+		return err
+	}
+	if err = reg.SaveFile(fn); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "registered toolchain '%s' in %s\n", tc.Name, fn)
+	return nil
+}
+
+type ToolchainRemove struct {
+	Name string `arg:"" help:"Toolchain name or alias"`
+	User bool   `short:"u" help:"Remove from the user registry instead of the project registry"`
+}
+
+func (cmd *ToolchainRemove) Run(ctx *kong.Context) error {
+	fn, err := registryPath(cmd.User)
+	if err != nil {
+		return err
+	}
+	reg, err := registry.LoadFile(fn)
+	if err != nil {
+		return err
+	}
+	if !reg.Remove(cmd.Name) {
+		return fmt.Errorf("toolchain '%s' is not registered in %s", cmd.Name, fn)
+	}
+	if err = reg.SaveFile(fn); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "removed toolchain '%s' from %s\n", cmd.Name, fn)
+	return nil
+}
+
+type ToolchainList struct {
+	Format string `short:"f" enum:"summary,json,yaml" placeholder:"summary|json|yaml" default:"summary" help:"Output format (defaults to summary)"`
+}
+
+func (cmd *ToolchainList) Run(ctx *kong.Context) error {
+	type source struct {
+		Path     string             `json:"path" yaml:"path"`
+		Registry *registry.Registry `json:"registry" yaml:"registry"`
+	}
+	sources := []source{}
+	for _, user := range []bool{true, false} {
+		fn, err := registryPath(user)
+		if err != nil {
+			continue
+		}
+		reg, err := registry.LoadFile(fn)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, source{Path: fn, Registry: reg})
+	}
+
+	var buf []byte
+	var err error
+	switch cmd.Format {
+	case "json":
+		buf, err = json.MarshalIndent(sources, "", "  ")
+	case "yaml":
+		buf, err = yaml.Marshal(sources)
+	case "summary":
+		w := &bytes.Buffer{}
+		for _, src := range sources {
+			fmt.Fprintf(w, "%s\n", src.Path)
+			reg := src.Registry
+			if len(reg.Toolchains) == 0 && len(reg.Overrides) == 0 {
+				fmt.Fprintf(w, "- no entries\n")
+			}
+			for _, tc := range reg.Toolchains {
+				cc, _ := tc.GetCompilerPaths()
+				fmt.Fprintf(w, "- toolchain %s: %s %s %s '%s'\n", tc.Name, tc.Compiler, tc.Version, tc.Target.Original, cc)
+				if len(tc.Aliases) > 0 {
+					fmt.Fprintf(w, "  - aliases: %s\n", strings.Join(tc.Aliases, ", "))
+				}
+			}
+			for _, o := range reg.Overrides {
+				fmt.Fprintf(w, "- override %s\n", describeSelector(&o.Select))
+				if o.Name != "" {
+					fmt.Fprintf(w, "  - name: %s\n", o.Name)
+				}
+				if len(o.Aliases) > 0 {
+					fmt.Fprintf(w, "  - aliases: %s\n", strings.Join(o.Aliases, ", "))
+				}
+				for tool, path := range o.Tools {
+					fmt.Fprintf(w, "  - %s: '%s'\n", tool.LongName(), path)
+				}
+				for _, e := range o.Environment {
+					fmt.Fprintf(w, "  - env: %s\n", e)
+				}
+				if o.Hide {
+					fmt.Fprintf(w, "  - hidden\n")
+				}
+			}
+		}
+		buf = w.Bytes()
+	default:
+		return fmt.Errorf("unsupported format '%s'", cmd.Format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(buf)
+	return err
+}
+
+func registryPath(user bool) (string, error) {
+	if user {
+		return registry.UserPath()
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", errors.New("failed to determine project directory")
+	}
+	return registry.ProjectPath(wd), nil
+}
+
+func describeSelector(s *registry.Selector) string {
+	parts := []string{}
+	add := func(k, v string) {
+		if v != "" {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	add("name", s.Name)
+	add("compiler", s.Compiler)
+	add("implementation", s.Implementation)
+	add("version", s.Version)
+	add("target", s.Target)
+	add("path", s.Path)
+	if len(parts) == 0 {
+		return "(all)"
+	}
+	return strings.Join(parts, " ")
+}
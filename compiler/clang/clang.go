@@ -16,10 +16,16 @@ type Installation struct {
 func (i *Installation) PrintSummary(w io.Writer) {
 	fmt.Fprintf(w, "%s %s\n", i.Ver.Implementation, i.Version)
 	fmt.Fprintf(w, "- full version: '%s'\n", i.FullVersion)
+	if i.LLVMVersion != "" {
+		fmt.Fprintf(w, "- llvm version: %s (%s)\n", i.LLVMVersion, i.CXXStandard)
+	}
 	fmt.Fprintf(w, "- primary target: %s\n", i.Target.Original)
 	fmt.Fprintf(w, "  - os: %s\n", i.Target.OS)
 	fmt.Fprintf(w, "  - arch: %s\n", i.Target.Arch)
-	fmt.Fprintf(w, "  - abi: %s\n", i.Target.ABI)
+	fmt.Fprintf(w, "  - object format: %s\n", i.Target.ObjectFormat)
+	if i.Target.Environment != "" {
+		fmt.Fprintf(w, "  - environment: %s\n", i.Target.Environment)
+	}
 	fmt.Fprintf(w, "  - libc: %s\n", i.Target.LibC)
 	fmt.Fprintf(w, "- thread model: %s\n", i.ThreadModel)
 	fmt.Fprintf(w, "- CC primary path: '%s'\n", i.CCompiler.PrimaryPath)
@@ -33,4 +39,10 @@ func (i *Installation) PrintSummary(w io.Writer) {
 		fmt.Fprintf(w, "- CC symlink path: '%s'\n", v)
 	}
 	fmt.Fprintf(w, "- installed dir: %s\n", i.InstalledDir)
+	if i.Sysroot != "" {
+		fmt.Fprintf(w, "- sysroot: %s\n", i.Sysroot)
+	}
+	if i.SDKVersion != "" {
+		fmt.Fprintf(w, "- sdk version: %s\n", i.SDKVersion)
+	}
 }
@@ -11,7 +11,6 @@ import (
 
 	"github.com/adnsv/go-build/compiler/toolchain"
 	"github.com/adnsv/go-utils/filesystem"
-	"github.com/blang/semver/v4"
 )
 
 // Implementation-specific filename patterns
@@ -42,6 +41,8 @@ func DiscoverInstallations(feedback func(string)) []*Installation {
 		}
 	}
 
+	search_paths = append(search_paths, WASISDKSearchPaths()...)
+
 	// Collect all potential compiler executables
 	files := filesystem.SearchFilesAndSymlinks(search_paths,
 		func(fi os.FileInfo) bool {
@@ -123,16 +124,7 @@ func DiscoverInstallations(feedback func(string)) []*Installation {
 
 	// Sort by version, latest first
 	sort.SliceStable(ret, func(i, j int) bool {
-		v1, e1 := semver.ParseTolerant(ret[i].Ver.Version)
-		v2, e2 := semver.ParseTolerant(ret[j].Ver.Version)
-		if e1 == nil && e2 == nil {
-			return v1.GT(v2)
-		} else if e1 != nil {
-			return true
-		} else if e2 != nil {
-			return false
-		}
-		return ret[i].Ver.Version > ret[j].Ver.Version
+		return toolchain.CompareVersionStrings(ret[i].Ver.Version, ret[j].Ver.Version) > 0
 	})
 
 	if feedback != nil {
@@ -0,0 +1,137 @@
+package clang
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+)
+
+// VersionMapping associates a vendor compiler version with the upstream LLVM
+// release it is based on
+type VersionMapping struct {
+	Vendor string // first vendor version based on the LLVM release
+	LLVM   string
+}
+
+// AppleLLVMVersions maps Apple clang versions (as reported by
+// `clang --version`) to upstream LLVM releases, see the Xcode release notes.
+var AppleLLVMVersions = []VersionMapping{
+	{"10.0.0", "6.0.1"},  // Xcode 10.0
+	{"10.0.1", "7.0.0"},  // Xcode 10.2
+	{"11.0.0", "8.0.0"},  // Xcode 11.0
+	{"11.0.3", "9.0.0"},  // Xcode 11.4
+	{"12.0.0", "10.0.0"}, // Xcode 12.0
+	{"12.0.5", "11.1.0"}, // Xcode 12.5
+	{"13.0.0", "12.0.0"}, // Xcode 13.0
+	{"13.1.6", "13.0.0"}, // Xcode 13.3
+	{"14.0.0", "14.0.0"}, // Xcode 14.0
+	{"14.0.3", "15.0.0"}, // Xcode 14.3
+	{"15.0.0", "16.0.0"}, // Xcode 15.0
+	{"16.0.0", "17.0.6"}, // Xcode 16.0
+	{"17.0.0", "19.1.4"}, // Xcode 16.3
+}
+
+// IntelLLVMVersions maps Intel oneAPI DPC++/C++ (icx) versions to upstream
+// LLVM releases. It is used only when the compiler does not report
+// __clang_major__ and friends.
+var IntelLLVMVersions = []VersionMapping{
+	{"2022.0", "14.0.0"},
+	{"2022.1", "15.0.0"},
+	{"2023.0", "16.0.0"},
+	{"2023.2", "17.0.0"},
+	{"2024.0", "18.0.0"},
+	{"2024.2", "19.0.0"},
+	{"2025.0", "20.0.0"},
+}
+
+// MapLLVMVersion looks up the LLVM release the vendor version is based on.
+// The latest mapping that does not exceed the vendor version is used.
+func MapLLVMVersion(mappings []VersionMapping, vendor string) (string, bool) {
+	v, err := toolchain.ParseVersion(vendor)
+	if err != nil {
+		return "", false
+	}
+	ret := ""
+	for _, m := range mappings {
+		if v.Compare(toolchain.MustParseVersion(m.Vendor)) >= 0 {
+			ret = m.LLVM
+		}
+	}
+	return ret, ret != ""
+}
+
+// LLVMVersion determines the upstream LLVM version for the compiler variant.
+// Apple clang is mapped by the table, other implementations use the
+// __clang_major__/__clang_minor__/__clang_patchlevel__ values reported by
+// the compiler (macros), falling back to the tables where available.
+func LLVMVersion(impl Implementation, version string, macros map[string]string) string {
+	switch impl {
+	case AppleClang:
+		s, _ := MapLLVMVersion(AppleLLVMVersions, version)
+		return s
+	case Clang, ZigClang:
+		return version
+	}
+	if s := clangMacroVersion(macros); s != "" {
+		return s
+	}
+	if impl == IntelClang {
+		s, _ := MapLLVMVersion(IntelLLVMVersions, version)
+		return s
+	}
+	return ""
+}
+
+// cxxStandards lists the most recent -std=c++XX value accepted by upstream
+// clang releases
+var cxxStandards = []VersionMapping{
+	{"3.5", "c++1z"},
+	{"5.0", "c++17"},
+	{"6.0", "c++2a"},
+	{"10.0", "c++20"},
+	{"12.0", "c++2b"},
+	{"17.0", "c++2c"},
+}
+
+// CXXStandard returns the most recent C++ standard accepted by the specified
+// LLVM version
+func CXXStandard(llvm string) string {
+	s, _ := MapLLVMVersion(cxxStandards, llvm)
+	return s
+}
+
+func clangMacroVersion(macros map[string]string) string {
+	major := macros["__clang_major__"]
+	if major == "" {
+		return ""
+	}
+	minor := macros["__clang_minor__"]
+	if minor == "" {
+		minor = "0"
+	}
+	patch := macros["__clang_patchlevel__"]
+	if patch == "" {
+		patch = "0"
+	}
+	return major + "." + minor + "." + patch
+}
+
+// queryMacros returns predefined preprocessor macros
+func queryMacros(tool toolchain.ToolPath) map[string]string {
+	args := append(tool.Commands(), "-dM", "-E", "-x", "c", "-")
+	cmd := exec.Command(tool.Path(), args...)
+	cmd.Stdin = strings.NewReader("")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	ret := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		ff := strings.Fields(line)
+		if len(ff) >= 3 && ff[0] == "#define" {
+			ret[ff[1]] = ff[2]
+		}
+	}
+	return ret
+}
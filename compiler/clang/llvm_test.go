@@ -0,0 +1,48 @@
+package clang
+
+import (
+	"testing"
+)
+
+func TestLLVMVersion(t *testing.T) {
+	tests := []struct {
+		impl     Implementation
+		version  string
+		macros   map[string]string
+		expected string
+	}{
+		{Clang, "17.0.6", nil, "17.0.6"},
+		{ZigClang, "18.1.6", nil, "18.1.6"},
+		{AppleClang, "15.0.0", nil, "16.0.0"},
+		{AppleClang, "14.0.3", nil, "15.0.0"},
+		{AppleClang, "13.1.6", nil, "13.0.0"},
+		{AppleClang, "13.0.1", nil, "12.0.0"},
+		{AppleClang, "9.1.0", nil, ""},
+		{IntelClang, "2024.0.0", map[string]string{"__clang_major__": "18", "__clang_minor__": "0", "__clang_patchlevel__": "0"}, "18.0.0"},
+		{IntelClang, "2023.2.1", nil, "17.0.0"},
+		{ARMClang, "6.21", map[string]string{"__clang_major__": "18", "__clang_minor__": "1"}, "18.1.0"},
+		{TIClang, "3.2.0", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.impl)+" "+tt.version, func(t *testing.T) {
+			if got := LLVMVersion(tt.impl, tt.version, tt.macros); got != tt.expected {
+				t.Errorf("LLVMVersion(%s, %q) = %q, want %q", tt.impl, tt.version, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCXXStandard(t *testing.T) {
+	tests := map[string]string{
+		"3.4.2":  "",
+		"5.0.0":  "c++17",
+		"10.0.1": "c++20",
+		"16.0.0": "c++2b",
+		"17.0.6": "c++2c",
+	}
+	for llvm, want := range tests {
+		if got := CXXStandard(llvm); got != want {
+			t.Errorf("CXXStandard(%q) = %q, want %q", llvm, got, want)
+		}
+	}
+}
@@ -23,6 +23,7 @@ const (
 	TIClang    Implementation = "ti-clang"
 	ARMClang   Implementation = "arm-clang"
 	ZigClang   Implementation = "zig-clang"
+	WASISDK    Implementation = "wasi-sdk"
 )
 
 // Ver contains version information extracted from compiler output
@@ -35,6 +36,10 @@ type Ver struct {
 	InstalledDir   string         `json:"installed-dir" yaml:"installed-dir"`
 	CCIncludeDirs  []string       `json:"cc-include-dirs" yaml:"cc-include-dirs"`
 	CXXIncludeDirs []string       `json:"cxx-include-dirs" yaml:"cxx-include-dirs"`
+	LLVMVersion    string         `json:"llvm-version,omitempty" yaml:"llvm-version,omitempty"` // upstream LLVM version the variant is based on
+	CXXStandard    string         `json:"cxx-standard,omitempty" yaml:"cxx-standard,omitempty"` // most recent -std=c++XX value
+	Sysroot        string         `json:"sysroot,omitempty" yaml:"sysroot,omitempty"`           // bundled sysroot, wasi-sdk only
+	SDKVersion     string         `json:"sdk-version,omitempty" yaml:"sdk-version,omitempty"`   // wasi-sdk release, wasi-sdk only
 }
 
 // Version detection regexes for different implementations
@@ -86,7 +91,7 @@ func QueryVersionWithRegex(tool toolchain.ToolPath, impl Implementation, version
 
 	// Special handling for emscripten
 	if impl == EmScripten {
-		ret.Target, _ = triplet.ParseFull("wasm32-emscripten")
+		ret.Target, _ = triplet.ParseFull("wasm32-unknown-emscripten")
 	} else {
 		for _, line := range lines {
 			n := len(line)
@@ -124,6 +129,13 @@ func QueryVersionWithRegex(tool toolchain.ToolPath, impl Implementation, version
 			ret.InstalledDir = filepath.ToSlash(strings.TrimSpace(match[1]))
 		}
 	}
+	var macros map[string]string
+	if impl != Clang && impl != ZigClang && impl != AppleClang {
+		macros = queryMacros(tool)
+	}
+	ret.LLVMVersion = LLVMVersion(impl, ret.Version, macros)
+	ret.CXXStandard = CXXStandard(ret.LLVMVersion)
+
 	if includes, err := gcc.GetSystemIncludes(string(tool), "c"); err == nil {
 		ret.CCIncludeDirs = append(ret.CCIncludeDirs, includes...)
 	}
@@ -143,23 +155,41 @@ func QueryVersion(tool toolchain.ToolPath) (*Ver, error) {
 	}
 	output := strings.TrimSpace(strings.Split(string(buf), "\n")[0])
 
-	// Try each implementation in order
+	impl, re := detectImplementation(tool, output)
+	if re == nil {
+		return nil, errors.New("unknown clang implementation")
+	}
+	ver, err := QueryVersionWithRegex(tool, impl, re)
+	if err == nil && impl == Clang {
+		queryWASISDK(ver, tool.Path())
+	}
+	return ver, err
+}
+
+// detectImplementation matches the first line of clang -v output against the
+// known implementations in order, zig is recognized by its executable name
+// as it reports a plain clang version
+func detectImplementation(tool toolchain.ToolPath, output string) (Implementation, *regexp.Regexp) {
 	switch {
+	case isZig(tool) && reZigVersion.MatchString(output):
+		return ZigClang, reZigVersion
 	case reEmscriptenVersion.MatchString(output):
-		return QueryVersionWithRegex(tool, EmScripten, reEmscriptenVersion)
+		return EmScripten, reEmscriptenVersion
 	case reAppleVersion.MatchString(output):
-		return QueryVersionWithRegex(tool, AppleClang, reAppleVersion)
+		return AppleClang, reAppleVersion
 	case reIntelVersion.MatchString(output):
-		return QueryVersionWithRegex(tool, IntelClang, reIntelVersion)
+		return IntelClang, reIntelVersion
 	case reTIVersion.MatchString(output):
-		return QueryVersionWithRegex(tool, TIClang, reTIVersion)
+		return TIClang, reTIVersion
 	case reARMVersion.MatchString(output):
-		return QueryVersionWithRegex(tool, ARMClang, reARMVersion)
-	case reZigVersion.MatchString(output):
-		return QueryVersionWithRegex(tool, ZigClang, reZigVersion)
+		return ARMClang, reARMVersion
 	case reClangVersion.MatchString(output):
-		return QueryVersionWithRegex(tool, Clang, reClangVersion)
-	default:
-		return nil, errors.New("unknown clang implementation")
+		return Clang, reClangVersion
 	}
+	return "", nil
+}
+
+func isZig(tool toolchain.ToolPath) bool {
+	base := strings.ToLower(filepath.Base(tool.Path()))
+	return strings.TrimSuffix(base, ".exe") == "zig"
 }
@@ -0,0 +1,37 @@
+package clang
+
+import (
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+)
+
+func TestDetectImplementation(t *testing.T) {
+	tests := []struct {
+		tool   toolchain.ToolPath
+		output string
+		want   Implementation
+	}{
+		{"/usr/bin/clang", "clang version 18.1.8", Clang},
+		{"/usr/bin/clang-17", "Ubuntu clang version 17.0.6 (++20231208085846+6009708b4367-1~exp1~20231208085949.74)", Clang},
+		{"/opt/homebrew/opt/llvm/bin/clang", "Homebrew clang version 18.1.8", Clang},
+		{"/usr/local/bin/clang-zig", "clang version 18.1.8", Clang},
+		{"/opt/zig/zig|cc", "clang version 18.1.6 (https://github.com/ziglang/zig-bootstrap 98bc6bf4fc4009888d33941daf6b600d20a42a56)", ZigClang},
+		{"C:/zig/zig.exe|c++", "clang version 18.1.6", ZigClang},
+		{"/usr/bin/clang", "Apple clang version 15.0.0 (clang-1500.3.9.4)", AppleClang},
+		{"/emsdk/upstream/emscripten/emcc", "emcc (Emscripten gcc/clang-like replacement + linker emulating GNU ld) 3.1.50 (047b82506d6b471873300a5e4d1e690420b582d0)", EmScripten},
+		{"/opt/intel/oneapi/compiler/latest/bin/icx", "Intel(R) oneAPI DPC++/C++ Compiler 2024.0.0 (2024.0.0.20231017)", IntelClang},
+		{"/usr/bin/gcc", "gcc version 12.2.0 (Debian 12.2.0-14)", ""},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.tool), func(t *testing.T) {
+			impl, re := detectImplementation(tt.tool, tt.output)
+			if impl != tt.want {
+				t.Errorf("detectImplementation(%q) = %q, want %q", tt.output, impl, tt.want)
+			}
+			if (re == nil) != (tt.want == "") {
+				t.Errorf("detectImplementation(%q) regex = %v", tt.output, re)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package clang
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/adnsv/go-utils/filesystem"
+)
+
+// wasi-sdk bundles an upstream clang configured for wasm32-wasi with a WASI
+// sysroot (wasi-libc, libc++):
+//
+//	<root>/bin/clang
+//	<root>/share/wasi-sysroot/lib/wasm32-wasip1
+//	<root>/share/wasi-sysroot/lib/wasm32-wasip2
+//	<root>/share/wasi-sysroot/lib/wasm32-wasip1-threads
+//	<root>/VERSION
+
+// WASISDKSearchPaths returns the bin directories of the wasi-sdk
+// installations at the usual locations: $WASI_SDK_PATH, /opt/wasi-sdk and
+// versioned /opt/wasi-sdk-* directories
+func WASISDKSearchPaths() []string {
+	roots := []string{}
+	for _, env := range []string{"WASI_SDK_PATH", "WASI_SDK"} {
+		if v := os.Getenv(env); v != "" {
+			roots = append(roots, v)
+		}
+	}
+	if runtime.GOOS == "windows" {
+		for _, env := range []string{"ProgramFiles", "LOCALAPPDATA"} {
+			if v := os.Getenv(env); v != "" {
+				roots = append(roots, filepath.Join(v, "wasi-sdk"))
+			}
+		}
+	} else {
+		roots = append(roots, "/opt/wasi-sdk")
+		if m, err := filepath.Glob("/opt/wasi-sdk-*"); err == nil {
+			sort.Sort(sort.Reverse(sort.StringSlice(m)))
+			roots = append(roots, m...)
+		}
+	}
+	ret := []string{}
+	for _, r := range roots {
+		if bin := filepath.Join(r, "bin"); filesystem.DirExists(bin) {
+			ret = append(ret, bin)
+		}
+	}
+	return ret
+}
+
+// WASISDKRoot returns the wasi-sdk installation directory that contains the
+// clang executable, or an empty string when clang does not come from a
+// wasi-sdk
+func WASISDKRoot(clangPath string) string {
+	root := filepath.Dir(filepath.Dir(clangPath))
+	if !filesystem.DirExists(filepath.Join(root, "share", "wasi-sysroot")) {
+		return ""
+	}
+	return root
+}
+
+// WASISDKVersion reads the wasi-sdk release from the VERSION file in the
+// installation root: 25.0
+func WASISDKVersion(root string) string {
+	buf, err := os.ReadFile(filepath.Join(root, "VERSION"))
+	if err != nil {
+		return ""
+	}
+	v, _, _ := strings.Cut(string(buf), "\n")
+	return strings.TrimSpace(v)
+}
+
+// WASISDKTargets lists the targets that the wasi-sdk sysroot provides
+// libraries for, in the order of their names
+func WASISDKTargets(sysroot string) []triplet.Full {
+	entries, err := os.ReadDir(filepath.Join(sysroot, "lib"))
+	if err != nil {
+		return nil
+	}
+	ret := []triplet.Full{}
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "wasm") {
+			continue
+		}
+		if t, err := triplet.ParseFull(e.Name()); err == nil && t.IsWASI() {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+// queryWASISDK fills the wasi-sdk specifics for clang executables that come
+// with a WASI sysroot
+func queryWASISDK(ver *Ver, clangPath string) {
+	root := WASISDKRoot(clangPath)
+	if root == "" {
+		return
+	}
+	ver.Implementation = WASISDK
+	ver.Sysroot = filepath.ToSlash(filepath.Join(root, "share", "wasi-sysroot"))
+	ver.SDKVersion = WASISDKVersion(root)
+	if !ver.Target.IsWASI() {
+		ver.Target, _ = triplet.ParseFull("wasm32-wasip1")
+	}
+}
@@ -0,0 +1,55 @@
+package clang
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWASISDK(t *testing.T) {
+	root := t.TempDir()
+	sysroot := filepath.Join(root, "share", "wasi-sysroot")
+	for _, d := range []string{"bin", "share/wasi-sysroot/include",
+		"share/wasi-sysroot/lib/wasm32-wasip1", "share/wasi-sysroot/lib/wasm32-wasip2",
+		"share/wasi-sysroot/lib/wasm32-wasip1-threads"} {
+		if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "VERSION"), []byte("25.0\nwasi-libc: 574b88da\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	clang := filepath.Join(root, "bin", "clang")
+
+	if got := WASISDKRoot(clang); got != root {
+		t.Errorf("WASISDKRoot() = %q, want %q", got, root)
+	}
+	if got := WASISDKRoot(filepath.Join(t.TempDir(), "bin", "clang")); got != "" {
+		t.Errorf("WASISDKRoot() = %q for a plain clang", got)
+	}
+	if got := WASISDKVersion(root); got != "25.0" {
+		t.Errorf("WASISDKVersion() = %q", got)
+	}
+
+	targets := WASISDKTargets(sysroot)
+	got := []string{}
+	for _, tt := range targets {
+		got = append(got, tt.Text())
+	}
+	want := []string{"wasm32-wasi.0.1-wasm-unknown", "wasm32-wasi.0.1-wasm-unknown-threads", "wasm32-wasi.0.2-wasm-unknown"}
+	if len(got) != len(want) {
+		t.Fatalf("WASISDKTargets() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("WASISDKTargets()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	ver := &Ver{Implementation: Clang, Version: "19.1.5"}
+	queryWASISDK(ver, clang)
+	if ver.Implementation != WASISDK || ver.Version != "19.1.5" || ver.SDKVersion != "25.0" ||
+		ver.Sysroot != filepath.ToSlash(sysroot) || ver.Target.WASIPreview() != 1 {
+		t.Errorf("queryWASISDK() = %#v", ver)
+	}
+}
@@ -1,15 +1,21 @@
 package discover
 
 import (
+	"errors"
+	"fmt"
 	"io"
-	"runtime"
+	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/adnsv/go-build/compiler/clang"
 	"github.com/adnsv/go-build/compiler/gcc"
 	"github.com/adnsv/go-build/compiler/msvc"
+	"github.com/adnsv/go-build/compiler/registry"
 	"github.com/adnsv/go-build/compiler/toolchain"
 	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/adnsv/go-build/env"
+	"github.com/adnsv/go-build/host"
 	"golang.org/x/exp/slices"
 )
 
@@ -40,7 +46,8 @@ func Installations(types []string, feedback func(string)) []Installation {
 	return ret
 }
 
-// Toolchains returns all available toolchains
+// Toolchains returns all available toolchains, merged with the toolchains
+// and overrides from the user and project registries
 func Toolchains(types []string, feedback func(string)) []*toolchain.Chain {
 	ret := []*toolchain.Chain{}
 	if fltShow("msvc", types) {
@@ -52,7 +59,138 @@ func Toolchains(types []string, feedback func(string)) []*toolchain.Chain {
 	if fltShow("clang", types) || fltShow("llvm", types) {
 		ret = append(ret, clang.DiscoverToolchains(feedback)...)
 	}
-	return ret
+
+	reg, err := registry.Load()
+	if err != nil {
+		if feedback != nil {
+			feedback(fmt.Sprintf("failed to load toolchain registry: %s", err))
+		}
+		return ret
+	}
+	for _, tc := range reg.Toolchains {
+		// only chains of other families are skipped, the family of a chain
+		// without a compiler is only known after probing it
+		if tc.Compiler != "" && !fltCompiler(tc.Compiler, types) {
+			continue
+		}
+		if err := Complete(tc, feedback); err != nil && feedback != nil {
+			feedback(fmt.Sprintf("registered toolchain '%s': %s", tc.Name, err))
+		}
+	}
+	tt := []*toolchain.Chain{}
+	for _, tc := range reg.Apply(ret) {
+		if fltCompiler(tc.Compiler, types) {
+			tt = append(tt, tc)
+		}
+	}
+	return tt
+}
+
+// Describe probes a C compiler executable and creates a toolchain for it.
+// The compiler is one of gcc|clang, an empty string tries both.
+func Describe(compiler string, cc string, feedback func(string)) (*toolchain.Chain, error) {
+	path, err := exec.LookPath(cc)
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.ToSlash(path)
+
+	if compiler == "" || compiler == "clang" || compiler == "llvm" {
+		tool := toolchain.ToolPath(path)
+		if strings.TrimSuffix(filepath.Base(path), ".exe") == "zig" {
+			tool = toolchain.NewToolPath(path, "cc")
+		}
+		if ver, err := clang.QueryVersion(tool); err == nil {
+			inst := &clang.Installation{Ver: *ver}
+			inst.CCompiler.PrimaryPath = path
+			return clang.NewToolchain(inst, feedback), nil
+		} else if compiler != "" {
+			return nil, err
+		}
+	}
+	if compiler == "" || compiler == "gcc" || compiler == "gnu" {
+		ver, err := gcc.QueryVersion(path)
+		if err != nil {
+			return nil, err
+		}
+		inst := &gcc.Installation{Ver: *ver}
+		inst.CCompiler.PrimaryPath = path
+		return gcc.NewToolchain(inst, feedback), nil
+	}
+	return nil, fmt.Errorf("unsupported compiler type '%s'", compiler)
+}
+
+// Complete fills in the fields of a registered toolchain that were left
+// empty by probing its C compiler. Fields specified in the registry are kept
+// intact.
+func Complete(tc *toolchain.Chain, feedback func(string)) error {
+	if tc.Compiler == "msvc" || tc.Target.Original != "" {
+		return nil
+	}
+	cc, cxx := tc.GetCompilerPaths()
+	if cc == "" {
+		cc = cxx
+	}
+	if cc == "" {
+		return errors.New("missing C/C++ compiler path")
+	}
+	probed, err := Describe(tc.Compiler, cc, feedback)
+	if err != nil {
+		return err
+	}
+	complete(tc, probed)
+	return nil
+}
+
+func fill(dst *string, src string) {
+	if *dst == "" {
+		*dst = src
+	}
+}
+
+// complete fills in the empty fields of tc from the probed toolchain
+func complete(tc *toolchain.Chain, probed *toolchain.Chain) {
+	fill(&tc.Compiler, probed.Compiler)
+	fill(&tc.Implementation, probed.Implementation)
+	fill(&tc.FullVersion, probed.FullVersion)
+	fill(&tc.Version, probed.Version)
+	fill(&tc.LLVMVersion, probed.LLVMVersion)
+	fill(&tc.ThreadModel, probed.ThreadModel)
+	fill(&tc.InstalledDir, probed.InstalledDir)
+	if tc.Target.Target == (triplet.Target{}) {
+		tc.Target = probed.Target
+	} else {
+		// the registry specifies the target in part, keep what it says
+		t, p := &tc.Target.Target, probed.Target.Target
+		fill(&t.OS, p.OS)
+		fill(&t.OSVersion, p.OSVersion)
+		fill(&t.Arch, p.Arch)
+		fill(&t.SubArch, p.SubArch)
+		fill(&t.ObjectFormat, p.ObjectFormat)
+		fill(&t.Environment, p.Environment)
+		fill(&t.LibC, p.LibC)
+		fill(&t.LibCVersion, p.LibCVersion)
+		fill(&t.FloatABI, p.FloatABI)
+		fill(&t.Endian, p.Endian)
+		if t.PointerWidth == 0 {
+			t.PointerWidth = p.PointerWidth
+		}
+	}
+	if tc.CCIncludeDirs == nil {
+		tc.CCIncludeDirs = probed.CCIncludeDirs
+	}
+	if tc.CXXIncludeDirs == nil {
+		tc.CXXIncludeDirs = probed.CXXIncludeDirs
+	}
+	if tc.Tools == nil {
+		tc.Tools = toolchain.Toolset{}
+	}
+	for k, v := range probed.Tools {
+		if !tc.Tools.Contains(k) {
+			tc.Tools[k] = v
+		}
+	}
+	tc.Environment = env.Join(env.Merge(env.Split(probed.Environment), env.Split(tc.Environment)))
 }
 
 func Find(target triplet.Target, tt []*toolchain.Chain) []*toolchain.Chain {
@@ -65,19 +203,32 @@ func Find(target triplet.Target, tt []*toolchain.Chain) []*toolchain.Chain {
 	return ret
 }
 
+// Natives returns the toolchains that produce binaries for the host
+// platform, including a matching C library where it can be determined
 func Natives(tt []*toolchain.Chain) []*toolchain.Chain {
-	target := triplet.Target{
-		OS:   triplet.NormalizeOS(runtime.GOOS),
-		Arch: triplet.NormalizeArch(runtime.GOARCH),
+	h := host.Current()
+	ret := []*toolchain.Chain{}
+	for _, t := range tt {
+		if h.CanRun(t.Target.Target) {
+			ret = append(ret, t)
+		}
 	}
-	return Find(target, tt)
+	return ret
 }
 
 func ChooseNative(tt []*toolchain.Chain, order_of_preference ...string) *toolchain.Chain {
-	tt = Natives(tt)
+	return choose(Natives(tt), order_of_preference)
+}
+
+var defaultPreference = []string{"gcc", "clang", "msvc"}
+
+// choose picks the newest toolchain of the first compiler family in the
+// order of preference. When none of the preferred families is available,
+// the families are ranked by the default preference, then by name.
+func choose(tt []*toolchain.Chain, order_of_preference []string) *toolchain.Chain {
 	if len(tt) <= 0 {
 		return nil
-	} else if len(tt) == 0 {
+	} else if len(tt) == 1 {
 		return tt[0]
 	}
 
@@ -94,16 +245,12 @@ func ChooseNative(tt []*toolchain.Chain, order_of_preference ...string) *toolcha
 			return sel[0]
 		}
 
-		if compiler == "msvc" {
-			slices.SortFunc(sel, msvc.Compare)
-		} else {
-			slices.SortFunc(sel, gcc.Compare)
-		}
+		slices.SortFunc(sel, toolchain.Compare)
 		return sel[len(sel)-1]
 	}
 
 	if order_of_preference == nil {
-		order_of_preference = []string{"gcc", "clang", "msvc"}
+		order_of_preference = defaultPreference
 	}
 	for _, c := range order_of_preference {
 		r := handle_compiler(c)
@@ -111,17 +258,38 @@ func ChooseNative(tt []*toolchain.Chain, order_of_preference ...string) *toolcha
 			return r
 		}
 	}
-	sel := slices.Clone(tt)
-	slices.SortFunc(sel, func(c1, c2 *toolchain.Chain) int {
-		if i := strings.Compare(c1.Compiler, c2.Compiler); i != 0 {
+
+	// versions are not comparable across families
+	families := []string{}
+	for _, t := range tt {
+		if !slices.Contains(families, t.Compiler) {
+			families = append(families, t.Compiler)
+		}
+	}
+	rank := func(compiler string) int {
+		if i := slices.Index(defaultPreference, compiler); i >= 0 {
 			return i
 		}
-		if i := strings.Compare(c1.Target.Original, c2.Target.Original); i != 0 {
+		return len(defaultPreference)
+	}
+	slices.SortFunc(families, func(c1, c2 string) int {
+		if i := rank(c1) - rank(c2); i != 0 {
 			return i
 		}
-		return strings.Compare(c1.FullVersion, c2.FullVersion)
+		return strings.Compare(c1, c2)
 	})
-	return sel[0]
+	return handle_compiler(families[0])
+}
+
+func fltCompiler(compiler string, types []string) bool {
+	switch compiler {
+	case "gcc":
+		return fltShow("gcc", types) || fltShow("gnu", types)
+	case "clang":
+		return fltShow("clang", types) || fltShow("llvm", types)
+	default:
+		return fltShow(compiler, types)
+	}
 }
 
 func fltShow(t string, tt []string) bool {
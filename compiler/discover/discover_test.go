@@ -0,0 +1,79 @@
+package discover
+
+import (
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+func TestComplete(t *testing.T) {
+	probedTarget, err := triplet.ParseFull("x86_64-linux-gnu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe := func() *toolchain.Chain {
+		return &toolchain.Chain{
+			Compiler: "gcc",
+			Version:  "12.2.0",
+			Target:   probedTarget,
+			Tools:    toolchain.Toolset{toolchain.CCompiler: "/usr/bin/gcc", toolchain.Archiver: "/usr/bin/gcc-ar"},
+		}
+	}
+
+	// no target in the registry: the probed one is used as is
+	tc := &toolchain.Chain{Name: "native", Tools: toolchain.Toolset{toolchain.CCompiler: "/usr/bin/gcc"}}
+	complete(tc, probe())
+	if tc.Target.Original != "x86_64-linux-gnu" || tc.Target.Target != probedTarget.Target {
+		t.Errorf("Target = %+v", tc.Target)
+	}
+	if tc.Compiler != "gcc" || tc.Version != "12.2.0" || !tc.Tools.Contains(toolchain.Archiver) {
+		t.Errorf("fields were not filled in: %+v", tc)
+	}
+
+	// a partial target in the registry keeps its fields
+	tc = &toolchain.Chain{Name: "musl", Version: "12", Target: triplet.Full{Target: triplet.Target{LibC: "musl", Environment: "musl"}}}
+	complete(tc, probe())
+	if tc.Target.LibC != "musl" || tc.Target.Environment != "musl" {
+		t.Errorf("registry target fields were replaced: %+v", tc.Target)
+	}
+	if tc.Target.Arch != probedTarget.Arch || tc.Target.OS != "linux" || tc.Target.PointerWidth != probedTarget.PointerWidth {
+		t.Errorf("empty target fields were not filled in: %+v", tc.Target)
+	}
+	if tc.Version != "12" {
+		t.Errorf("Version = %s, want the registered one", tc.Version)
+	}
+}
+
+func TestChoose(t *testing.T) {
+	chain := func(compiler, version string) *toolchain.Chain {
+		return &toolchain.Chain{Name: compiler + "-" + version, Compiler: compiler, Version: version}
+	}
+	tests := []struct {
+		name       string
+		tt         []*toolchain.Chain
+		preference []string
+		want       string
+	}{
+		{"none", nil, nil, ""},
+		{"single", []*toolchain.Chain{chain("zig", "0.11.0")}, []string{"gcc"}, "zig-0.11.0"},
+		{"default preference", []*toolchain.Chain{chain("clang", "18.1.0"), chain("gcc", "12.2.0"), chain("gcc", "13.2.0")}, nil, "gcc-13.2.0"},
+		{"explicit preference", []*toolchain.Chain{chain("clang", "17.0.6"), chain("gcc", "13.2.0"), chain("clang", "18.1.0")}, []string{"clang", "gcc"}, "clang-18.1.0"},
+		{"preferred family missing", []*toolchain.Chain{chain("gcc", "9.4.0"), chain("clang", "18.1.0")}, []string{"msvc", "clang"}, "clang-18.1.0"},
+		// the newer clang does not win over gcc from another family
+		{"fallback by family", []*toolchain.Chain{chain("clang", "18.1.0"), chain("gcc", "9.4.0"), chain("gcc", "12.2.0")}, []string{"msvc"}, "gcc-12.2.0"},
+		{"fallback to unknown families by name", []*toolchain.Chain{chain("zig", "0.13.0"), chain("tcc", "0.9.27"), chain("tcc", "0.9.26")}, nil, "tcc-0.9.27"},
+		{"known families before unknown ones", []*toolchain.Chain{chain("aocc", "4.2.0"), chain("msvc", "19.38")}, []string{"gcc"}, "msvc-19.38"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if tc := choose(tt.tt, tt.preference); tc != nil {
+				got = tc.Name
+			}
+			if got != tt.want {
+				t.Errorf("choose() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
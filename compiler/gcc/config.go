@@ -0,0 +1,85 @@
+package gcc
+
+import (
+	"strings"
+)
+
+// Capabilities are derived from the options gcc was configured with
+type Capabilities struct {
+	Vendor         string `json:"vendor,omitempty" yaml:"vendor,omitempty"`               // --with-pkgversion
+	DefaultPIE     bool   `json:"default-pie" yaml:"default-pie"`                         // --enable-default-pie
+	DefaultSSP     bool   `json:"default-ssp" yaml:"default-ssp"`                         // --enable-default-ssp
+	Multilib       bool   `json:"multilib" yaml:"multilib"`                               // --enable-multilib (default)
+	LTO            bool   `json:"lto" yaml:"lto"`                                         // --enable-lto (default)
+	Plugin         bool   `json:"plugin" yaml:"plugin"`                                   // --enable-plugin (default)
+	ThreadModel    string `json:"thread-model,omitempty" yaml:"thread-model,omitempty"`   // --enable-threads
+	Sysroot        string `json:"sysroot,omitempty" yaml:"sysroot,omitempty"`             // --with-sysroot
+	Arch           string `json:"arch,omitempty" yaml:"arch,omitempty"`                   // --with-arch
+	Tune           string `json:"tune,omitempty" yaml:"tune,omitempty"`                   // --with-tune
+	CPU            string `json:"cpu,omitempty" yaml:"cpu,omitempty"`                     // --with-cpu
+	FPU            string `json:"fpu,omitempty" yaml:"fpu,omitempty"`                     // --with-fpu
+	Float          string `json:"float,omitempty" yaml:"float,omitempty"`                 // --with-float
+	ABI            string `json:"abi,omitempty" yaml:"abi,omitempty"`                     // --with-abi
+	LibstdcxxDebug bool   `json:"libstdcxx-debug" yaml:"libstdcxx-debug"`                 // --enable-libstdcxx-debug
+	LibstdcxxABI   string `json:"libstdcxx-abi,omitempty" yaml:"libstdcxx-abi,omitempty"` // --with-default-libstdcxx-abi: new|gcc4-compatible
+}
+
+// ParseCapabilities derives capabilities from configure options as returned
+// by parseConfig, options that are not specified assume the gcc defaults
+func ParseCapabilities(config map[string]string) Capabilities {
+	c := Capabilities{
+		Vendor:         config["with-pkgversion"],
+		DefaultPIE:     configFlag(config, "default-pie", false),
+		DefaultSSP:     configFlag(config, "default-ssp", false),
+		Multilib:       configFlag(config, "multilib", true),
+		LTO:            configFlag(config, "lto", true),
+		Plugin:         configFlag(config, "plugin", true),
+		ThreadModel:    config["enable-threads"],
+		Sysroot:        config["with-sysroot"],
+		Arch:           config["with-arch"],
+		Tune:           config["with-tune"],
+		CPU:            config["with-cpu"],
+		FPU:            config["with-fpu"],
+		Float:          config["with-float"],
+		ABI:            config["with-abi"],
+		LibstdcxxDebug: configFlag(config, "libstdcxx-debug", false),
+		LibstdcxxABI:   config["with-default-libstdcxx-abi"],
+	}
+	switch c.ThreadModel {
+	case "", "yes":
+		if _, ok := config["enable-threads"]; ok {
+			c.ThreadModel = "posix"
+		} else if _, ok := config["disable-threads"]; ok {
+			c.ThreadModel = "single"
+		}
+	case "no":
+		c.ThreadModel = "single"
+	}
+	switch {
+	case !configFlag(config, "libstdcxx-dual-abi", true):
+		// only the old ABI is built, --with-default-libstdcxx-abi is ignored
+		c.LibstdcxxABI = "gcc4-compatible"
+	case c.LibstdcxxABI == "" || c.LibstdcxxABI == "cxx11":
+		c.LibstdcxxABI = "new"
+	}
+	return c
+}
+
+// configFlag interprets --enable-<name>[=value] and --disable-<name> options
+func configFlag(config map[string]string, name string, def bool) bool {
+	if v, ok := config["enable-"+name]; ok {
+		return v != "no"
+	}
+	if _, ok := config["disable-"+name]; ok {
+		return false
+	}
+	return def
+}
+
+// configList splits comma-separated option values
+func configList(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
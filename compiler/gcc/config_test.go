@@ -0,0 +1,104 @@
+package gcc
+
+import (
+	"fmt"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func ExampleParseCapabilities() {
+	const input = `../src/configure -v --with-pkgversion='Debian 12.2.0-14' --with-bugurl=file:///usr/share/doc/gcc-12/README.Bugs --enable-languages=c,ada,c++,go,d,fortran,objc,obj-c++,m2 --prefix=/usr --with-gcc-major-version-only --program-suffix=-12 --enable-shared --enable-linker-build-id --libexecdir=/usr/libexec --without-included-gettext --enable-threads=posix --libdir=/usr/lib --enable-nls --enable-clocale=gnu --enable-libstdcxx-debug --enable-libstdcxx-time=yes --with-default-libstdcxx-abi=new --enable-gnu-unique-object --disable-vtable-verify --enable-plugin --enable-default-pie --with-system-zlib --enable-libphobos-checking=release --with-target-system-zlib=auto --enable-objc-gc=auto --enable-multiarch --disable-werror --enable-cet --with-arch-32=i686 --with-abi=m64 --with-multilib-list=m32,m64,mx32 --enable-multilib --with-tune=generic --enable-offload-targets=nvptx-none=/build/gcc-12-ALHxjy/gcc-12-12.2.0/debian/tmp-nvptx/usr,amdgcn-amdhsa=/build/gcc-12-ALHxjy/gcc-12-12.2.0/debian/tmp-gcn/usr --enable-offload-defaulted --without-cuda-driver --enable-checking=release --build=x86_64-linux-gnu --host=x86_64-linux-gnu --target=x86_64-linux-gnu`
+	cc, err := parseConfig(input)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	buf, _ := yaml.Marshal(ParseCapabilities(cc))
+	fmt.Printf("%s", string(buf))
+
+	// Output:
+	// vendor: Debian 12.2.0-14
+	// default-pie: true
+	// default-ssp: false
+	// multilib: true
+	// lto: true
+	// plugin: true
+	// thread-model: posix
+	// tune: generic
+	// abi: m64
+	// libstdcxx-debug: true
+	// libstdcxx-abi: new
+}
+
+func TestParseCapabilities(t *testing.T) {
+	defaults := Capabilities{Multilib: true, LTO: true, Plugin: true, LibstdcxxABI: "new"}
+	tests := []struct {
+		name   string
+		config string
+		want   func(c *Capabilities)
+	}{
+		{"defaults", "../configure --prefix=/usr", func(c *Capabilities) {}},
+		{"disable flags", "../configure --disable-multilib --disable-lto --disable-plugin --disable-libstdcxx-debug", func(c *Capabilities) {
+			c.Multilib, c.LTO, c.Plugin = false, false, false
+		}},
+		{"enable flags", "../configure --enable-default-pie --enable-default-ssp --enable-libstdcxx-debug", func(c *Capabilities) {
+			c.DefaultPIE, c.DefaultSSP, c.LibstdcxxDebug = true, true, true
+		}},
+		{"enable=no", "../configure --enable-multilib=no --enable-lto=yes", func(c *Capabilities) {
+			c.Multilib = false
+		}},
+		{"threads", "../configure --enable-threads", func(c *Capabilities) {
+			c.ThreadModel = "posix"
+		}},
+		{"threads=yes", "../configure --enable-threads=yes", func(c *Capabilities) {
+			c.ThreadModel = "posix"
+		}},
+		{"threads=win32", "../configure --enable-threads=win32", func(c *Capabilities) {
+			c.ThreadModel = "win32"
+		}},
+		{"threads=no", "../configure --enable-threads=no", func(c *Capabilities) {
+			c.ThreadModel = "single"
+		}},
+		{"disable threads", "../configure --disable-threads", func(c *Capabilities) {
+			c.ThreadModel = "single"
+		}},
+		{"old abi", "../configure --with-default-libstdcxx-abi=gcc4-compatible", func(c *Capabilities) {
+			c.LibstdcxxABI = "gcc4-compatible"
+		}},
+		{"cxx11 abi", "../configure --with-default-libstdcxx-abi=cxx11", func(c *Capabilities) {
+			c.LibstdcxxABI = "new"
+		}},
+		{"no dual abi", "../configure --disable-libstdcxx-dual-abi", func(c *Capabilities) {
+			c.LibstdcxxABI = "gcc4-compatible"
+		}},
+		{"no dual abi ignores the default abi", "../configure --disable-libstdcxx-dual-abi --with-default-libstdcxx-abi=new", func(c *Capabilities) {
+			c.LibstdcxxABI = "gcc4-compatible"
+		}},
+		// arm-linux-gnueabihf as configured by Debian
+		{"arm", "../src/configure --with-arch=armv7-a+fp --with-float=hard --with-mode=thumb --with-fpu=vfpv3-d16 --disable-sjlj-exceptions --enable-threads=posix --target=arm-linux-gnueabihf", func(c *Capabilities) {
+			c.Arch, c.Float, c.FPU, c.ThreadModel = "armv7-a+fp", "hard", "vfpv3-d16", "posix"
+		}},
+		// aarch64-none-elf from the Arm GNU toolchain
+		{"aarch64 bare metal", "/build/src/gcc/configure --target=aarch64-none-elf --with-cpu=cortex-a53 --with-sysroot=/build/install/aarch64-none-elf --disable-shared --disable-nls --disable-threads --disable-tls --enable-checking=release --enable-languages=c,c++,fortran --with-newlib --with-pkgversion='Arm GNU Toolchain 13.2.rel1 (Build arm-13.7)'", func(c *Capabilities) {
+			c.CPU, c.Sysroot, c.ThreadModel, c.Vendor = "cortex-a53", "/build/install/aarch64-none-elf", "single", "Arm GNU Toolchain 13.2.rel1 (Build arm-13.7)"
+		}},
+		{"riscv", "../configure --with-arch=rv64gc --with-abi=lp64d --with-tune=rocket --enable-multilib", func(c *Capabilities) {
+			c.Arch, c.ABI, c.Tune = "rv64gc", "lp64d", "rocket"
+		}},
+		{"multilib arch is not the default arch", "../configure --with-arch-32=i686 --with-arch-64=x86-64 --with-float-abi=soft", func(c *Capabilities) {}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := parseConfig(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := defaults
+			tt.want(&want)
+			if got := ParseCapabilities(config); got != want {
+				t.Errorf("ParseCapabilities() = %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
@@ -21,6 +21,11 @@ type Ver struct {
 	CXXIncludeDirs  []string     `json:"cxx-include-dirs" yaml:"cxx-include-dirs"`
 	Languages       []string     `json:"languages,omitempty" yaml:"languages,omitempty"`
 	ToolchainPrefix string       `json:"toolchain-prefix,omitempty" yaml:"toolchain-prefix,omitempty"`
+	Sysroot         string       `json:"sysroot,omitempty" yaml:"sysroot,omitempty"` // reported by -print-sysroot
+
+	// Config contains the options from the `Configured with:` line
+	Config       map[string]string `json:"config,omitempty" yaml:"config,omitempty"`
+	Capabilities Capabilities      `json:"capabilities" yaml:"capabilities"`
 }
 
 type Installation struct {
@@ -36,9 +41,16 @@ func (i *Installation) PrintSummary(w io.Writer) {
 	fmt.Fprintf(w, "- target: %s\n", i.Target.Original)
 	fmt.Fprintf(w, "  - os: %s\n", i.Target.OS)
 	fmt.Fprintf(w, "  - arch: %s\n", i.Target.Arch)
-	fmt.Fprintf(w, "  - abi: %s\n", i.Target.ABI)
+	fmt.Fprintf(w, "  - object format: %s\n", i.Target.ObjectFormat)
+	if i.Target.Environment != "" {
+		fmt.Fprintf(w, "  - environment: %s\n", i.Target.Environment)
+	}
 	fmt.Fprintf(w, "  - libc: %s\n", i.Target.LibC)
 	fmt.Fprintf(w, "- thread model: %s\n", i.ThreadModel)
+	i.Capabilities.PrintSummary(w)
+	if i.Sysroot != "" {
+		fmt.Fprintf(w, "- sysroot: '%s'\n", i.Sysroot)
+	}
 	fmt.Fprintf(w, "- CC primary path: '%s'\n", i.CCompiler.PrimaryPath)
 	for _, v := range i.CCompiler.OtherPaths {
 		fmt.Fprintf(w, "- CC alternative path: '%s'\n", v)
@@ -47,3 +59,32 @@ func (i *Installation) PrintSummary(w io.Writer) {
 		fmt.Fprintf(w, "- CC symlink path: '%s'\n", v)
 	}
 }
+
+func (c *Capabilities) PrintSummary(w io.Writer) {
+	yn := map[bool]string{true: "yes", false: "no"}
+	if c.Vendor != "" {
+		fmt.Fprintf(w, "- vendor: '%s'\n", c.Vendor)
+	}
+	fmt.Fprintf(w, "- configured defaults:\n")
+	fmt.Fprintf(w, "  - pie: %s\n", yn[c.DefaultPIE])
+	fmt.Fprintf(w, "  - ssp: %s\n", yn[c.DefaultSSP])
+	fmt.Fprintf(w, "  - multilib: %s\n", yn[c.Multilib])
+	fmt.Fprintf(w, "  - lto: %s\n", yn[c.LTO])
+	fmt.Fprintf(w, "  - plugin: %s\n", yn[c.Plugin])
+	for _, it := range []struct{ name, value string }{
+		{"threads", c.ThreadModel},
+		{"sysroot", c.Sysroot},
+		{"arch", c.Arch},
+		{"tune", c.Tune},
+		{"cpu", c.CPU},
+		{"fpu", c.FPU},
+		{"float", c.Float},
+		{"abi", c.ABI},
+	} {
+		if it.value != "" {
+			fmt.Fprintf(w, "  - %s: %s\n", it.name, it.value)
+		}
+	}
+	fmt.Fprintf(w, "  - libstdc++ abi: %s\n", c.LibstdcxxABI)
+	fmt.Fprintf(w, "  - libstdc++ debug: %s\n", yn[c.LibstdcxxDebug])
+}
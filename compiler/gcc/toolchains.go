@@ -16,73 +16,80 @@ func DiscoverToolchains(feedback func(string)) []*toolchain.Chain {
 	toolchains := []*toolchain.Chain{}
 
 	for _, inst := range installations {
-		tc := &toolchain.Chain{
-			Compiler:       "gcc",
-			Implementation: "gcc",
-			Version:        inst.Version,
-			FullVersion:    inst.FullVersion,
-			Target:         inst.Target,
-			ThreadModel:    inst.ThreadModel,
-			InstalledDir:   filepath.ToSlash(filepath.Dir(inst.CCompiler.PrimaryPath)),
-			CCIncludeDirs:  inst.CCIncludeDirs,
-			CXXIncludeDirs: inst.CXXIncludeDirs,
-			Tools:          toolchain.Toolset{},
-		}
+		toolchains = append(toolchains, NewToolchain(inst, feedback))
+	}
 
-		if feedback != nil {
-			feedback(fmt.Sprintf("scanning gcc %s targeting %s at %s",
-				tc.FullVersion, tc.Target.Original, inst.CCompiler.PrimaryPath))
-		}
-		tc.Tools[toolchain.CCompiler] = toolchain.ToolPath(inst.CCompiler.PrimaryPath)
+	return toolchains
+}
 
-		{
-			n := inst.CCompiler.PrimaryPath
-			infix := "gcc"
-			i := strings.LastIndex(n, infix)
-			if i >= 0 {
-				prefix := n[:i]
-				postfix := n[i+len(infix):]
-				tt := toolchain.FindTools(prefix, postfix, ToolNames)
-				for tool, path := range tt {
-					tc.Tools[tool] = path
-				}
+// NewToolchain creates a toolchain from a gcc installation, collecting
+// the tools that live next to its primary C compiler.
+func NewToolchain(inst *Installation, feedback func(string)) *toolchain.Chain {
+	tc := &toolchain.Chain{
+		Compiler:       "gcc",
+		Implementation: "gcc",
+		Version:        inst.Version,
+		FullVersion:    inst.FullVersion,
+		Target:         inst.Target,
+		ThreadModel:    inst.ThreadModel,
+		InstalledDir:   filepath.ToSlash(filepath.Dir(inst.CCompiler.PrimaryPath)),
+		Sysroot:        inst.Sysroot,
+		CCIncludeDirs:  inst.CCIncludeDirs,
+		CXXIncludeDirs: inst.CXXIncludeDirs,
+		Tools:          toolchain.Toolset{},
+	}
+
+	if feedback != nil {
+		feedback(fmt.Sprintf("scanning gcc %s targeting %s at %s",
+			tc.FullVersion, tc.Target.Original, inst.CCompiler.PrimaryPath))
+	}
+	tc.Tools[toolchain.CCompiler] = toolchain.ToolPath(inst.CCompiler.PrimaryPath)
+
+	{
+		n := inst.CCompiler.PrimaryPath
+		infix := "gcc"
+		i := strings.LastIndex(n, infix)
+		if i >= 0 {
+			prefix := n[:i]
+			postfix := n[i+len(infix):]
+			tt := toolchain.FindTools(prefix, postfix, ToolNames)
+			for tool, path := range tt {
+				tc.Tools[tool] = path
 			}
 		}
+	}
 
-		if !tc.Tools.Contains(toolchain.CXXCompiler) {
-			n := inst.CCompiler.PrimaryPath
-			infix := "gcc"
-			i := strings.LastIndex(n, infix)
-			if i >= 0 {
-				prefix := n[:i]
-				postfix := n[i+len(infix):]
-				tt := toolchain.FindTools(prefix+"g++", postfix, ToolNames)
-				for tool, path := range tt {
-					if _, exists := tc.Tools[tool]; !exists {
-						tc.Tools[tool] = path
-					}
+	if !tc.Tools.Contains(toolchain.CXXCompiler) {
+		n := inst.CCompiler.PrimaryPath
+		infix := "gcc"
+		i := strings.LastIndex(n, infix)
+		if i >= 0 {
+			prefix := n[:i]
+			postfix := n[i+len(infix):]
+			tt := toolchain.FindTools(prefix+"g++", postfix, ToolNames)
+			for tool, path := range tt {
+				if _, exists := tc.Tools[tool]; !exists {
+					tc.Tools[tool] = path
 				}
 			}
 		}
+	}
 
-		em := map[string]string{}
-		if v := tc.Tools[toolchain.CCompiler]; v != "" {
-			em["CC"] = v.Path()
-		}
-		if v := tc.Tools[toolchain.CXXCompiler]; v != "" {
-			em["CXX"] = v.Path()
-		}
-		em["C_INCLUDE_PATH"] = filesystem.JoinPathList(tc.CCIncludeDirs...)
-		em["CPLUS_INCLUDE_PATH"] = filesystem.JoinPathList(tc.CXXIncludeDirs...)
-		for k, v := range em {
-			tc.Environment = append(tc.Environment, fmt.Sprintf("%s=%s", k, v))
-		}
-		sort.Strings(tc.Environment)
-
-		toolchains = append(toolchains, tc)
+	em := map[string]string{}
+	if v := tc.Tools[toolchain.CCompiler]; v != "" {
+		em["CC"] = v.Path()
+	}
+	if v := tc.Tools[toolchain.CXXCompiler]; v != "" {
+		em["CXX"] = v.Path()
 	}
+	em["C_INCLUDE_PATH"] = filesystem.JoinPathList(tc.CCIncludeDirs...)
+	em["CPLUS_INCLUDE_PATH"] = filesystem.JoinPathList(tc.CXXIncludeDirs...)
+	for k, v := range em {
+		tc.Environment = append(tc.Environment, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(tc.Environment)
 
-	return toolchains
+	return tc
 }
 
 func findBinUtils(prefix, version string) map[toolchain.Tool]string {
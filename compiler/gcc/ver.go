@@ -5,12 +5,11 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 
-	"github.com/adnsv/go-build/compiler/toolchain"
 	"github.com/adnsv/go-build/compiler/triplet"
-	"github.com/blang/semver/v4"
 )
 
 var reVersion = regexp.MustCompile("gcc version (.*?) .*")
@@ -81,11 +80,19 @@ func QueryVersion(exe string) (*Ver, error) {
 			line = strings.TrimPrefix(line, configuredWithPrefix)
 			configs, err := parseConfig(line)
 			if err == nil {
-				ret.Languages = strings.Split(configs["enable-languages"], ",")
+				ret.Config = configs
+				ret.Languages = configList(configs["enable-languages"])
+				ret.Capabilities = ParseCapabilities(configs)
 			}
 		}
 	}
 
+	if out, err := exec.Command(exe, "-print-sysroot").Output(); err == nil {
+		if s := strings.TrimSpace(string(out)); s != "" {
+			ret.Sysroot = fixWSLPath(filepath.ToSlash(s))
+		}
+	}
+
 	// Get include paths with proper locale handling
 	if ccIncludes, err := GetSystemIncludes(exe, "c"); err == nil {
 		ret.CCIncludeDirs = ccIncludes
@@ -170,25 +177,6 @@ func GetSystemIncludes(exe, lang string) ([]string, error) {
 	return includes, nil
 }
 
-func Compare(c1, c2 *toolchain.Chain) int {
-	v1, e1 := semver.ParseTolerant(c1.Version)
-	v2, e2 := semver.ParseTolerant(c2.Version)
-	if e1 == nil && e2 == nil {
-		v1.Compare(v2)
-	} else if e1 == nil {
-		return -1
-	} else if e2 == nil {
-		return +1
-	}
-	if i := strings.Compare(c1.Target.Original, c2.Target.Original); i != 0 {
-		return i
-	}
-	if i := strings.Compare(c1.FullVersion, c2.FullVersion); i != 0 {
-		return i
-	}
-	return strings.Compare(string(c1.Tools[toolchain.CXXCompiler]), string(c2.Tools[toolchain.CXXCompiler]))
-}
-
 func parseConfig(s string) (map[string]string, error) {
 	i, n := 0, len(s)
 	for i < n && s[i] < ' ' {
@@ -232,12 +232,7 @@ func detectToolsetVersions(installPath string, feedback func(string)) []ToolsetV
 
 	// Sort versions, latest first
 	sort.Slice(versions, func(i, j int) bool {
-		v1, e1 := ParseVersionQuad(versions[i].Version)
-		v2, e2 := ParseVersionQuad(versions[j].Version)
-		if e1 == nil && e2 == nil {
-			return v1.Compare(v2) > 0
-		}
-		return versions[i].Version > versions[j].Version
+		return toolchain.CompareVersionStrings(versions[i].Version, versions[j].Version) > 0
 	})
 
 	return versions
@@ -335,12 +330,7 @@ func deduplicateInstallations(installations []*Installation) []*Installation {
 
 	// Sort installations by version, latest first
 	sort.SliceStable(result, func(i, j int) bool {
-		v1, e1 := ParseVersionQuad(result[i].InstallationVersion)
-		v2, e2 := ParseVersionQuad(result[j].InstallationVersion)
-		if e1 == nil && e2 == nil {
-			return v1.Compare(v2) > 0
-		}
-		return result[i].InstallationVersion > result[j].InstallationVersion
+		return toolchain.CompareVersionStrings(result[i].InstallationVersion, result[j].InstallationVersion) > 0
 	})
 
 	return result
@@ -446,10 +436,11 @@ func TestArches(inst *Installation, feedback func(string)) []*toolchain.Chain {
 
 		tt := triplet.Full{
 			Target: triplet.Target{
-				Arch: triplet.NormalizeArch(spec.TargetArch),
-				OS:   "windows",
-				ABI:  "pe",
-				LibC: "msvcrt",
+				Arch:         triplet.NormalizeArch(spec.TargetArch),
+				OS:           "windows",
+				ObjectFormat: triplet.PE,
+				Environment:  "msvc",
+				LibC:         "msvcrt",
 			}}
 
 		tc := &toolchain.Chain{
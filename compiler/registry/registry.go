@@ -0,0 +1,341 @@
+// Package registry provides user and project level toolchain configuration
+// that is merged with the results of toolchain discovery. A registry can
+// define toolchains that discovery is unable to find, assign stable names and
+// aliases, override tool paths and environment variables, and hide unwanted
+// toolchains.
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/adnsv/go-build/env"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the registry file inside configuration directories
+const FileName = "toolchains.yaml"
+
+// ProjectDir is the name of the per-project configuration directory
+const ProjectDir = ".go-build"
+
+// Registry contains user-defined toolchains and overrides
+type Registry struct {
+	Toolchains []*toolchain.Chain `json:"toolchains,omitempty" yaml:"toolchains,omitempty"`
+	Overrides  []*Override        `json:"overrides,omitempty" yaml:"overrides,omitempty"`
+}
+
+// Selector picks toolchains an override applies to. Empty fields match
+// anything, all specified fields must match.
+type Selector struct {
+	Name           string `json:"name,omitempty" yaml:"name,omitempty"`                     // name or alias
+	Compiler       string `json:"compiler,omitempty" yaml:"compiler,omitempty"`             // msvc|gcc|clang
+	Implementation string `json:"implementation,omitempty" yaml:"implementation,omitempty"` // gcc|apple-clang|zig-clang|...
+	Version        string `json:"version,omitempty" yaml:"version,omitempty"`               // version constraint, e.g. 13 or >=12,<14
+	Target         string `json:"target,omitempty" yaml:"target,omitempty"`                 // target triplet or pattern (arm*-linux-*-musl)
+	Path           string `json:"path,omitempty" yaml:"path,omitempty"`                     // C or C++ compiler path
+}
+
+// Override modifies toolchains matched by its selector
+type Override struct {
+	Select      Selector          `json:"select" yaml:"select"`
+	Name        string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Aliases     []string          `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Tools       toolchain.Toolset `json:"tools,omitempty" yaml:"tools,omitempty"`
+	Environment []string          `json:"environment,omitempty" yaml:"environment,omitempty"` // KEY=VALUE pairs
+	Hide        bool              `json:"hide,omitempty" yaml:"hide,omitempty"`
+}
+
+// UserPath returns the location of the per-user registry file
+func UserPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-build", FileName), nil
+}
+
+// ProjectPath returns the location of the per-project registry file for the
+// project rooted at dir
+func ProjectPath(dir string) string {
+	return filepath.Join(dir, ProjectDir, FileName)
+}
+
+// LoadFile reads a registry from the specified file. A missing file results
+// in an empty registry.
+func LoadFile(fn string) (*Registry, error) {
+	r := &Registry{}
+	buf, err := os.ReadFile(fn)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(buf, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return r, nil
+}
+
+// SaveFile writes the registry into the specified file, creating the
+// containing directory if necessary.
+func (r *Registry) SaveFile(fn string) error {
+	buf, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(fn), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(fn, buf, 0666)
+}
+
+// Load reads the per-user registry and the registry of the project in the
+// current working directory. Project entries take precedence.
+func Load() (*Registry, error) {
+	r := &Registry{}
+	if fn, err := UserPath(); err == nil {
+		u, err := LoadFile(fn)
+		if err != nil {
+			return nil, err
+		}
+		r.Merge(u)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		p, err := LoadFile(ProjectPath(wd))
+		if err != nil {
+			return nil, err
+		}
+		r.Merge(p)
+	}
+	return r, nil
+}
+
+// Merge adds the contents of another registry. Toolchains from o replace
+// toolchains with the same name, overrides from o are applied after the
+// existing ones.
+func (r *Registry) Merge(o *Registry) {
+	for _, tc := range o.Toolchains {
+		r.put(tc)
+	}
+	r.Overrides = append(r.Overrides, o.Overrides...)
+}
+
+// Find returns a registered toolchain by its name or alias
+func (r *Registry) Find(name string) *toolchain.Chain {
+	for _, tc := range r.Toolchains {
+		if tc.HasName(name) {
+			return tc
+		}
+	}
+	return nil
+}
+
+// Add registers a named toolchain, replacing an existing one with the same
+// name.
+func (r *Registry) Add(tc *toolchain.Chain) error {
+	if tc.Name == "" {
+		return errors.New("toolchain name is required")
+	}
+	for _, a := range tc.Aliases {
+		if other := r.Find(a); other != nil && other.Name != tc.Name {
+			return fmt.Errorf("alias '%s' is already used by toolchain '%s'", a, other.Name)
+		}
+	}
+	r.put(tc)
+	return nil
+}
+
+func (r *Registry) put(tc *toolchain.Chain) {
+	for i, it := range r.Toolchains {
+		if it.Name != "" && it.Name == tc.Name {
+			r.Toolchains[i] = tc
+			return
+		}
+	}
+	r.Toolchains = append(r.Toolchains, tc)
+}
+
+// Remove deletes the registered toolchain with the specified name or alias
+// along with the overrides that select it by name. Returns false if nothing
+// was removed.
+func (r *Registry) Remove(name string) bool {
+	removed := false
+	names := map[string]struct{}{name: {}}
+	tt := r.Toolchains[:0]
+	for _, tc := range r.Toolchains {
+		if tc.HasName(name) {
+			removed = true
+			names[tc.Name] = struct{}{}
+			for _, a := range tc.Aliases {
+				names[a] = struct{}{}
+			}
+			continue
+		}
+		tt = append(tt, tc)
+	}
+	r.Toolchains = tt
+
+	oo := r.Overrides[:0]
+	for _, o := range r.Overrides {
+		_, bySelect := names[o.Select.Name]
+		_, byName := names[o.Name]
+		if bySelect || byName {
+			removed = true
+			continue
+		}
+		oo = append(oo, o)
+	}
+	r.Overrides = oo
+	return removed
+}
+
+// Apply merges the registry with discovered toolchains. It appends
+// registered toolchains, applies the overrides in order, and drops hidden
+// toolchains. A registered toolchain replaces the discovered one with the
+// same compiler.
+func (r *Registry) Apply(tt []*toolchain.Chain) []*toolchain.Chain {
+	ret := make([]*toolchain.Chain, 0, len(tt)+len(r.Toolchains))
+	ret = append(ret, tt...)
+	for _, tc := range r.Toolchains {
+		c := *tc
+		c.Tools = toolchain.Toolset{}
+		for k, v := range tc.Tools {
+			c.Tools[k] = v
+		}
+		p := compilerTool(&c)
+		i := slices.IndexFunc(ret[:len(tt)], func(d *toolchain.Chain) bool { return p != "" && compilerTool(d) == p })
+		if i >= 0 {
+			ret[i] = &c
+		} else {
+			ret = append(ret, &c)
+		}
+	}
+
+	hidden := map[*toolchain.Chain]struct{}{}
+	for _, o := range r.Overrides {
+		for _, tc := range ret {
+			if o.Select.Match(tc) {
+				if o.Hide {
+					hidden[tc] = struct{}{}
+				}
+				o.apply(tc)
+			}
+		}
+	}
+	if len(hidden) == 0 {
+		return ret
+	}
+
+	visible := ret[:0]
+	for _, tc := range ret {
+		if _, ok := hidden[tc]; !ok {
+			visible = append(visible, tc)
+		}
+	}
+	return visible
+}
+
+// compilerTool returns the C compiler of the chain, or the C++ compiler
+// for chains without one
+func compilerTool(tc *toolchain.Chain) toolchain.ToolPath {
+	if v := tc.Tools[toolchain.CCompiler]; v != "" {
+		return v
+	}
+	return tc.Tools[toolchain.CXXCompiler]
+}
+
+func (o *Override) apply(tc *toolchain.Chain) {
+	if o.Name != "" {
+		tc.Name = o.Name
+	}
+	for _, a := range o.Aliases {
+		if !tc.HasName(a) {
+			tc.Aliases = append(tc.Aliases, a)
+		}
+	}
+
+	em := env.Split(tc.Environment)
+	if len(o.Tools) > 0 {
+		if tc.Tools == nil {
+			tc.Tools = toolchain.Toolset{}
+		}
+		for k, v := range o.Tools {
+			tc.Tools[k] = v
+		}
+		// keep CC/CXX in sync with the overridden tools
+		if _, ok := em["CC"]; ok && o.Tools.Contains(toolchain.CCompiler) {
+			em["CC"] = o.Tools[toolchain.CCompiler].Path()
+		}
+		if _, ok := em["CXX"]; ok && o.Tools.Contains(toolchain.CXXCompiler) {
+			em["CXX"] = o.Tools[toolchain.CXXCompiler].Path()
+		}
+	}
+	if len(o.Environment) > 0 || len(o.Tools) > 0 {
+		tc.Environment = env.Join(env.Merge(em, env.Split(o.Environment)))
+	}
+}
+
+// Match checks whether the toolchain satisfies all the selector fields
+func (s *Selector) Match(tc *toolchain.Chain) bool {
+	if s.Name != "" && !tc.HasName(s.Name) {
+		return false
+	}
+	if s.Compiler != "" && s.Compiler != tc.Compiler {
+		return false
+	}
+	if s.Implementation != "" && s.Implementation != tc.Implementation {
+		return false
+	}
+	if s.Version != "" {
+		c, err := toolchain.ParseConstraint(s.Version)
+		if err != nil || !tc.MatchVersion(c) {
+			return false
+		}
+	}
+	if s.Target != "" && s.Target != tc.Target.Original {
+		if triplet.IsPattern(s.Target) {
+			p, err := triplet.ParsePattern(s.Target)
+			if err != nil || !p.Match(tc.Target.Target) {
+				return false
+			}
+		} else {
+			t, _, err := triplet.ParseTarget(s.Target)
+			if err != nil || !tc.Target.Match(wildcardUnknowns(t)) {
+				return false
+			}
+		}
+	}
+	if s.Path != "" {
+		p := filepath.ToSlash(s.Path)
+		cc, cxx := tc.GetCompilerPaths()
+		if p != cc && p != cxx {
+			return false
+		}
+	}
+	return true
+}
+
+// wildcardUnknowns clears the target fields that were not recognized so that
+// partial selectors like 'arm64-linux' match any object format and C library
+func wildcardUnknowns(t triplet.Target) triplet.Target {
+	if t.Arch == "unknown" {
+		t.Arch = ""
+	}
+	if t.OS == "unknown" {
+		t.OS = ""
+	}
+	if t.ObjectFormat == "unknown" {
+		t.ObjectFormat = ""
+	}
+	if t.LibC == "unknown" {
+		t.LibC = ""
+	}
+	return t
+}
@@ -0,0 +1,259 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+func mustTarget(t *testing.T, s string) triplet.Full {
+	t.Helper()
+	f, err := triplet.ParseFull(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func names(tt []*toolchain.Chain) string {
+	ss := []string{}
+	for _, tc := range tt {
+		ss = append(ss, tc.Name)
+	}
+	return strings.Join(ss, ",")
+}
+
+func TestSelector_Match(t *testing.T) {
+	tc := &toolchain.Chain{
+		Name:           "cross",
+		Aliases:        []string{"arm"},
+		Compiler:       "gcc",
+		Implementation: "gcc",
+		Version:        "13.2.0",
+		Target:         mustTarget(t, "aarch64-linux-gnu"),
+		Tools: toolchain.Toolset{
+			toolchain.CCompiler:   "/opt/cross/bin/aarch64-linux-gnu-gcc",
+			toolchain.CXXCompiler: "/opt/cross/bin/aarch64-linux-gnu-g++",
+		},
+	}
+	tests := []struct {
+		name string
+		sel  Selector
+		want bool
+	}{
+		{"empty", Selector{}, true},
+		{"name", Selector{Name: "cross"}, true},
+		{"alias", Selector{Name: "arm"}, true},
+		{"other name", Selector{Name: "native"}, false},
+		{"compiler", Selector{Compiler: "gcc"}, true},
+		{"other compiler", Selector{Compiler: "clang"}, false},
+		{"implementation", Selector{Implementation: "apple-clang"}, false},
+		{"version", Selector{Version: "13"}, true},
+		{"version range", Selector{Version: ">=12,<14"}, true},
+		{"version mismatch", Selector{Version: ">=14"}, false},
+		{"invalid version", Selector{Version: ">>1"}, false},
+		{"original target", Selector{Target: "aarch64-linux-gnu"}, true},
+		{"target alias", Selector{Target: "arm64-linux-gnu"}, true},
+		{"partial target", Selector{Target: "arm64-linux"}, true},
+		{"partial target mismatch", Selector{Target: "x86_64-linux"}, false},
+		{"target pattern", Selector{Target: "aarch64-linux-*"}, true},
+		{"target pattern mismatch", Selector{Target: "*-windows-*"}, false},
+		{"c path", Selector{Path: "/opt/cross/bin/aarch64-linux-gnu-gcc"}, true},
+		{"c++ path", Selector{Path: "/opt/cross/bin/aarch64-linux-gnu-g++"}, true},
+		{"other path", Selector{Path: "/usr/bin/gcc"}, false},
+		{"all fields", Selector{Name: "arm", Compiler: "gcc", Version: "13", Target: "arm64-linux"}, true},
+		{"one field fails", Selector{Name: "arm", Compiler: "gcc", Version: "12"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sel.Match(tc); got != tt.want {
+				t.Errorf("Match(%+v) = %v, want %v", tt.sel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_Apply(t *testing.T) {
+	discovered := func() []*toolchain.Chain {
+		return []*toolchain.Chain{
+			{Compiler: "gcc", Version: "12.2.0", Tools: toolchain.Toolset{
+				toolchain.CCompiler:   "/usr/bin/gcc-12",
+				toolchain.CXXCompiler: "/usr/bin/g++-12",
+			}, Environment: []string{"CC=/usr/bin/gcc-12", "CXX=/usr/bin/g++-12"}},
+			{Compiler: "clang", Version: "17.0.6"},
+			{Compiler: "gcc", Version: "13.2.0"},
+		}
+	}
+	tests := []struct {
+		name  string
+		reg   Registry
+		want  string
+		check func(t *testing.T, tt []*toolchain.Chain)
+	}{
+		{"registered toolchains are appended", Registry{
+			Toolchains: []*toolchain.Chain{{Name: "zig", Compiler: "clang"}},
+		}, ",,,zig", nil},
+		{"registered toolchains replace discovered ones with the same compiler", Registry{
+			Toolchains: []*toolchain.Chain{{Name: "gcc-12", Compiler: "gcc", Tools: toolchain.Toolset{
+				toolchain.CCompiler: "/usr/bin/gcc-12",
+			}}},
+		}, "gcc-12,,", func(t *testing.T, tt []*toolchain.Chain) {
+			if _, cxx := tt[0].GetCompilerPaths(); cxx != "" {
+				t.Errorf("discovered tools were kept: %s", cxx)
+			}
+		}},
+		{"overrides apply in order", Registry{
+			Overrides: []*Override{
+				{Select: Selector{Compiler: "gcc"}, Name: "gcc"},
+				{Select: Selector{Compiler: "gcc", Version: "13"}, Name: "gcc-13"},
+			},
+		}, "gcc,,gcc-13", nil},
+		{"later overrides select the names of earlier ones", Registry{
+			Overrides: []*Override{
+				{Select: Selector{Version: "17"}, Name: "clang", Aliases: []string{"llvm"}},
+				{Select: Selector{Name: "llvm"}, Name: "clang-17"},
+			},
+		}, ",clang-17,", func(t *testing.T, tt []*toolchain.Chain) {
+			if !tt[1].HasName("llvm") {
+				t.Errorf("alias was lost: %v", tt[1].Aliases)
+			}
+		}},
+		{"hide", Registry{
+			Toolchains: []*toolchain.Chain{{Name: "old", Compiler: "gcc", Version: "9.4.0"}},
+			Overrides: []*Override{
+				{Select: Selector{Compiler: "clang"}, Hide: true},
+				{Select: Selector{Name: "old"}, Hide: true},
+			},
+		}, ",", nil},
+		{"tools keep CC and CXX in sync", Registry{
+			Overrides: []*Override{{
+				Select: Selector{Path: "/usr/bin/gcc-12"},
+				Name:   "gcc-12",
+				Tools: toolchain.Toolset{
+					toolchain.CCompiler:   "/usr/bin/ccache-gcc",
+					toolchain.CXXCompiler: "/usr/bin/ccache-g++",
+				},
+				Environment: []string{"CCACHE_DIR=/tmp/ccache"},
+			}},
+		}, "gcc-12,,", func(t *testing.T, tt []*toolchain.Chain) {
+			cc, cxx := tt[0].GetCompilerPaths()
+			if cc != "/usr/bin/ccache-gcc" || cxx != "/usr/bin/ccache-g++" {
+				t.Errorf("tools = %s, %s", cc, cxx)
+			}
+			want := "CC=/usr/bin/ccache-gcc CCACHE_DIR=/tmp/ccache CXX=/usr/bin/ccache-g++"
+			if got := strings.Join(tt[0].Environment, " "); got != want {
+				t.Errorf("Environment = %s, want %s", got, want)
+			}
+			// CC and CXX are only updated, never added
+			if len(tt[2].Environment) != 0 {
+				t.Errorf("Environment of an unselected chain = %v", tt[2].Environment)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.reg.Apply(discovered())
+			if names(got) != tt.want {
+				t.Errorf("Apply() = %q, want %q", names(got), tt.want)
+			}
+			if tt.check != nil {
+				tt.check(t, got)
+			}
+		})
+	}
+
+	// registered toolchains are copied, overrides do not modify the registry
+	r := &Registry{
+		Toolchains: []*toolchain.Chain{{Name: "zig", Compiler: "clang", Tools: toolchain.Toolset{toolchain.CCompiler: "/opt/zig/zig|cc"}}},
+		Overrides:  []*Override{{Select: Selector{Name: "zig"}, Tools: toolchain.Toolset{toolchain.CCompiler: "/usr/bin/zig|cc"}}},
+	}
+	r.Apply(nil)
+	if cc, _ := r.Toolchains[0].GetCompilerPaths(); cc != "/opt/zig/zig" {
+		t.Errorf("Apply() modified the registered toolchain: %s", cc)
+	}
+}
+
+func TestRegistry_Add(t *testing.T) {
+	r := &Registry{}
+	if err := r.Add(&toolchain.Chain{Compiler: "gcc"}); err == nil {
+		t.Errorf("Add() accepted a toolchain without a name")
+	}
+	if err := r.Add(&toolchain.Chain{Name: "a", Aliases: []string{"x"}, Version: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(&toolchain.Chain{Name: "b", Aliases: []string{"x"}}); err == nil {
+		t.Errorf("Add() accepted an alias of another toolchain")
+	}
+	if err := r.Add(&toolchain.Chain{Name: "c", Aliases: []string{"a"}}); err == nil {
+		t.Errorf("Add() accepted an alias that is the name of another toolchain")
+	}
+	// replacing a toolchain may keep its aliases
+	if err := r.Add(&toolchain.Chain{Name: "a", Aliases: []string{"x", "y"}, Version: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Toolchains) != 1 || r.Find("y") == nil || r.Find("x").Version != "2" {
+		t.Errorf("Toolchains = %v", names(r.Toolchains))
+	}
+}
+
+func TestRegistry_Remove(t *testing.T) {
+	r := &Registry{
+		Toolchains: []*toolchain.Chain{
+			{Name: "a", Aliases: []string{"x"}},
+			{Name: "b"},
+		},
+		Overrides: []*Override{
+			{Select: Selector{Name: "x"}, Environment: []string{"A=1"}},
+			{Select: Selector{Compiler: "gcc"}, Name: "a"},
+			{Select: Selector{Name: "b"}, Hide: true},
+			{Select: Selector{Compiler: "clang"}},
+		},
+	}
+	if !r.Remove("x") {
+		t.Fatalf("Remove() = false")
+	}
+	if names(r.Toolchains) != "b" {
+		t.Errorf("Toolchains = %s, want b", names(r.Toolchains))
+	}
+	if len(r.Overrides) != 2 || r.Overrides[0].Select.Name != "b" || r.Overrides[1].Select.Compiler != "clang" {
+		t.Errorf("Overrides = %+v", r.Overrides)
+	}
+	if r.Remove("x") {
+		t.Errorf("Remove() of a removed toolchain = true")
+	}
+
+	// overrides of discovered toolchains are removed by name as well
+	r = &Registry{Overrides: []*Override{{Select: Selector{Compiler: "gcc"}, Name: "gcc-12"}}}
+	if !r.Remove("gcc-12") || len(r.Overrides) != 0 {
+		t.Errorf("Remove() kept %+v", r.Overrides)
+	}
+}
+
+func TestRegistry_Merge(t *testing.T) {
+	user := &Registry{
+		Toolchains: []*toolchain.Chain{{Name: "a", Version: "user"}, {Name: "b", Version: "user"}},
+		Overrides:  []*Override{{Select: Selector{Compiler: "gcc"}, Name: "user"}},
+	}
+	project := &Registry{
+		Toolchains: []*toolchain.Chain{{Name: "b", Version: "project"}, {Name: "c", Version: "project"}},
+		Overrides:  []*Override{{Select: Selector{Compiler: "gcc"}, Name: "project"}},
+	}
+	r := &Registry{}
+	r.Merge(user)
+	r.Merge(project)
+
+	got := []string{}
+	for _, tc := range r.Toolchains {
+		got = append(got, tc.Name+"="+tc.Version)
+	}
+	if s := strings.Join(got, ","); s != "a=user,b=project,c=project" {
+		t.Errorf("Toolchains = %s", s)
+	}
+	// the project override is applied last, so it wins
+	tt := r.Apply([]*toolchain.Chain{{Compiler: "gcc"}})
+	if names(tt) != "project,a,b,c" {
+		t.Errorf("Apply() = %s", names(tt))
+	}
+}
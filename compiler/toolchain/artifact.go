@@ -0,0 +1,38 @@
+package toolchain
+
+import (
+	"fmt"
+
+	"github.com/adnsv/go-build/inspect"
+)
+
+// ErrTargetMismatch is returned by VerifyArtifact when a file was not built
+// for the toolchain target
+type ErrTargetMismatch struct {
+	File   string
+	Want   string
+	Actual string
+}
+
+func (e *ErrTargetMismatch) Error() string {
+	return fmt.Sprintf("%s was built for %s, want %s", e.File, e.Actual, e.Want)
+}
+
+// VerifyArtifact inspects a file produced with the toolchain and checks that
+// it was built for the toolchain target, e.g. that a cross build produced
+// arm64 musl binaries rather than host binaries. Fields that can not be
+// derived from the file headers are not compared.
+func (tc *Chain) VerifyArtifact(fn string) (*inspect.Info, error) {
+	info, err := inspect.File(fn)
+	if err != nil {
+		return nil, err
+	}
+	want := tc.Target.Target
+	if tc.IsMSVC() {
+		want.Environment = "msvc"
+	}
+	if !info.Matches(want) {
+		return info, &ErrTargetMismatch{File: fn, Want: want.Text(), Actual: info.Target.Text()}
+	}
+	return info, nil
+}
@@ -0,0 +1,36 @@
+package toolchain
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+func TestChain_VerifyArtifact(t *testing.T) {
+	fn, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	host, err := triplet.FromGo(runtime.GOOS, runtime.GOARCH, "", "")
+	if err != nil {
+		t.Skip(err)
+	}
+	native := &Chain{Compiler: "gcc", Target: triplet.Full{Target: host}}
+	if _, err := native.VerifyArtifact(fn); err != nil {
+		t.Errorf("VerifyArtifact() for the host target: %v", err)
+	}
+
+	other := "riscv64-linux-musl"
+	if host.Arch == "riscv64" {
+		other = "aarch64-linux-musl"
+	}
+	cross, _ := triplet.ParseFull(other)
+	_, err = (&Chain{Compiler: "gcc", Target: cross}).VerifyArtifact(fn)
+	var mismatch *ErrTargetMismatch
+	if !errors.As(err, &mismatch) {
+		t.Errorf("VerifyArtifact() for %s = %v, want a target mismatch", other, err)
+	}
+}
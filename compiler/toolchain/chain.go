@@ -3,19 +3,25 @@ package toolchain
 import (
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/adnsv/go-build/compiler/triplet"
 )
 
 // Chain contains all the information discovered about a compiler
 type Chain struct {
+	Name                string       `json:"name,omitempty" yaml:"name,omitempty"`                     // stable name assigned by the registry
+	Aliases             []string     `json:"aliases,omitempty" yaml:"aliases,omitempty"`               // alternative names
 	Compiler            string       `json:"compiler" yaml:"compiler"`                                 // msvc|gcc|clang
 	Implementation      string       `json:"implementation,omitempty" yaml:"implementation,omitempty"` // msvc|gcc|clang|apple-clang|emscripten|...
 	FullVersion         string       `json:"full-version,omitempty" yaml:"full-version,omitempty"`
 	Version             string       `json:"version,omitempty" yaml:"version,omitempty"`
+	LLVMVersion         string       `json:"llvm-version,omitempty" yaml:"llvm-version,omitempty"` // upstream LLVM version for clang variants
 	Target              triplet.Full `json:"target,omitempty" yaml:"target,omitempty"`
 	ThreadModel         string       `json:"thread-model,omitempty" yaml:"thread-model,omitempty"`
 	InstalledDir        string       `json:"installed-dir,omitempty" yaml:"installed-dir,omitempty"`
+	Sysroot             string       `json:"sysroot,omitempty" yaml:"sysroot,omitempty"`
+	SDKVersion          string       `json:"sdk-version,omitempty" yaml:"sdk-version,omitempty"` // release of the SDK that bundles the compiler (wasi-sdk)
 	VisualStudioID      string       `json:"msvc-id,omitempty" yaml:"msvc-id,omitempty"`
 	VisualStudioArch    string       `json:"msvc-arch,omitempty" yaml:"msvc-arch,omitempty"`
 	VisualStudioVersion string       `json:"msvc-version,omitempty" yaml:"msvc-version,omitempty"`
@@ -23,7 +29,8 @@ type Chain struct {
 	UCRTVersion         string       `json:"ucrt,omitempty" yaml:"ucrt,omitempty"`
 	ToolsetVersion      string       `json:"toolset,omitempty" yaml:"toolset,omitempty"`
 
-	Tools Toolset `json:"tools" yaml:"tools"` // paths to tool executables
+	Tools Toolset `json:"tools" yaml:"tools"`                 // paths to tool executables
+	ISA   *ISA    `json:"isa,omitempty" yaml:"isa,omitempty"` // populated with ProbeISA
 
 	CCIncludeDirs  []string `json:"cc-include-dirs,omitempty" yaml:"cc-include-dirs,omitempty"`
 	CXXIncludeDirs []string `json:"cxx-include-dirs,omitempty" yaml:"cxx-include-dirs,omitempty"`
@@ -36,12 +43,48 @@ func (tc *Chain) PrintSummary(w io.Writer) {
 	if tc.VisualStudioVersion != "" {
 		ver = fmt.Sprintf("%s (%s)", tc.VisualStudioVersion, tc.Version)
 	}
+	if tc.LLVMVersion != "" && tc.LLVMVersion != tc.Version {
+		ver = fmt.Sprintf("%s (llvm %s)", ver, tc.LLVMVersion)
+	}
 	fmt.Fprintf(w, "%s %s\n", tc.Compiler, ver)
+	if tc.Name != "" {
+		fmt.Fprintf(w, "- name: %s\n", tc.Name)
+	}
+	if len(tc.Aliases) > 0 {
+		fmt.Fprintf(w, "- aliases: %s\n", strings.Join(tc.Aliases, ", "))
+	}
 	fmt.Fprintf(w, "- target: %s\n", tc.Target.Original)
 	fmt.Fprintf(w, "  - os: %s\n", tc.Target.OS)
+	if tc.Target.OSVersion != "" {
+		fmt.Fprintf(w, "  - os version: %s\n", tc.Target.OSVersion)
+	}
 	fmt.Fprintf(w, "  - arch: %s\n", tc.Target.Arch)
-	fmt.Fprintf(w, "  - abi: %s\n", tc.Target.ABI)
+	if tc.Target.SubArch != "" {
+		fmt.Fprintf(w, "  - subarch: %s\n", tc.Target.SubArch)
+	}
+	if tc.Target.FloatABI != "" {
+		fmt.Fprintf(w, "  - float abi: %s\n", tc.Target.FloatABI)
+	}
+	if tc.Target.Endian != "" {
+		fmt.Fprintf(w, "  - endian: %s\n", tc.Target.Endian)
+	}
+	if tc.Target.PointerWidth != 0 {
+		fmt.Fprintf(w, "  - pointer width: %d\n", tc.Target.PointerWidth)
+	}
+	fmt.Fprintf(w, "  - object format: %s\n", tc.Target.ObjectFormat)
+	if tc.Target.Environment != "" {
+		fmt.Fprintf(w, "  - environment: %s\n", tc.Target.Environment)
+	}
 	fmt.Fprintf(w, "  - libc: %s\n", tc.Target.LibC)
+	if tc.Target.LibCVersion != "" {
+		fmt.Fprintf(w, "  - libc version: %s\n", tc.Target.LibCVersion)
+	}
+	if tc.Sysroot != "" {
+		fmt.Fprintf(w, "- sysroot: '%s'\n", tc.Sysroot)
+	}
+	if tc.SDKVersion != "" {
+		fmt.Fprintf(w, "- sdk version: %s\n", tc.SDKVersion)
+	}
 	cc, cxx := tc.GetCompilerPaths()
 	if cc == cxx {
 		fmt.Fprintf(w, "  - C/C++ path: '%s'\n", cc)
@@ -53,6 +96,18 @@ func (tc *Chain) PrintSummary(w io.Writer) {
 			fmt.Fprintf(w, "  - C++ path: '%s'\n", cxx)
 		}
 	}
+	if tc.ISA != nil {
+		if tc.ISA.Default != "" {
+			fmt.Fprintf(w, "- default arch: %s\n", tc.ISA.Default)
+		}
+		if n := tc.ISA.Native; n != nil {
+			fmt.Fprintf(w, "- native arch: %s\n", n.Arch)
+			if len(n.Features) > 0 {
+				fmt.Fprintf(w, "  - features: %s\n", strings.Join(n.Features, " "))
+			}
+		}
+		fmt.Fprintf(w, "- supported arches: %d, cpus: %d, tunes: %d\n", len(tc.ISA.Arches), len(tc.ISA.CPUs), len(tc.ISA.Tunes))
+	}
 }
 
 func (tc *Chain) GetCompilerPaths() (cc, cxx string) {
@@ -64,3 +119,20 @@ func (tc *Chain) GetCompilerPaths() (cc, cxx string) {
 	}
 	return
 }
+
+// HasName returns true if the chain is known under the specified name or
+// one of its aliases.
+func (tc *Chain) HasName(name string) bool {
+	if name == "" {
+		return false
+	}
+	if tc.Name == name {
+		return true
+	}
+	for _, a := range tc.Aliases {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package toolchain
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/adnsv/go-build/env"
+)
+
+// Command prepares execution of a chain tool with the chain environment
+func (tc *Chain) Command(tool Tool, args ...string) (*exec.Cmd, error) {
+	tp := tc.Tools[tool]
+	if tp == "" {
+		return nil, fmt.Errorf("%s is not available", tool.LongName())
+	}
+	cmd := exec.Command(tp.Path(), append(tp.Commands(), args...)...)
+	cmd.Env = tc.Env()
+	return cmd, nil
+}
+
+// Env returns the process environment extended with the chain environment
+func (tc *Chain) Env() []string {
+	if len(tc.Environment) == 0 {
+		return os.Environ()
+	}
+	return env.Join(env.Merge(env.Split(os.Environ()), env.Split(tc.Environment)))
+}
+
+// IsMSVC returns true for toolchains with MSVC-style command line
+func (tc *Chain) IsMSVC() bool {
+	return tc.Compiler == "msvc"
+}
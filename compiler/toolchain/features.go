@@ -0,0 +1,375 @@
+package toolchain
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Features describes the language standards accepted by a toolchain and the
+// standard library it uses
+type Features struct {
+	C       []Standard `json:"c,omitempty" yaml:"c,omitempty"`
+	CXX     []Standard `json:"cxx,omitempty" yaml:"cxx,omitempty"`
+	Library *StdLib    `json:"library,omitempty" yaml:"library,omitempty"`
+}
+
+// Standard is a language standard accepted by the compiler
+type Standard struct {
+	Name   string            `json:"name" yaml:"name"`                         // c11, c++20, ...
+	Flag   string            `json:"flag" yaml:"flag"`                         // -std=c++2a, /std:c++20, ...
+	Macros map[string]string `json:"macros,omitempty" yaml:"macros,omitempty"` // __STDC_VERSION__, __cplusplus, __cpp_* and __cpp_lib_* values
+
+	// Partial is set when the standard is only reachable through a flag
+	// that selects whatever the compiler implements of the latest draft,
+	// like /std:c++latest, so the support may be incomplete
+	Partial bool `json:"partial,omitempty" yaml:"partial,omitempty"`
+}
+
+// StdLib identifies a C++ standard library implementation
+type StdLib struct {
+	Name    string `json:"name" yaml:"name"` // libstdc++|libc++|msvc-stl
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// Standards in the order of appearance, each with alternative spellings
+// (preferred first) for gcc/clang and msvc.
+var (
+	CStandards   = []string{"c89", "c99", "c11", "c17", "c23"}
+	CXXStandards = []string{"c++98", "c++11", "c++14", "c++17", "c++20", "c++23", "c++26"}
+
+	gnuStdFlags = map[string][]string{
+		"c89":   {"c89"},
+		"c99":   {"c99"},
+		"c11":   {"c11"},
+		"c17":   {"c17", "c18"},
+		"c23":   {"c23", "c2x"},
+		"c++98": {"c++98"},
+		"c++11": {"c++11", "c++0x"},
+		"c++14": {"c++14", "c++1y"},
+		"c++17": {"c++17", "c++1z"},
+		"c++20": {"c++20", "c++2a"},
+		"c++23": {"c++23", "c++2b"},
+		"c++26": {"c++26", "c++2c"},
+	}
+	msvcStdFlags = map[string][]string{
+		"c11":   {"c11"},
+		"c17":   {"c17"},
+		"c23":   {"clatest"},
+		"c++14": {"c++14"},
+		"c++17": {"c++17"},
+		"c++20": {"c++20"},
+		"c++23": {"c++23preview", "c++latest"}, // c++23preview needs VS 17.13
+	}
+)
+
+// Get returns the standard with the specified name
+func (f *Features) Get(name string) *Standard {
+	for _, ss := range [][]Standard{f.C, f.CXX} {
+		for i := range ss {
+			if ss[i].Name == name {
+				return &ss[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Supports checks if the standard is accepted by the compiler
+func (f *Features) Supports(name string) bool {
+	return f.Get(name) != nil
+}
+
+// Macro returns the value of a feature-test macro in the most recent C++
+// standard where it is defined
+func (f *Features) Macro(name string) string {
+	for i := len(f.CXX) - 1; i >= 0; i-- {
+		if v := f.CXX[i].Macros[name]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Features probes the compiler for accepted -std values (/std: for msvc),
+// collects feature-test macros for each C++ standard, and identifies the
+// standard library.
+func (tc *Chain) Features() (*Features, error) {
+	tmpdir, err := os.MkdirTemp("", "go-build-features")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	f := &Features{}
+	var libmacros map[string]string
+	if tc.Tools.Contains(CCompiler) {
+		for _, name := range CStandards {
+			if s := tc.probeStandard(CCompiler, name, tmpdir); s != nil {
+				f.C = append(f.C, *s)
+			}
+		}
+	}
+	if tc.Tools.Contains(CXXCompiler) {
+		for _, name := range CXXStandards {
+			if s := tc.probeStandard(CXXCompiler, name, tmpdir); s != nil {
+				libmacros = s.Macros
+				f.CXX = append(f.CXX, *s)
+			}
+		}
+	}
+	if len(f.C) == 0 && len(f.CXX) == 0 {
+		return nil, errors.New("failed to probe language standards")
+	}
+	f.Library = detectStdLib(libmacros)
+	for i := range f.CXX {
+		for k := range f.CXX[i].Macros {
+			if !isFeatureMacro(k) {
+				delete(f.CXX[i].Macros, k)
+			}
+		}
+	}
+	return f, nil
+}
+
+func (tc *Chain) probeStandard(tool Tool, name string, tmpdir string) *Standard {
+	cxx := tool == CXXCompiler
+	if tc.IsMSVC() {
+		for _, v := range msvcStdFlags[name] {
+			flag := "/std:" + v
+			if m, ok := tc.msvcMacros(flag, cxx, tmpdir); ok {
+				return &Standard{Name: name, Flag: flag, Macros: m, Partial: strings.HasSuffix(v, "latest")}
+			}
+		}
+		return nil
+	}
+	for _, v := range gnuStdFlags[name] {
+		flag := "-std=" + v
+		if m, ok := tc.gnuMacros(tool, flag, cxx); ok {
+			return &Standard{Name: name, Flag: flag, Macros: m}
+		}
+	}
+	return nil
+}
+
+const cxxProbeSource = `#if defined(__has_include)
+#if __has_include(<version>)
+#include <version>
+#else
+#include <ciso646>
+#endif
+#else
+#include <ciso646>
+#endif
+`
+
+// gnuMacros dumps predefined macros with gcc-compatible drivers
+func (tc *Chain) gnuMacros(tool Tool, flag string, cxx bool) (map[string]string, bool) {
+	lang, src := "c", ""
+	if cxx {
+		lang, src = "c++", cxxProbeSource
+	}
+	run := func(src string) (string, error) {
+		cmd, err := tc.Command(tool, flag, "-dM", "-E", "-x", lang, "-")
+		if err != nil {
+			return "", err
+		}
+		cmd.Stdin = strings.NewReader(src)
+		out, err := cmd.Output()
+		return string(out), err
+	}
+	out, err := run(src)
+	if err != nil && src != "" {
+		// the flag may still be valid without the library headers
+		out, err = run("")
+	}
+	if err != nil {
+		return nil, false
+	}
+	return parseDefines(out), true
+}
+
+// parseDefines collects the macros of interest from -dM output
+func parseDefines(out string) map[string]string {
+	m := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		ff := strings.Fields(line)
+		if len(ff) >= 3 && ff[0] == "#define" && keepMacro(ff[1]) {
+			m[ff[1]] = ff[2]
+		}
+	}
+	return m
+}
+
+// msvcMacros evaluates the known macros with the msvc preprocessor as it
+// has no equivalent of -dM
+func (tc *Chain) msvcMacros(flag string, cxx bool, tmpdir string) (map[string]string, bool) {
+	names := []string{"__STDC_VERSION__"}
+	fn := filepath.Join(tmpdir, "probe.c")
+	if cxx {
+		names = append([]string{"__cplusplus", "_MSVC_STL_VERSION", "_MSVC_STL_UPDATE", "_LIBCPP_VERSION"}, knownFeatureMacros...)
+		fn = filepath.Join(tmpdir, "probe.cpp")
+	}
+	if err := os.WriteFile(fn, []byte(macroProbeSource(names, cxx)), 0666); err != nil {
+		return nil, false
+	}
+	cmd, err := tc.Command(CXXCompiler, "/nologo", "/EP", "/Zc:__cplusplus", flag, fn)
+	if err != nil {
+		return nil, false
+	}
+	cmd.Dir = tmpdir
+	out, err := cmd.CombinedOutput()
+	// unknown options are reported with a D9002 warning only
+	if err != nil || strings.Contains(string(out), "D9002") {
+		return nil, false
+	}
+	return parseProbedMacros(string(out)), true
+}
+
+const macroProbeMarker = "GO_BUILD_MACRO"
+
+// macroProbeSource emits a marker line per macro with the stringized name
+// followed by the name, which the preprocessor replaces with the value
+func macroProbeSource(names []string, cxx bool) string {
+	lines := []string{}
+	if cxx {
+		lines = append(lines, cxxProbeSource)
+	}
+	lines = append(lines, "#define GO_BUILD_STR(x) #x")
+	for _, n := range names {
+		lines = append(lines, fmt.Sprintf("%s GO_BUILD_STR(%s) %s", macroProbeMarker, n, n))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// parseProbedMacros collects the values from the preprocessed
+// macroProbeSource, macros that are not defined expand to their names
+func parseProbedMacros(out string) map[string]string {
+	m := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		ff := strings.Fields(line)
+		if len(ff) < 3 || ff[0] != macroProbeMarker {
+			continue
+		}
+		name, err := strconv.Unquote(ff[1])
+		if err != nil {
+			continue
+		}
+		if v := strings.Join(ff[2:], ""); v != name {
+			m[name] = v
+		}
+	}
+	return m
+}
+
+func keepMacro(name string) bool {
+	switch name {
+	case "__STDC_VERSION__", "__cplusplus",
+		"_GLIBCXX_RELEASE", "__GLIBCXX__", "_LIBCPP_VERSION",
+		"_MSVC_STL_VERSION", "_MSVC_STL_UPDATE":
+		return true
+	}
+	return strings.HasPrefix(name, "__cpp_")
+}
+
+func isFeatureMacro(name string) bool {
+	return name == "__STDC_VERSION__" || name == "__cplusplus" || strings.HasPrefix(name, "__cpp_")
+}
+
+func detectStdLib(m map[string]string) *StdLib {
+	switch {
+	case m["_LIBCPP_VERSION"] != "":
+		return &StdLib{Name: "libc++", Version: libcxxVersion(m["_LIBCPP_VERSION"])}
+	case m["_GLIBCXX_RELEASE"] != "" || m["__GLIBCXX__"] != "":
+		v := m["_GLIBCXX_RELEASE"]
+		if d := m["__GLIBCXX__"]; d != "" {
+			if v != "" {
+				v += " (" + d + ")"
+			} else {
+				v = d
+			}
+		}
+		return &StdLib{Name: "libstdc++", Version: v}
+	case m["_MSVC_STL_VERSION"] != "":
+		v := m["_MSVC_STL_VERSION"]
+		if u := m["_MSVC_STL_UPDATE"]; u != "" {
+			v += " (" + strings.TrimSuffix(u, "L") + ")"
+		}
+		return &StdLib{Name: "msvc-stl", Version: v}
+	}
+	return nil
+}
+
+// libcxxVersion decodes _LIBCPP_VERSION: XXYYZZ since libc++ 16, XYYZZ before
+func libcxxVersion(s string) string {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return s
+	}
+	if n >= 100000 {
+		return fmt.Sprintf("%d.%d.%d", n/10000, n/100%100, n%100)
+	}
+	return fmt.Sprintf("%d.%d.%d", n/1000, n/100%10, n%100)
+}
+
+// SortedMacros returns macro names in alphabetical order
+func (s *Standard) SortedMacros() []string {
+	ret := make([]string, 0, len(s.Macros))
+	for k := range s.Macros {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// knownFeatureMacros are probed on compilers that can not dump predefined
+// macros
+var knownFeatureMacros = []string{
+	"__cpp_aggregate_bases", "__cpp_aggregate_nsdmi", "__cpp_aggregate_paren_init",
+	"__cpp_alias_templates", "__cpp_aligned_new", "__cpp_attributes",
+	"__cpp_auto_cast", "__cpp_binary_literals", "__cpp_capture_star_this",
+	"__cpp_char8_t", "__cpp_concepts", "__cpp_conditional_explicit",
+	"__cpp_consteval", "__cpp_constexpr", "__cpp_constexpr_dynamic_alloc",
+	"__cpp_constexpr_in_decltype", "__cpp_constinit", "__cpp_decltype",
+	"__cpp_decltype_auto", "__cpp_deduction_guides", "__cpp_delegating_constructors",
+	"__cpp_designated_initializers", "__cpp_enumerator_attributes", "__cpp_explicit_this_parameter",
+	"__cpp_fold_expressions", "__cpp_generic_lambdas", "__cpp_guaranteed_copy_elision",
+	"__cpp_hex_float", "__cpp_if_consteval", "__cpp_if_constexpr",
+	"__cpp_impl_coroutine", "__cpp_impl_destroying_delete", "__cpp_impl_three_way_comparison",
+	"__cpp_inheriting_constructors", "__cpp_init_captures", "__cpp_initializer_lists",
+	"__cpp_inline_variables", "__cpp_lambdas", "__cpp_modules",
+	"__cpp_multidimensional_subscript", "__cpp_named_character_escapes", "__cpp_namespace_attributes",
+	"__cpp_noexcept_function_type", "__cpp_nontype_template_args", "__cpp_nontype_template_parameter_auto",
+	"__cpp_nsdmi", "__cpp_range_based_for", "__cpp_raw_strings",
+	"__cpp_ref_qualifiers", "__cpp_return_type_deduction", "__cpp_rvalue_references",
+	"__cpp_size_t_suffix", "__cpp_sized_deallocation", "__cpp_static_assert",
+	"__cpp_static_call_operator", "__cpp_structured_bindings", "__cpp_template_template_args",
+	"__cpp_threadsafe_static_init", "__cpp_unicode_characters", "__cpp_unicode_literals",
+	"__cpp_user_defined_literals", "__cpp_using_enum", "__cpp_variable_templates",
+	"__cpp_variadic_templates", "__cpp_variadic_using",
+
+	"__cpp_lib_any", "__cpp_lib_apply", "__cpp_lib_array_constexpr",
+	"__cpp_lib_assume_aligned", "__cpp_lib_atomic_ref", "__cpp_lib_atomic_wait",
+	"__cpp_lib_barrier", "__cpp_lib_bind_front", "__cpp_lib_bit_cast",
+	"__cpp_lib_bitops", "__cpp_lib_bounded_array_traits", "__cpp_lib_byteswap",
+	"__cpp_lib_char8_t", "__cpp_lib_chrono", "__cpp_lib_concepts",
+	"__cpp_lib_constexpr_algorithms", "__cpp_lib_constexpr_string", "__cpp_lib_constexpr_vector",
+	"__cpp_lib_coroutine", "__cpp_lib_endian", "__cpp_lib_execution",
+	"__cpp_lib_expected", "__cpp_lib_filesystem", "__cpp_lib_flat_map",
+	"__cpp_lib_flat_set", "__cpp_lib_format", "__cpp_lib_format_ranges",
+	"__cpp_lib_generator", "__cpp_lib_hardware_interference_size", "__cpp_lib_int_pow2",
+	"__cpp_lib_integer_comparison_functions", "__cpp_lib_is_constant_evaluated", "__cpp_lib_jthread",
+	"__cpp_lib_latch", "__cpp_lib_make_unique", "__cpp_lib_math_constants",
+	"__cpp_lib_mdspan", "__cpp_lib_memory_resource", "__cpp_lib_move_only_function",
+	"__cpp_lib_optional", "__cpp_lib_print", "__cpp_lib_ranges",
+	"__cpp_lib_ranges_to_container", "__cpp_lib_ranges_zip", "__cpp_lib_semaphore",
+	"__cpp_lib_shared_mutex", "__cpp_lib_source_location", "__cpp_lib_span",
+	"__cpp_lib_spanstream", "__cpp_lib_ssize", "__cpp_lib_stacktrace",
+	"__cpp_lib_starts_ends_with", "__cpp_lib_stdatomic_h", "__cpp_lib_string_view",
+	"__cpp_lib_syncbuf", "__cpp_lib_three_way_comparison", "__cpp_lib_to_chars",
+	"__cpp_lib_to_underlying", "__cpp_lib_unreachable", "__cpp_lib_variant",
+}
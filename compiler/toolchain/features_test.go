@@ -0,0 +1,224 @@
+package toolchain
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// excerpt of `g++ -std=c++20 -dM -E -x c++ -` with <version> included, gcc 12.2
+const gccDefines = `#define _GLIBCXX_HAVE_SYS_SDT_H 1
+#define _GLIBCXX_DEPRECATED_SUGGEST(ALT) __attribute__ ((__deprecated__ ("use '" ALT "' instead")))
+#define __cpp_lib_ranges 202110L
+#define _GLIBCXX_TXN_SAFE_DYN
+#define __cpp_char8_t 201811L
+#define __GNUC__ 12
+#define __cplusplus 202002L
+#define __GLIBCXX__ 20220819
+#define __GNUC_PREREQ(maj,min) ((__GNUC__ << 16) + __GNUC_MINOR__ >= ((maj) << 16) + (min))
+#define _PSTL_CPP14_MAKE_REVERSE_ITERATOR_PRESENT (_MSC_VER >= 1900 || __cplusplus >= 201402L || __cpp_lib_make_reverse_iterator == 201402)
+#define _GLIBCXX_RELEASE 12
+#define _GLIBCXX_USE_CXX11_ABI 1
+#define __cpp_concepts 202002L
+#define __cpp_lib_span 202002L
+#define __STDC__ 1
+`
+
+// excerpt of `clang++ -std=c++2b -stdlib=libc++ -dM -E -x c++ -`, clang 17
+const clangDefines = `#define _LIBCPP_VERSION 170006
+#define _LIBCPP_ABI_VERSION 1
+#define __clang_major__ 17
+#define __cplusplus 202302L
+#define __cpp_lib_expected 202211L
+#define __cpp_if_consteval 202106L
+#define __has_include(x) 0
+`
+
+func TestParseDefines(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want map[string]string
+	}{
+		{"gcc", gccDefines, map[string]string{
+			"__cpp_lib_ranges": "202110L",
+			"__cpp_char8_t":    "201811L",
+			"__cplusplus":      "202002L",
+			"__GLIBCXX__":      "20220819",
+			"_GLIBCXX_RELEASE": "12",
+			"__cpp_concepts":   "202002L",
+			"__cpp_lib_span":   "202002L",
+		}},
+		{"clang", clangDefines, map[string]string{
+			"_LIBCPP_VERSION":    "170006",
+			"__cplusplus":        "202302L",
+			"__cpp_lib_expected": "202211L",
+			"__cpp_if_consteval": "202106L",
+		}},
+		{"c", "#define __STDC_VERSION__ 201710L\r\n#define __STDC__ 1\r\n", map[string]string{
+			"__STDC_VERSION__": "201710L",
+		}},
+		{"empty", "", map[string]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseDefines(tt.out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDefines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepMacro(t *testing.T) {
+	tests := []struct {
+		name    string
+		keep    bool
+		feature bool
+	}{
+		{"__cplusplus", true, true},
+		{"__STDC_VERSION__", true, true},
+		{"__cpp_concepts", true, true},
+		{"__cpp_lib_format", true, true},
+		{"_GLIBCXX_RELEASE", true, false},
+		{"__GLIBCXX__", true, false},
+		{"_LIBCPP_VERSION", true, false},
+		{"_MSVC_STL_VERSION", true, false},
+		{"_MSVC_STL_UPDATE", true, false},
+		{"__STDC__", false, false},
+		{"__GNUC__", false, false},
+		{"_GLIBCXX_USE_CXX11_ABI", false, false},
+		{"__cpp", false, false},
+	}
+	for _, tt := range tests {
+		if got := keepMacro(tt.name); got != tt.keep {
+			t.Errorf("keepMacro(%q) = %v, want %v", tt.name, got, tt.keep)
+		}
+		if got := isFeatureMacro(tt.name); got != tt.feature {
+			t.Errorf("isFeatureMacro(%q) = %v, want %v", tt.name, got, tt.feature)
+		}
+	}
+}
+
+func TestDetectStdLib(t *testing.T) {
+	tests := []struct {
+		name string
+		m    map[string]string
+		want *StdLib
+	}{
+		{"libstdc++", parseDefines(gccDefines), &StdLib{Name: "libstdc++", Version: "12 (20220819)"}},
+		{"libstdc++ without release", map[string]string{"__GLIBCXX__": "20150623"}, &StdLib{Name: "libstdc++", Version: "20150623"}},
+		{"libstdc++ without date", map[string]string{"_GLIBCXX_RELEASE": "13"}, &StdLib{Name: "libstdc++", Version: "13"}},
+		{"libc++", parseDefines(clangDefines), &StdLib{Name: "libc++", Version: "17.0.6"}},
+		{"msvc-stl", map[string]string{"_MSVC_STL_VERSION": "143", "_MSVC_STL_UPDATE": "202408L"}, &StdLib{Name: "msvc-stl", Version: "143 (202408)"}},
+		{"msvc-stl without update", map[string]string{"_MSVC_STL_VERSION": "142"}, &StdLib{Name: "msvc-stl", Version: "142"}},
+		{"c only", map[string]string{"__STDC_VERSION__": "201710L"}, nil},
+		{"nil", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectStdLib(tt.m); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectStdLib() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLibcxxVersion(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"170006", "17.0.6"},
+		{"190100", "19.1.0"},
+		{"160000", "16.0.0"},
+		{"15007", "15.0.7"},
+		{"11000", "11.0.0"},
+		{"9000", "9.0.0"},
+		{"1101", "1.1.1"},
+		{"abc", "abc"},
+	}
+	for _, tt := range tests {
+		if got := libcxxVersion(tt.input); got != tt.want {
+			t.Errorf("libcxxVersion(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFeatures_Macro(t *testing.T) {
+	f := &Features{
+		C: []Standard{{Name: "c17", Flag: "-std=c17", Macros: map[string]string{"__STDC_VERSION__": "201710L"}}},
+		CXX: []Standard{
+			{Name: "c++17", Flag: "-std=c++17", Macros: map[string]string{"__cplusplus": "201703L", "__cpp_concepts": "201507L"}},
+			{Name: "c++20", Flag: "-std=c++20", Macros: map[string]string{"__cplusplus": "202002L", "__cpp_concepts": "202002L", "__cpp_lib_span": "202002L"}},
+			{Name: "c++23", Flag: "-std=c++2b", Macros: map[string]string{"__cplusplus": "202100L"}},
+		},
+	}
+	macros := []struct {
+		name string
+		want string
+	}{
+		{"__cplusplus", "202100L"},    // defined in the latest standard
+		{"__cpp_concepts", "202002L"}, // falls back to the latest standard that defines it
+		{"__cpp_lib_span", "202002L"},
+		{"__STDC_VERSION__", ""}, // C standards are not searched
+		{"__cpp_modules", ""},
+	}
+	for _, tt := range macros {
+		if got := f.Macro(tt.name); got != tt.want {
+			t.Errorf("Macro(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	for _, name := range []string{"c17", "c++17", "c++20", "c++23"} {
+		if !f.Supports(name) {
+			t.Errorf("Supports(%q) = false", name)
+		}
+	}
+	for _, name := range []string{"c11", "c++14", "c++26", ""} {
+		if f.Supports(name) {
+			t.Errorf("Supports(%q) = true", name)
+		}
+	}
+	if s := f.Get("c++23"); s == nil || s.Flag != "-std=c++2b" {
+		t.Errorf("Get(c++23) = %+v", s)
+	}
+}
+
+func TestParseProbedMacros(t *testing.T) {
+	// `g++ -std=c++20 -E -P` output for macroProbeSource, msvc /EP prints
+	// the same lines
+	out := `GO_BUILD_MACRO "__cplusplus" 202002L
+GO_BUILD_MACRO "_MSVC_STL_VERSION" _MSVC_STL_VERSION
+GO_BUILD_MACRO "_LIBCPP_VERSION" _LIBCPP_VERSION
+GO_BUILD_MACRO "__cpp_concepts" 202002L
+GO_BUILD_MACRO "__cpp_modules" __cpp_modules
+
+GO_BUILD_MACRO __cpp_lib_span 202002L
+`
+	want := map[string]string{"__cplusplus": "202002L", "__cpp_concepts": "202002L"}
+	if got := parseProbedMacros(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProbedMacros() = %v, want %v", got, want)
+	}
+}
+
+func TestMacroProbeSource(t *testing.T) {
+	cxx, err := exec.LookPath("g++")
+	if err != nil {
+		t.Skip(err)
+	}
+	names := []string{"__cplusplus", "__cpp_concepts", "_MSVC_STL_VERSION", "__cpp_lib_span"}
+	cmd := exec.Command(cxx, "-std=c++20", "-E", "-P", "-x", "c++", "-")
+	cmd.Stdin = strings.NewReader(macroProbeSource(names, true))
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := parseProbedMacros(string(out))
+	if m["__cplusplus"] != "202002L" || m["__cpp_concepts"] == "" {
+		t.Errorf("parseProbedMacros() = %v", m)
+	}
+	if _, ok := m["_MSVC_STL_VERSION"]; ok {
+		t.Errorf("undefined macro was recorded: %v", m)
+	}
+}
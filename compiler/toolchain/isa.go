@@ -0,0 +1,303 @@
+package toolchain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// ISA describes the instruction set options accepted by a toolchain
+type ISA struct {
+	Default string     `json:"default,omitempty" yaml:"default,omitempty"` // -march used when none is specified
+	Arches  []string   `json:"arches,omitempty" yaml:"arches,omitempty"`   // accepted -march values (/arch: for msvc)
+	CPUs    []string   `json:"cpus,omitempty" yaml:"cpus,omitempty"`       // accepted -mcpu values
+	Tunes   []string   `json:"tunes,omitempty" yaml:"tunes,omitempty"`     // accepted -mtune values
+	Native  *NativeISA `json:"native,omitempty" yaml:"native,omitempty"`   // what -march=native resolves to on this host
+}
+
+// NativeISA is the expansion of -march=native (-mcpu=native for arm
+// targets on clang)
+type NativeISA struct {
+	Arch     string   `json:"arch,omitempty" yaml:"arch,omitempty"`
+	CPU      string   `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	Tune     string   `json:"tune,omitempty" yaml:"tune,omitempty"`
+	Features []string `json:"features,omitempty" yaml:"features,omitempty"` // target features enabled in addition to the default -march
+}
+
+// MSVCArches lists /arch: values accepted by cl.exe for each target
+// architecture, from least to most capable
+var MSVCArches = map[string][]string{
+	"x32":   {"IA32", "SSE", "SSE2", "AVX", "AVX2", "AVX512", "AVX10.1"},
+	"x64":   {"AVX", "AVX2", "AVX512", "AVX10.1"},
+	"arm":   {"ARMv7VE", "VFPv4"},
+	"arm64": {"armv8.0", "armv8.1", "armv8.2", "armv8.3", "armv8.4", "armv8.5", "armv8.6", "armv8.7", "armv8.8"},
+}
+
+// msvcLevels maps portable ISA levels to /arch: values
+var msvcLevels = map[string]string{
+	"x86-64-v3": "AVX2",
+	"x86-64-v4": "AVX512",
+}
+
+// SupportsArch checks if the value is accepted by -march (/arch: for msvc)
+func (isa *ISA) SupportsArch(s string) bool {
+	return slices.Contains(isa.Arches, s)
+}
+
+// SupportsCPU checks if the value is accepted by -mcpu
+func (isa *ISA) SupportsCPU(s string) bool {
+	return slices.Contains(isa.CPUs, s)
+}
+
+// ProbeISA queries the compiler for supported -march/-mcpu/-mtune values and
+// resolves -march=native for the current host. Msvc has no way of listing
+// /arch: values, they are taken from the MSVCArches table.
+func (tc *Chain) ProbeISA() (*ISA, error) {
+	switch {
+	case tc.IsMSVC():
+		aa, ok := MSVCArches[tc.Target.Arch]
+		if !ok {
+			return nil, fmt.Errorf("unsupported msvc architecture '%s'", tc.Target.Arch)
+		}
+		return &ISA{Arches: slices.Clone(aa)}, nil
+	case tc.Compiler == "clang":
+		return tc.probeClangISA()
+	default:
+		return tc.probeGnuISA()
+	}
+}
+
+// ISAFlags returns compiler flags for building with the specified ISA level,
+// e.g. x86-64-v3, armv8.2-a, or a cpu name. Levels are validated against the
+// probed ISA when it is available.
+func (tc *Chain) ISAFlags(level string) ([]string, error) {
+	if level == "" {
+		return nil, nil
+	}
+	if tc.IsMSVC() {
+		if level == "native" {
+			return nil, errors.New("msvc does not support native ISA detection")
+		}
+		v := level
+		if m, ok := msvcLevels[level]; ok {
+			v = m
+		} else if strings.HasPrefix(level, "armv8.") {
+			v = strings.TrimSuffix(level, "-a")
+		}
+		if !slices.Contains(MSVCArches[tc.Target.Arch], v) {
+			return nil, fmt.Errorf("ISA level '%s' is not supported by msvc for %s", level, tc.Target.Arch)
+		}
+		return []string{"/arch:" + v}, nil
+	}
+	if level == "native" {
+		if tc.Compiler == "clang" && isARM(tc.Target.Arch) {
+			return []string{"-mcpu=native"}, nil
+		}
+		return []string{"-march=native"}, nil
+	}
+	isa := tc.ISA
+	if isa == nil || (len(isa.Arches) == 0 && len(isa.CPUs) == 0) {
+		return []string{"-march=" + level}, nil
+	}
+	base, _, _ := strings.Cut(level, "+")
+	switch {
+	case isa.SupportsArch(base):
+		return []string{"-march=" + level}, nil
+	case isa.SupportsCPU(base):
+		return []string{"-mcpu=" + level}, nil
+	case len(isa.Arches) == 0 && isARM(tc.Target.Arch) && strings.HasPrefix(base, "armv"):
+		// arm -march values are not listed by the compilers
+		return []string{"-march=" + level}, nil
+	}
+	return nil, fmt.Errorf("ISA level '%s' is not supported by %s %s", level, tc.Compiler, tc.Version)
+}
+
+func isARM(arch string) bool {
+	return arch == "arm" || arch == "arm64"
+}
+
+var (
+	gnuKnownValues = regexp.MustCompile(`^\s*Known valid arguments for (-m[a-z0-9-]+)= option:`)
+	gnuOption      = regexp.MustCompile(`^\s+(-m[a-zA-Z0-9_.=-]+)\s+(\S*)\s*$`)
+)
+
+// gnuTargetHelp runs gcc -Q --help=target and returns option values and the
+// lists of valid arguments
+func (tc *Chain) gnuTargetHelp(args ...string) (opts map[string]string, known map[string][]string, err error) {
+	cmd, err := tc.Command(CCompiler, append(args, "-Q", "--help=target")...)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, nil, err
+	}
+	opts, known = parseGnuTargetHelp(string(out))
+	return opts, known, nil
+}
+
+// parseGnuTargetHelp parses the output of gcc -Q --help=target
+func parseGnuTargetHelp(out string) (opts map[string]string, known map[string][]string) {
+	opts = map[string]string{}
+	known = map[string][]string{}
+	list := ""
+	for _, line := range strings.Split(out, "\n") {
+		if m := gnuKnownValues.FindStringSubmatch(line); m != nil {
+			list = m[1]
+			continue
+		}
+		if list != "" {
+			if strings.TrimSpace(line) == "" {
+				list = ""
+				continue
+			}
+			known[list] = append(known[list], strings.Fields(line)...)
+			continue
+		}
+		if m := gnuOption.FindStringSubmatch(line); m != nil {
+			opts[m[1]] = m[2]
+		}
+	}
+	return opts, known
+}
+
+func (tc *Chain) probeGnuISA() (*ISA, error) {
+	opts, known, err := tc.gnuTargetHelp()
+	if err != nil {
+		return nil, err
+	}
+	isa := &ISA{
+		Default: opts["-march="],
+		Arches:  withoutNative(known["-march"]),
+		CPUs:    withoutNative(known["-mcpu"]),
+		Tunes:   withoutNative(known["-mtune"]),
+	}
+	if nopts, _, err := tc.gnuTargetHelp("-march=native"); err == nil {
+		isa.Native = gnuNativeISA(opts, nopts)
+	}
+	return isa, nil
+}
+
+// gnuNativeISA compares the option values for -march=native with the
+// default ones
+func gnuNativeISA(opts, nopts map[string]string) *NativeISA {
+	n := &NativeISA{
+		Arch: nopts["-march="],
+		CPU:  nopts["-mcpu="],
+		Tune: nopts["-mtune="],
+	}
+	// features enabled by -march=native and not by the default -march
+	for k, v := range nopts {
+		if v == "[enabled]" && opts[k] != "[enabled]" {
+			n.Features = append(n.Features, strings.TrimPrefix(k, "-m"))
+		}
+	}
+	// aarch64 spells out the extensions in the -march value
+	if base, ext, ok := strings.Cut(n.Arch, "+"); ok {
+		n.Arch = base
+		n.Features = append(n.Features, strings.Split(ext, "+")...)
+	}
+	sort.Strings(n.Features)
+	return n
+}
+
+func (tc *Chain) probeClangISA() (*ISA, error) {
+	args := []string{"--print-supported-cpus"}
+	if tc.Target.Original != "" {
+		args = append(args, "--target="+tc.Target.Original)
+	}
+	cmd, err := tc.Command(CCompiler, args...)
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	isa := &ISA{CPUs: parseClangCPUs(string(out))}
+	if len(isa.CPUs) == 0 {
+		return nil, errors.New("failed to obtain the list of supported cpus")
+	}
+	isa.Tunes = isa.CPUs
+	if !isARM(tc.Target.Arch) {
+		// x86 -march accepts cpu names and x86-64 levels
+		isa.Arches = isa.CPUs
+	}
+
+	flag := "-march=native"
+	if isARM(tc.Target.Arch) {
+		flag = "-mcpu=native"
+	}
+	if cmd, err = tc.Command(CCompiler, flag, "-###", "-c", "-x", "c", "-"); err == nil {
+		if out, err := cmd.CombinedOutput(); err == nil {
+			isa.Native = parseClangDriverISA(string(out))
+		}
+	}
+	return isa, nil
+}
+
+// parseClangCPUs extracts the cpu names from --print-supported-cpus output
+func parseClangCPUs(s string) []string {
+	ret := []string{}
+	listing := false
+	for _, line := range strings.Split(s, "\n") {
+		switch {
+		case strings.HasPrefix(line, "Available CPUs"):
+			listing = true
+		case !listing:
+		case strings.HasPrefix(line, "Use -mcpu"):
+			listing = false
+		case strings.TrimSpace(line) != "":
+			ret = append(ret, strings.TrimSpace(line))
+		}
+	}
+	return ret
+}
+
+// parseClangDriverISA extracts -target-cpu and enabled -target-feature values
+// from the -### output
+func parseClangDriverISA(s string) *NativeISA {
+	n := &NativeISA{}
+	for _, line := range strings.Split(s, "\n") {
+		if !strings.Contains(line, "-cc1") {
+			continue
+		}
+		args := strings.Fields(line)
+		for i := 0; i+1 < len(args); i++ {
+			v := strings.Trim(args[i+1], `"`)
+			switch strings.Trim(args[i], `"`) {
+			case "-target-cpu":
+				n.CPU = v
+			case "-tune-cpu":
+				n.Tune = v
+			case "-target-feature":
+				if strings.HasPrefix(v, "+") {
+					n.Features = append(n.Features, v[1:])
+				}
+			}
+		}
+	}
+	if n.CPU == "" {
+		return nil
+	}
+	n.Arch = n.CPU
+	sort.Strings(n.Features)
+	return n
+}
+
+func withoutNative(ss []string) []string {
+	ret := []string{}
+	for _, s := range ss {
+		if s != "native" {
+			ret = append(ret, s)
+		}
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
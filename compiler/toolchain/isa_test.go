@@ -0,0 +1,227 @@
+package toolchain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseClangCPUs(t *testing.T) {
+	out := `clang version 17.0.6
+Target: x86_64-pc-linux-gnu
+Thread model: posix
+Available CPUs for this target:
+
+	alderlake
+	x86-64
+	x86-64-v3
+
+Use -mcpu or -mtune to specify the target's processor.
+For example, clang --target=aarch64-unknown-linux-gnu -mcpu=cortex-a35
+`
+	got := parseClangCPUs(out)
+	want := []string{"alderlake", "x86-64", "x86-64-v3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseClangCPUs() = %v, want %v", got, want)
+	}
+}
+
+func TestParseClangDriverISA(t *testing.T) {
+	out := `clang version 17.0.6
+ "/usr/lib/llvm-17/bin/clang" "-cc1" "-triple" "x86_64-pc-linux-gnu" "-target-cpu" "znver3" "-target-feature" "+avx2" "-target-feature" "-avx512f" "-target-feature" "+bmi2" "-tune-cpu" "znver3" "-x" "c" "-"
+`
+	n := parseClangDriverISA(out)
+	if n == nil {
+		t.Fatal("parseClangDriverISA() returned nil")
+	}
+	if n.CPU != "znver3" || n.Tune != "znver3" || n.Arch != "znver3" {
+		t.Errorf("parseClangDriverISA() cpu = %s, tune = %s, arch = %s", n.CPU, n.Tune, n.Arch)
+	}
+	if got := strings.Join(n.Features, " "); got != "avx2 bmi2" {
+		t.Errorf("parseClangDriverISA() features = %s, want avx2 bmi2", got)
+	}
+}
+
+func TestChain_ISAFlags(t *testing.T) {
+	gnu := &Chain{Compiler: "gcc", ISA: &ISA{Arches: []string{"x86-64", "x86-64-v3"}}}
+	gnu.Target.Arch = "x64"
+	msvc := &Chain{Compiler: "msvc"}
+	msvc.Target.Arch = "x64"
+	arm := &Chain{Compiler: "msvc"}
+	arm.Target.Arch = "arm64"
+
+	tests := []struct {
+		tc    *Chain
+		level string
+		want  string
+		err   bool
+	}{
+		{gnu, "x86-64-v3", "-march=x86-64-v3", false},
+		{gnu, "x86-64-v4", "", true},
+		{gnu, "native", "-march=native", false},
+		{msvc, "x86-64-v3", "/arch:AVX2", false},
+		{msvc, "AVX512", "/arch:AVX512", false},
+		{msvc, "native", "", true},
+		{arm, "armv8.2-a", "/arch:armv8.2", false},
+	}
+	for _, tt := range tests {
+		got, err := tt.tc.ISAFlags(tt.level)
+		if (err != nil) != tt.err {
+			t.Errorf("%s ISAFlags(%s) error = %v", tt.tc.Compiler, tt.level, err)
+			continue
+		}
+		if s := strings.Join(got, " "); s != tt.want {
+			t.Errorf("%s ISAFlags(%s) = %s, want %s", tt.tc.Compiler, tt.level, s, tt.want)
+		}
+	}
+}
+
+// excerpts of gcc 12.2 -Q --help=target output, the values are tab separated
+const (
+	gccTargetHelpX64 = `The following options are target specific:
+  -m128bit-long-double        		[enabled]
+  -m64                        		[enabled]
+  -mabi=                      		sysv
+  -march=                     		x86-64
+  -mavx                       		[disabled]
+  -mavx2                      		[disabled]
+  -mbmi2                      		[disabled]
+  -mcmodel=                   		[default]
+  -mfpmath=                   		sse
+  -msse2                      		[enabled]
+  -mtune=                     		generic
+
+  Known assembler dialects (for use with the -masm= option):
+    att intel
+
+  Known valid arguments for -mstack-protector-guard-reg= option:
+    fs gs
+
+  Valid arguments to -mstringop-strategy=:
+    byte_loop libcall loop rep_4byte rep_8byte rep_byte unrolled_loop
+    vector_loop
+
+  Known valid arguments for -march= option:
+    i386 i486 x86-64 x86-64-v2 x86-64-v3 x86-64-v4 znver3 btver1 btver2 generic native
+
+  Known valid arguments for -mtune= option:
+    generic i386 haswell znver3
+
+`
+	gccTargetHelpX64Native = `The following options are target specific:
+  -m128bit-long-double        		[enabled]
+  -m64                        		[enabled]
+  -march=                     		znver3
+  -mavx                       		[enabled]
+  -mavx2                      		[enabled]
+  -mbmi2                      		[enabled]
+  -msse2                      		[enabled]
+  -mtune=                     		znver3
+`
+	gccTargetHelpA64 = `The following options are target specific:
+  -mabi=                      		lp64
+  -march=                     		armv8-a
+  -mbig-endian                		[disabled]
+  -mbranch-protection=        		
+  -mcmodel=                   		small
+  -mcpu=                      		generic
+  -mfix-cortex-a53-835769     		[enabled]
+  -mgeneral-regs-only         		[disabled]
+  -mlittle-endian             		[enabled]
+  -moutline-atomics           		[enabled]
+  -mtls-dialect=              		desc
+  -mtune=                     		generic
+
+  Known valid arguments for -mabi= option:
+    ilp32 lp64
+
+  Known valid arguments for -mcmodel= option:
+    large small tiny
+
+  Known valid arguments for -mtls-dialect= option:
+    desc trad
+
+`
+	gccTargetHelpA64Native = `The following options are target specific:
+  -mabi=                      		lp64
+  -march=                     		armv8.2-a+crypto+fp16+rcpc+dotprod
+  -mbig-endian                		[disabled]
+  -mbranch-protection=        		
+  -mcmodel=                   		small
+  -mcpu=                      		
+  -mfix-cortex-a53-835769     		[enabled]
+  -mgeneral-regs-only         		[disabled]
+  -mlittle-endian             		[enabled]
+  -moutline-atomics           		[enabled]
+  -mtls-dialect=              		desc
+  -mtune=                     		
+
+  Known valid arguments for -mabi= option:
+    ilp32 lp64
+
+  Known valid arguments for -mcmodel= option:
+    large small tiny
+
+  Known valid arguments for -mtls-dialect= option:
+    desc trad
+
+`
+)
+
+func TestParseGnuTargetHelp(t *testing.T) {
+	opts, known := parseGnuTargetHelp(gccTargetHelpX64)
+	for k, want := range map[string]string{"-march=": "x86-64", "-mtune=": "generic", "-mabi=": "sysv", "-mavx2": "[disabled]", "-msse2": "[enabled]", "-mcmodel=": "[default]"} {
+		if opts[k] != want {
+			t.Errorf("x86_64 %s = %q, want %q", k, opts[k], want)
+		}
+	}
+	if got := strings.Join(known["-march"], " "); got != "i386 i486 x86-64 x86-64-v2 x86-64-v3 x86-64-v4 znver3 btver1 btver2 generic native" {
+		t.Errorf("x86_64 -march values = %s", got)
+	}
+	if got := strings.Join(known["-mtune"], " "); got != "generic i386 haswell znver3" {
+		t.Errorf("x86_64 -mtune values = %s", got)
+	}
+	if got := strings.Join(known["-mstack-protector-guard-reg"], " "); got != "fs gs" {
+		t.Errorf("x86_64 -mstack-protector-guard-reg values = %s", got)
+	}
+	// lists in other formats are not confused with options
+	for _, k := range []string{"att", "vector_loop", "-mstringop-strategy="} {
+		if _, ok := opts[k]; ok {
+			t.Errorf("x86_64 unexpected option %s", k)
+		}
+	}
+
+	opts, known = parseGnuTargetHelp(gccTargetHelpA64)
+	for k, want := range map[string]string{"-march=": "armv8-a", "-mcpu=": "generic", "-mbranch-protection=": "", "-moutline-atomics": "[enabled]"} {
+		if v, ok := opts[k]; !ok || v != want {
+			t.Errorf("aarch64 %s = %q, want %q", k, v, want)
+		}
+	}
+	// aarch64 does not list -march and -mcpu values
+	if len(known["-march"]) != 0 || len(known["-mcpu"]) != 0 {
+		t.Errorf("aarch64 -march values = %v, -mcpu values = %v", known["-march"], known["-mcpu"])
+	}
+	if got := strings.Join(known["-mtls-dialect"], " "); got != "desc trad" {
+		t.Errorf("aarch64 -mtls-dialect values = %s", got)
+	}
+}
+
+func TestGnuNativeISA(t *testing.T) {
+	tests := []struct {
+		name        string
+		out, native string
+		want        NativeISA
+	}{
+		{"x86_64", gccTargetHelpX64, gccTargetHelpX64Native, NativeISA{Arch: "znver3", Tune: "znver3", Features: []string{"avx", "avx2", "bmi2"}}},
+		{"aarch64", gccTargetHelpA64, gccTargetHelpA64Native, NativeISA{Arch: "armv8.2-a", Features: []string{"crypto", "dotprod", "fp16", "rcpc"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, _ := parseGnuTargetHelp(tt.out)
+			nopts, _ := parseGnuTargetHelp(tt.native)
+			if got := gnuNativeISA(opts, nopts); !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("gnuNativeISA() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
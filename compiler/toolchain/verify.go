@@ -0,0 +1,293 @@
+package toolchain
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/adnsv/go-build/host"
+)
+
+// Step is the outcome of a single toolchain verification step
+type Step struct {
+	Name     string        `json:"name" yaml:"name"`
+	Command  []string      `json:"command,omitempty" yaml:"command,omitempty,flow"`
+	Passed   bool          `json:"passed" yaml:"passed"`
+	Skipped  bool          `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	Output   string        `json:"output,omitempty" yaml:"output,omitempty"` // captured diagnostics
+	Error    string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+}
+
+// Report is the outcome of toolchain verification
+type Report struct {
+	Steps []*Step  `json:"steps" yaml:"steps"`
+	Hints []string `json:"hints,omitempty" yaml:"hints,omitempty"` // likely causes of failures
+}
+
+// Passed returns true if none of the steps failed
+func (r *Report) Passed() bool {
+	for _, s := range r.Steps {
+		if !s.Passed && !s.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// Step returns the step with the specified name
+func (r *Report) Step(name string) *Step {
+	for _, s := range r.Steps {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Verification step names
+const (
+	StepCompileC    = "compile-c"
+	StepCompileCXX  = "compile-c++"
+	StepArchive     = "archive"
+	StepExecutableC = "link-executable-c"
+	StepExecutable  = "link-executable"
+	StepShared      = "link-shared"
+	StepTarget      = "check-target"
+	StepRunC        = "run-c"
+	StepRun         = "run"
+)
+
+const (
+	verifyLibSource = `int answer(void) { return 42; }
+`
+	verifyCSource = `#include <stdio.h>
+int answer(void);
+int main(void) { printf("hello from c %d\n", answer()); return 0; }
+`
+	verifyCXXSource = `#include <iostream>
+#include <string>
+extern "C" int answer(void);
+int main() { std::string s = "hello from c++"; std::cout << s << " " << answer() << std::endl; return 0; }
+`
+	verifyExpectedC = "hello from c 42"
+	verifyExpected  = "hello from c++ 42"
+)
+
+// Verify checks that the toolchain actually works by compiling C and C++
+// sources, archiving a static library, linking C and C++ executables and a
+// shared library, checking that the C++ executable was built for the
+// toolchain target, and running both executables when the target is native
+// to the host. Steps that depend on a failed step are skipped.
+func Verify(tc *Chain) *Report {
+	r := &Report{}
+	tmpdir, err := os.MkdirTemp("", "go-build-verify")
+	if err != nil {
+		r.Steps = append(r.Steps, &Step{Name: StepCompileC, Error: err.Error()})
+		return r
+	}
+	defer os.RemoveAll(tmpdir)
+
+	r.Hints = tc.preflightHints()
+
+	for fn, src := range map[string]string{"answer.c": verifyLibSource, "hello.c": verifyCSource, "hello.cpp": verifyCXXSource} {
+		if err := os.WriteFile(filepath.Join(tmpdir, fn), []byte(src), 0666); err != nil {
+			r.Steps = append(r.Steps, &Step{Name: StepCompileC, Error: err.Error()})
+			return r
+		}
+	}
+
+	x := verifier{tc: tc, dir: tmpdir, report: r}
+	t := tc.Target.Target
+	if tc.IsMSVC() {
+		t.Environment = "msvc"
+	}
+	obj := t.Naming().ObjectSuffix
+	lib, dll := t.StaticLibrary("answer"), t.SharedLibrary("answer", "")
+	exeC, exe := t.Executable("hello_c"), t.Executable("hello")
+
+	var cc, cxx, archive, linkC, link *Step
+	if tc.IsMSVC() {
+		cc = x.run(StepCompileC, CCompiler, nil, "/nologo", "/c", "answer.c", "hello.c")
+		cxx = x.run(StepCompileCXX, CXXCompiler, nil, "/nologo", "/EHsc", "/c", "hello.cpp", "/Fohello_cpp.obj")
+		archive = x.run(StepArchive, Archiver, []*Step{cc}, "/nologo", "/OUT:"+lib, "answer"+obj)
+		linkC = x.run(StepExecutableC, CCompiler, []*Step{cc, archive}, "/nologo", "hello"+obj, lib, "/Fe:"+exeC)
+		link = x.run(StepExecutable, CXXCompiler, []*Step{cxx, archive}, "/nologo", "hello_cpp.obj", lib, "/Fe:"+exe)
+		x.run(StepShared, CCompiler, []*Step{cc}, "/nologo", "/LD", "answer.c", "/Fe:"+dll)
+	} else {
+		args := []string{"-c", "answer.c", "hello.c"}
+		if t.ObjectFormat == triplet.ELF {
+			// position independent code is needed for the shared library
+			args = append(args, "-fPIC")
+		}
+		cc = x.run(StepCompileC, CCompiler, nil, args...)
+		cxx = x.run(StepCompileCXX, CXXCompiler, nil, "-c", "hello.cpp", "-o", "hello_cpp"+obj)
+		archive = x.run(StepArchive, Archiver, []*Step{cc}, "rcs", lib, "answer"+obj)
+		linkC = x.run(StepExecutableC, CCompiler, []*Step{cc, archive}, "hello"+obj, lib, "-o", exeC)
+		link = x.run(StepExecutable, CXXCompiler, []*Step{cxx, archive}, "hello_cpp"+obj, lib, "-o", exe)
+		x.run(StepShared, CCompiler, []*Step{cc}, "-shared", "answer"+obj, "-o", dll)
+	}
+
+	check := &Step{Name: StepTarget}
+	r.Steps = append(r.Steps, check)
+	if link.Passed {
+		start := time.Now()
+		info, err := tc.VerifyArtifact(filepath.Join(tmpdir, exe))
+		check.Duration = time.Since(start)
+		if err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Passed = true
+			check.Output = info.Target.Text()
+		}
+	} else {
+		check.Skipped = true
+		check.Error = "executable was not built"
+	}
+
+	x.execute(StepRunC, linkC, exeC, verifyExpectedC)
+	x.execute(StepRun, link, exe, verifyExpected)
+
+	r.Hints = append(r.Hints, r.failureHints(tc)...)
+	return r
+}
+
+type verifier struct {
+	tc     *Chain
+	dir    string
+	report *Report
+}
+
+// run executes a tool in the work directory, the step is skipped if any of
+// its dependencies failed
+func (x *verifier) run(name string, tool Tool, deps []*Step, args ...string) *Step {
+	s := &Step{Name: name}
+	x.report.Steps = append(x.report.Steps, s)
+	for _, d := range deps {
+		if !d.Passed {
+			s.Skipped = true
+			s.Error = fmt.Sprintf("%s did not pass", d.Name)
+			return s
+		}
+	}
+	cmd, err := x.tc.Command(tool, args...)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	cmd.Dir = x.dir
+	s.Command = cmd.Args
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	s.Duration = time.Since(start)
+	s.Output = string(out)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.Passed = true
+	return s
+}
+
+// execute runs an executable built by the link step and compares its output
+// with the expected one, the step is skipped if the executable was not built
+// or can not run on the host
+func (x *verifier) execute(name string, link *Step, exe string, expected string) *Step {
+	s := &Step{Name: name}
+	x.report.Steps = append(x.report.Steps, s)
+	switch {
+	case !link.Passed:
+		s.Skipped = true
+		s.Error = "executable was not built"
+	case !host.Current().CanRun(x.tc.Target.Target):
+		s.Skipped = true
+		s.Error = fmt.Sprintf("%s binaries can not run on this host", x.tc.Target.Original)
+	default:
+		start := time.Now()
+		cmd := exec.Command(filepath.Join(x.dir, exe))
+		cmd.Dir = x.dir
+		cmd.Env = x.tc.Env()
+		s.Command = []string{exe}
+		out, err := cmd.CombinedOutput()
+		s.Duration = time.Since(start)
+		s.Output = string(out)
+		switch {
+		case err != nil:
+			s.Error = err.Error()
+		case strings.TrimSpace(string(out)) != expected:
+			s.Error = fmt.Sprintf("unexpected output, want '%s'", expected)
+		default:
+			s.Passed = true
+		}
+	}
+	return s
+}
+
+// preflightHints flags missing tools and sysroot before running anything
+func (tc *Chain) preflightHints() []string {
+	hints := []string{}
+	if tc.IsMSVC() {
+		return hints
+	}
+	for _, t := range []Tool{CCompiler, CXXCompiler, Archiver} {
+		if !tc.Tools.Contains(t) {
+			hints = append(hints, fmt.Sprintf("%s was not found next to the compiler", t.LongName()))
+		}
+	}
+	if tc.Sysroot != "" {
+		if _, err := os.Stat(tc.Sysroot); err != nil {
+			hints = append(hints, fmt.Sprintf("sysroot '%s' does not exist", tc.Sysroot))
+		}
+	}
+	return hints
+}
+
+// failureHints derives likely causes from the captured diagnostics
+func (r *Report) failureHints(tc *Chain) []string {
+	hints := []string{}
+	failed := func(step string) string {
+		if s := r.Step(step); s != nil && !s.Passed {
+			return s.Output
+		}
+		return ""
+	}
+	has := func(step string, ss ...string) bool {
+		out := failed(step)
+		for _, v := range ss {
+			if strings.Contains(out, v) {
+				return true
+			}
+		}
+		return false
+	}
+	if has(StepCompileC, "stdio.h") {
+		if tc.Sysroot == "" && !tc.IsMSVC() && !host.Current().CanRun(tc.Target.Target) {
+			hints = append(hints, fmt.Sprintf("cross toolchain for %s has no sysroot configured", tc.Target.Original))
+		}
+		hints = append(hints, "C library headers are missing (sysroot or libc development package)")
+	}
+	if has(StepCompileCXX, "iostream", "string:") {
+		hints = append(hints, "C++ standard library headers are missing (libstdc++/libc++ development package)")
+	}
+	if has(StepExecutable, "-lstdc++", "libstdc++") {
+		hints = append(hints, "libstdc++ is not installed for the target")
+	}
+	if has(StepExecutable, "-lc++", "libc++") {
+		hints = append(hints, "libc++ is not installed for the target")
+	}
+	for _, step := range []string{StepExecutableC, StepExecutable} {
+		if has(step, "crt1.o", "crti.o") || missingLibC.MatchString(failed(step)) {
+			hints = append(hints, "C runtime startup files are missing (sysroot or libc development package)")
+			break
+		}
+	}
+	return hints
+}
+
+// missingLibC matches the linker errors for -lc, but not for -lc++
+var missingLibC = regexp.MustCompile(`(?m)(cannot find|unable to find library) -lc(:|\s|$)`)
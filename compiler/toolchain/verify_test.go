@@ -0,0 +1,181 @@
+package toolchain
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/adnsv/go-build/internal/testutil"
+)
+
+func TestVerify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses gcc from PATH")
+	}
+	tools := Toolset{}
+	for tool, name := range map[Tool]string{CCompiler: "gcc", CXXCompiler: "g++", Archiver: "gcc-ar"} {
+		fn, err := exec.LookPath(name)
+		if err != nil {
+			t.Skip(err)
+		}
+		tools[tool] = ToolPath(fn)
+	}
+	target, err := triplet.FromGo(runtime.GOOS, runtime.GOARCH, "", "")
+	if err != nil {
+		t.Skip(err)
+	}
+	tc := &Chain{Compiler: "gcc", Target: triplet.Full{Target: target, Original: target.Text()}, Tools: tools}
+
+	r := Verify(tc)
+	for _, s := range r.Steps {
+		if !s.Passed && !s.Skipped {
+			t.Errorf("%s: %s\n%s", s.Name, s.Error, s.Output)
+		}
+	}
+	want := []string{StepCompileC, StepCompileCXX, StepArchive, StepExecutableC, StepExecutable, StepShared, StepTarget, StepRunC, StepRun}
+	got := []string{}
+	for _, s := range r.Steps {
+		got = append(got, s.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("steps = %v, want %v", got, want)
+	}
+	if s := r.Step(StepRunC); s != nil && s.Passed && s.Output != verifyExpectedC+"\n" {
+		t.Errorf("%s output = %q", StepRunC, s.Output)
+	}
+	if len(r.Hints) != 0 {
+		t.Errorf("Hints = %v", r.Hints)
+	}
+
+	// the executables depend on the archive
+	tc.Tools = Toolset{CCompiler: tools[CCompiler], CXXCompiler: tools[CXXCompiler], Archiver: ToolPath(filepath.Join(t.TempDir(), "missing-ar"))}
+	r = Verify(tc)
+	for _, name := range []string{StepExecutableC, StepExecutable, StepTarget, StepRunC, StepRun} {
+		if s := r.Step(name); !s.Skipped {
+			t.Errorf("%s was not skipped: %+v", name, s)
+		}
+	}
+	if s := r.Step(StepShared); !s.Passed {
+		t.Errorf("%s: %s\n%s", s.Name, s.Error, s.Output)
+	}
+	if r.Passed() {
+		t.Errorf("Passed() = true with a broken archiver")
+	}
+}
+
+func TestVerify_PIC(t *testing.T) {
+	dir := testutil.Scripts(t, map[string]string{"cc": "#!/bin/sh\nexit 1\n"})
+	for triple, want := range map[string]bool{"x86_64-linux-gnu": true, "x86_64-w64-mingw32": false, "arm64-apple-darwin": false} {
+		target, err := triplet.ParseFull(triple)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tc := &Chain{Compiler: "gcc", Target: target, Tools: Toolset{CCompiler: ToolPath(filepath.Join(dir, "cc"))}}
+		s := Verify(tc).Step(StepCompileC)
+		if got := slices.Contains(s.Command, "-fPIC"); got != want {
+			t.Errorf("%s: %s command = %q", triple, StepCompileC, s.Command)
+		}
+	}
+}
+
+func TestPreflightHints(t *testing.T) {
+	tests := []struct {
+		name string
+		tc   *Chain
+		want []string
+	}{
+		{"complete", &Chain{Compiler: "gcc", Tools: Toolset{CCompiler: "gcc", CXXCompiler: "g++", Archiver: "ar"}}, []string{}},
+		{"no archiver", &Chain{Compiler: "gcc", Tools: Toolset{CCompiler: "gcc", CXXCompiler: "g++"}},
+			[]string{"Archiver was not found next to the compiler"}},
+		{"c only", &Chain{Compiler: "clang", Tools: Toolset{CCompiler: "clang", Archiver: "llvm-ar"}},
+			[]string{"C++ Compiler was not found next to the compiler"}},
+		{"no sysroot", &Chain{Compiler: "gcc", Sysroot: "/nonexistent/sysroot", Tools: Toolset{CCompiler: "gcc", CXXCompiler: "g++", Archiver: "ar"}},
+			[]string{"sysroot '/nonexistent/sysroot' does not exist"}},
+		{"msvc", &Chain{Compiler: "msvc"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tc.preflightHints(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("preflightHints() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReport_failureHints(t *testing.T) {
+	cross, err := triplet.ParseFull("mips-linux-gnu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcc := &Chain{Compiler: "gcc", Target: cross}
+
+	const (
+		hintSysroot = "cross toolchain for mips-linux-gnu has no sysroot configured"
+		hintLibC    = "C library headers are missing (sysroot or libc development package)"
+		hintCXX     = "C++ standard library headers are missing (libstdc++/libc++ development package)"
+		hintStdCXX  = "libstdc++ is not installed for the target"
+		hintLibCXX  = "libc++ is not installed for the target"
+		hintCRT     = "C runtime startup files are missing (sysroot or libc development package)"
+	)
+	tests := []struct {
+		name   string
+		tc     *Chain
+		step   string
+		output string
+		want   []string
+	}{
+		{"gcc without libc headers", gcc, StepCompileC,
+			"hello.c:1:10: fatal error: stdio.h: No such file or directory\n    1 | #include <stdio.h>\n      |          ^~~~~~~~~\ncompilation terminated.\n",
+			[]string{hintSysroot, hintLibC}},
+		{"clang without libc headers", &Chain{Compiler: "clang", Target: cross, Sysroot: "/opt/mips"}, StepCompileC,
+			"hello.c:1:10: fatal error: 'stdio.h' file not found\n#include <stdio.h>\n         ^~~~~~~~~\n1 error generated.\n",
+			[]string{hintLibC}},
+		{"clang without c++ headers", gcc, StepCompileCXX,
+			"hello.cpp:1:10: fatal error: 'iostream' file not found\n#include <iostream>\n         ^~~~~~~~~~\n1 error generated.\n",
+			[]string{hintCXX}},
+		{"gcc without c++ headers", gcc, StepCompileCXX,
+			"In file included from hello.cpp:1:\n/usr/include/c++/12/iostream:38:10: fatal error: bits/c++config.h: No such file or directory\n",
+			[]string{hintCXX}},
+		{"gcc without libstdc++", gcc, StepExecutable,
+			"/usr/bin/mips-linux-gnu-ld: cannot find -lstdc++: No such file or directory\ncollect2: error: ld returned 1 exit status\n",
+			[]string{hintStdCXX}},
+		{"lld without libc++", gcc, StepExecutable,
+			"ld.lld: error: unable to find library -lc++\nld.lld: error: unable to find library -lc++abi\nclang++: error: linker command failed with exit code 1 (use -v to see invocation)\n",
+			[]string{hintLibCXX}},
+		{"ld without libc++", gcc, StepExecutable,
+			"/usr/bin/ld: cannot find -lc++: No such file or directory\ncollect2: error: ld returned 1 exit status\n",
+			[]string{hintLibCXX}},
+		{"ld without crt", gcc, StepExecutableC,
+			"/usr/bin/mips-linux-gnu-ld: cannot find crt1.o: No such file or directory\n/usr/bin/mips-linux-gnu-ld: cannot find crti.o: No such file or directory\ncollect2: error: ld returned 1 exit status\n",
+			[]string{hintCRT}},
+		{"ld without libc", gcc, StepExecutable,
+			"/usr/bin/mips-linux-gnu-ld: cannot find -lc: No such file or directory\ncollect2: error: ld returned 1 exit status\n",
+			[]string{hintCRT}},
+		{"old ld without libc", gcc, StepExecutableC,
+			"/usr/bin/ld: cannot find -lc\ncollect2: error: ld returned 1 exit status\n",
+			[]string{hintCRT}},
+		{"lld without libc", gcc, StepExecutableC,
+			"ld.lld: error: unable to find library -lc\n",
+			[]string{hintCRT}},
+		{"unrelated error", gcc, StepCompileC,
+			"hello.c:3:1: error: expected ';' before '}' token\n",
+			[]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Report{Steps: []*Step{{Name: tt.step, Output: tt.output, Error: "exit status 1"}}}
+			if got := r.failureHints(tt.tc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("failureHints() = %q, want %q", got, tt.want)
+			}
+			// the output of passed steps is not inspected
+			r.Steps[0].Passed = true
+			if got := r.failureHints(tt.tc); len(got) != 0 {
+				t.Errorf("failureHints() for a passed step = %q", got)
+			}
+		})
+	}
+}
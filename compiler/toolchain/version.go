@@ -0,0 +1,326 @@
+package toolchain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a toolchain version consisting of up to four numeric components
+// and an optional vendor suffix, e.g. 12.2.0, 14.29.30133, 17.0.6-1ubuntu2,
+// 19.0.0git.
+type Version struct {
+	Parts  [4]int `json:"parts" yaml:"parts,flow"`
+	Count  int    `json:"count" yaml:"count"`                       // number of specified components
+	Suffix string `json:"suffix,omitempty" yaml:"suffix,omitempty"` // text following the numeric components
+}
+
+// ErrInvalidVersion is returned when a version string can not be parsed
+var ErrInvalidVersion = errors.New("invalid version")
+
+// ParseVersion parses a version string tolerantly: a leading 'v' is ignored,
+// missing components are treated as zeroes, and anything that follows the
+// numeric components is kept as the suffix.
+func ParseVersion(s string) (Version, error) {
+	v := Version{}
+	s = strings.TrimSpace(s)
+	if len(s) > 1 && (s[0] == 'v' || s[0] == 'V') && s[1] >= '0' && s[1] <= '9' {
+		s = s[1:]
+	}
+	i, n := 0, len(s)
+	for {
+		o := i
+		for i < n && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == o {
+			return Version{}, fmt.Errorf("%w '%s'", ErrInvalidVersion, s)
+		}
+		if v.Count == len(v.Parts) {
+			return Version{}, fmt.Errorf("%w '%s': too many components", ErrInvalidVersion, s)
+		}
+		x, err := strconv.Atoi(s[o:i])
+		if err != nil {
+			return Version{}, fmt.Errorf("%w '%s'", ErrInvalidVersion, s)
+		}
+		v.Parts[v.Count] = x
+		v.Count++
+		if i+1 < n && s[i] == '.' && s[i+1] >= '0' && s[i+1] <= '9' {
+			i++
+			continue
+		}
+		break
+	}
+	v.Suffix = s[i:]
+	return v, nil
+}
+
+// MustParseVersion is like ParseVersion but panics on error
+func MustParseVersion(s string) Version {
+	v, err := ParseVersion(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Major returns the first version component
+func (v Version) Major() int { return v.Parts[0] }
+
+// Minor returns the second version component
+func (v Version) Minor() int { return v.Parts[1] }
+
+// Patch returns the third version component
+func (v Version) Patch() int { return v.Parts[2] }
+
+// IsPrerelease returns true for development and release candidate builds
+func (v Version) IsPrerelease() bool {
+	s := strings.ToLower(strings.TrimLeft(v.Suffix, "-.~_ "))
+	for _, p := range []string{"alpha", "beta", "rc", "pre", "dev", "git", "snapshot", "svn", "trunk"} {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare returns -1, 0, or +1 depending on whether v is older, same, or
+// newer than o. Missing components compare as zeroes, prereleases are older
+// than the corresponding releases, remaining suffixes are compared as text
+// with digit runs compared numerically, so -ubuntu2 is older than -ubuntu10.
+func (v Version) Compare(o Version) int {
+	for i := range v.Parts {
+		if v.Parts[i] != o.Parts[i] {
+			if v.Parts[i] < o.Parts[i] {
+				return -1
+			}
+			return +1
+		}
+	}
+	if p1, p2 := v.IsPrerelease(), o.IsPrerelease(); p1 != p2 {
+		if p1 {
+			return -1
+		}
+		return +1
+	}
+	return compareSuffix(v.Suffix, o.Suffix)
+}
+
+// compareSuffix compares text byte by byte and digit runs by their values
+func compareSuffix(s1, s2 string) int {
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	i, j := 0, 0
+	for i < len(s1) && j < len(s2) {
+		if !isDigit(s1[i]) || !isDigit(s2[j]) {
+			if s1[i] != s2[j] {
+				if s1[i] < s2[j] {
+					return -1
+				}
+				return +1
+			}
+			i++
+			j++
+			continue
+		}
+		o1, o2 := i, j
+		for i < len(s1) && isDigit(s1[i]) {
+			i++
+		}
+		for j < len(s2) && isDigit(s2[j]) {
+			j++
+		}
+		n1 := strings.TrimLeft(s1[o1:i], "0")
+		n2 := strings.TrimLeft(s2[o2:j], "0")
+		if len(n1) != len(n2) {
+			if len(n1) < len(n2) {
+				return -1
+			}
+			return +1
+		}
+		if c := strings.Compare(n1, n2); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case i < len(s1):
+		return +1
+	case j < len(s2):
+		return -1
+	}
+	return strings.Compare(s1, s2)
+}
+
+// String returns the specified numeric components followed by the suffix
+func (v Version) String() string {
+	ss := make([]string, v.Count)
+	for i := 0; i < v.Count; i++ {
+		ss[i] = strconv.Itoa(v.Parts[i])
+	}
+	return strings.Join(ss, ".") + v.Suffix
+}
+
+// CompareVersionStrings compares two version strings, parsable versions
+// are considered newer than the ones that fail to parse
+func CompareVersionStrings(s1, s2 string) int {
+	v1, e1 := ParseVersion(s1)
+	v2, e2 := ParseVersion(s2)
+	switch {
+	case e1 == nil && e2 == nil:
+		return v1.Compare(v2)
+	case e1 == nil:
+		return +1
+	case e2 == nil:
+		return -1
+	default:
+		return strings.Compare(s1, s2)
+	}
+}
+
+// Constraint is a set of version requirements, e.g. ">=12, <14" or
+// "^17 || ~16.2". Comma or space separated terms must all match, groups
+// separated by "||" are alternatives.
+//
+// Supported operators are =, !=, >, >=, <, <=, ~ (same major.minor when
+// minor is specified, same major otherwise), and ^ (same major). A bare
+// version or '=' matches by prefix: "13" matches 13.2.0.
+type Constraint struct {
+	groups [][]versionTerm
+	text   string
+}
+
+type versionTerm struct {
+	op string
+	v  Version
+}
+
+// ParseConstraint parses a version constraint expression
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{text: strings.TrimSpace(s)}
+	for _, alt := range strings.Split(s, "||") {
+		group := []versionTerm{}
+		fields := strings.FieldsFunc(alt, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			op := ""
+			for _, o := range []string{">=", "<=", "!=", "==", ">", "<", "=", "~", "^"} {
+				if strings.HasPrefix(f, o) {
+					op = o
+					break
+				}
+			}
+			f = f[len(op):]
+			if f == "" && i+1 < len(fields) {
+				// operator separated from the version by a space
+				i++
+				f = fields[i]
+			}
+			if op == "==" || op == "" {
+				op = "="
+			}
+			v, err := ParseVersion(f)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("invalid version constraint '%s': %w", s, err)
+			}
+			group = append(group, versionTerm{op: op, v: v})
+		}
+		if len(group) == 0 {
+			return Constraint{}, fmt.Errorf("invalid version constraint '%s'", s)
+		}
+		c.groups = append(c.groups, group)
+	}
+	return c, nil
+}
+
+// Check returns true if the version satisfies the constraint
+func (c Constraint) Check(v Version) bool {
+	for _, group := range c.groups {
+		ok := true
+		for _, t := range group {
+			if !t.check(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the original constraint text
+func (c Constraint) String() string {
+	return c.text
+}
+
+func (t versionTerm) check(v Version) bool {
+	switch t.op {
+	case "=":
+		return t.prefixMatch(v)
+	case "!=":
+		return !t.prefixMatch(v)
+	case ">":
+		return v.Compare(t.v) > 0
+	case ">=":
+		return v.Compare(t.v) >= 0
+	case "<":
+		return v.Compare(t.v) < 0
+	case "<=":
+		return v.Compare(t.v) <= 0
+	case "~":
+		if v.Compare(t.v) < 0 || v.Major() != t.v.Major() {
+			return false
+		}
+		return t.v.Count < 2 || v.Minor() == t.v.Minor()
+	case "^":
+		return v.Compare(t.v) >= 0 && v.Major() == t.v.Major()
+	}
+	return false
+}
+
+func (t versionTerm) prefixMatch(v Version) bool {
+	for i := 0; i < t.v.Count; i++ {
+		if v.Parts[i] != t.v.Parts[i] {
+			return false
+		}
+	}
+	return t.v.Suffix == "" || t.v.Suffix == v.Suffix
+}
+
+// MatchVersion checks if the chain version satisfies the constraint
+func (tc *Chain) MatchVersion(c Constraint) bool {
+	v, err := ParseVersion(tc.Version)
+	return err == nil && c.Check(v)
+}
+
+// MatchLLVMVersion checks if the upstream LLVM version of a clang-based
+// chain satisfies the constraint. Unlike vendor versions, LLVM versions have
+// the same meaning across clang variants.
+func (tc *Chain) MatchLLVMVersion(c Constraint) bool {
+	v, err := ParseVersion(tc.LLVMVersion)
+	return err == nil && c.Check(v)
+}
+
+// Compare orders toolchains by version, older first. Toolchains with equal
+// versions are ordered by Windows SDK and UCRT versions (msvc), then by
+// target, full version, and compiler path. Toolchains from different
+// compiler families are compared by their versions as well.
+func Compare(c1, c2 *Chain) int {
+	if i := CompareVersionStrings(c1.Version, c2.Version); i != 0 {
+		return i
+	}
+	if i := CompareVersionStrings(c1.WindowsSDKVersion, c2.WindowsSDKVersion); i != 0 {
+		return i
+	}
+	if i := CompareVersionStrings(c1.UCRTVersion, c2.UCRTVersion); i != 0 {
+		return i
+	}
+	if i := strings.Compare(c1.Target.Original, c2.Target.Original); i != 0 {
+		return i
+	}
+	if i := strings.Compare(c1.FullVersion, c2.FullVersion); i != 0 {
+		return i
+	}
+	return strings.Compare(string(c1.Tools[CXXCompiler]), string(c2.Tools[CXXCompiler]))
+}
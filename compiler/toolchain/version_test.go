@@ -0,0 +1,119 @@
+package toolchain
+
+import (
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		input  string
+		parts  [4]int
+		count  int
+		suffix string
+	}{
+		{"12", [4]int{12}, 1, ""},
+		{"12.2", [4]int{12, 2}, 2, ""},
+		{"12.2.0", [4]int{12, 2, 0}, 3, ""},
+		{"14.29.30133", [4]int{14, 29, 30133}, 3, ""},
+		{"17.8.34330.188", [4]int{17, 8, 34330, 188}, 4, ""},
+		{"v1.2.3", [4]int{1, 2, 3}, 3, ""},
+		{"13.2.0-ubuntu1", [4]int{13, 2, 0}, 3, "-ubuntu1"},
+		{"19.0.0git", [4]int{19, 0, 0}, 3, "git"},
+		{"10.2.1+deb11u1", [4]int{10, 2, 1}, 3, "+deb11u1"},
+		{"11.", [4]int{11}, 1, "."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			v, err := ParseVersion(tt.input)
+			if err != nil {
+				t.Fatalf("ParseVersion(%q) unexpected error: %v", tt.input, err)
+			}
+			if v.Parts != tt.parts || v.Count != tt.count || v.Suffix != tt.suffix {
+				t.Errorf("ParseVersion(%q) = %+v", tt.input, v)
+			}
+		})
+	}
+
+	for _, s := range []string{"", "abc", "1.2.3.4.5", "v"} {
+		if _, err := ParseVersion(s); err == nil {
+			t.Errorf("ParseVersion(%q) expected error", s)
+		}
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	tests := []struct {
+		v1, v2   string
+		expected int
+	}{
+		{"12.2.0", "12.2.0", 0},
+		{"12", "12.0.0", 0},
+		{"12.10.0", "12.9.0", +1},
+		{"9.4.0", "10.1.0", -1},
+		{"14.29.30133", "14.29.30037", +1},
+		{"17.8.34330.188", "17.8.34330.200", -1},
+		{"19.0.0git", "19.0.0", -1},
+		{"14.0.0-rc1", "14.0.0", -1},
+		{"13.2.0-ubuntu1", "13.2.0-ubuntu2", -1},
+		{"13.2.0-ubuntu2", "13.2.0-ubuntu10", -1},
+		{"12.3.0-1ubuntu1~22.04", "12.3.0-1ubuntu1~20.04", +1},
+		{"10.2.1-6+deb11u1", "10.2.1-6+deb11u12", -1},
+		{"10.2.1-10", "10.2.1-9", +1},
+		{"13.2.0-ubuntu1", "13.2.0-ubuntu1.1", -1},
+		{"13.2.0-a", "13.2.0-1", +1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.v1+" vs "+tt.v2, func(t *testing.T) {
+			got := MustParseVersion(tt.v1).Compare(MustParseVersion(tt.v2))
+			if got != tt.expected {
+				t.Errorf("Compare(%q, %q) = %d, want %d", tt.v1, tt.v2, got, tt.expected)
+			}
+			if back := MustParseVersion(tt.v2).Compare(MustParseVersion(tt.v1)); back != -tt.expected {
+				t.Errorf("Compare(%q, %q) = %d, want %d", tt.v2, tt.v1, back, -tt.expected)
+			}
+		})
+	}
+}
+
+func TestConstraint_Check(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{">=12, <14", "12.0.0", true},
+		{">=12, <14", "13.2.1", true},
+		{">=12, <14", "14.0.0", false},
+		{">=12, <14", "11.4.0", false},
+		{">= 12 < 14", "13.1", true},
+		{"13", "13.2.0", true},
+		{"13", "12.13.0", false},
+		{"=13.2", "13.2.1", true},
+		{"!=13", "13.2.0", false},
+		{"~16.2", "16.2.9", true},
+		{"~16.2", "16.3.0", false},
+		{"^17", "17.0.6", true},
+		{"^17.0.2", "17.0.1", false},
+		{"^17", "18.1.0", false},
+		{"<12 || >=15", "16.0.0", true},
+		{"<12 || >=15", "13.0.0", false},
+		{">14.29", "14.29.30133", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseConstraint(%q) unexpected error: %v", tt.constraint, err)
+			}
+			if got := c.Check(MustParseVersion(tt.version)); got != tt.expected {
+				t.Errorf("%q.Check(%q) = %v, want %v", tt.constraint, tt.version, got, tt.expected)
+			}
+		})
+	}
+
+	for _, s := range []string{"", ">=", "abc", ">=12 ||"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) expected error", s)
+		}
+	}
+}
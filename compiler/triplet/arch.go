@@ -0,0 +1,166 @@
+package triplet
+
+import (
+	"strings"
+)
+
+// Endianness values
+const (
+	LittleEndian = "little"
+	BigEndian    = "big"
+)
+
+// Float ABI values for targets where the calling convention for floating
+// point arguments varies (arm)
+const (
+	FloatHard   = "hard"   // arguments in FPU registers (gnueabihf, armhf)
+	FloatSoftFP = "softfp" // FPU instructions, arguments in integer registers (androideabi)
+	FloatSoft   = "soft"   // no FPU (gnueabi, armel)
+)
+
+type archTraits struct {
+	endian string
+	width  int
+}
+
+// traits of normalized architectures
+var archTraitsMap = map[string]archTraits{
+	"x64":        {LittleEndian, 64},
+	"x32":        {LittleEndian, 32},
+	"arm":        {LittleEndian, 32},
+	"arm64":      {LittleEndian, 64},
+	"ia64":       {LittleEndian, 64},
+	"powerpc":    {BigEndian, 32},
+	"powerpcle":  {LittleEndian, 32},
+	"ppc64":      {BigEndian, 64},
+	"ppc64le":    {LittleEndian, 64},
+	"loong64":    {LittleEndian, 64},
+	"mips":       {BigEndian, 32},
+	"mipsel":     {LittleEndian, 32},
+	"mips64":     {BigEndian, 64},
+	"mips64el":   {LittleEndian, 64},
+	"riscv32":    {LittleEndian, 32},
+	"riscv64":    {LittleEndian, 64},
+	"s390":       {BigEndian, 32},
+	"s390x":      {BigEndian, 64},
+	"sparc":      {BigEndian, 32},
+	"sparc64":    {BigEndian, 64},
+	"wasm32":     {LittleEndian, 32},
+	"wasm64":     {LittleEndian, 64},
+	"m68k":       {BigEndian, 32},
+	"avr":        {LittleEndian, 16},
+	"msp430":     {LittleEndian, 16},
+	"xtensa":     {LittleEndian, 32},
+	"microblaze": {BigEndian, 32},
+	"nvptx":      {LittleEndian, 32},
+	"nvptx64":    {LittleEndian, 64},
+	"amdgcn":     {LittleEndian, 64},
+}
+
+// splitArch separates the sub-architecture and the endianness suffix from
+// architecture names such as armv7a, armv7l, thumbv7em, armeb, aarch64_be,
+// arm64e, riscv64gc, and i686. The returned base is not normalized.
+//
+// For arm the A-profile is the default: armv7a and armv7 both give v7,
+// while M and R profiles are kept (v7m, v7em, v8m.main).
+func splitArch(arch string) (base, sub, endian string) {
+	arch = strings.ToLower(arch)
+	switch {
+	case arch == "aarch64_be" || arch == "arm64_be":
+		return "aarch64", "", BigEndian
+	case arch == "arm64e":
+		return "arm64", "e", ""
+	case strings.HasPrefix(arch, "thumb"), strings.HasPrefix(arch, "arm") && !strings.HasPrefix(arch, "arm64"):
+		rest := strings.TrimPrefix(strings.TrimPrefix(arch, "thumb"), "arm")
+		if strings.HasPrefix(rest, "eb") {
+			rest, endian = rest[2:], BigEndian
+		} else if strings.HasSuffix(rest, "eb") {
+			rest, endian = rest[:len(rest)-2], BigEndian
+		}
+		if rest == "" || rest == "32" || rest == "el" || rest == "hf" {
+			return "arm", "", endian
+		}
+		if !strings.HasPrefix(rest, "v") {
+			return arch, "", ""
+		}
+		// uname suffixes: armv7l, armv7hl (fedora hard float)
+		rest = strings.TrimSuffix(strings.TrimSuffix(rest, "l"), "h")
+		if len(rest) > 2 && strings.HasSuffix(rest, "a") && rest[len(rest)-2] >= '0' && rest[len(rest)-2] <= '9' {
+			rest = rest[:len(rest)-1]
+		}
+		return "arm", rest, endian
+	case strings.HasPrefix(arch, "riscv32"), strings.HasPrefix(arch, "riscv64"):
+		return arch[:7], arch[7:], ""
+	case arch == "i386", arch == "i486", arch == "i586", arch == "i686":
+		return arch, arch, ""
+	}
+	return arch, "", ""
+}
+
+// ParseSubArch returns the sub-architecture spelled in the architecture
+// name, e.g. v7 for armv7a, gc for riscv64gc, i686 for i686
+func ParseSubArch(arch string) string {
+	_, sub, _ := splitArch(arch)
+	return sub
+}
+
+// parseFloatABI derives the float ABI from an environment component
+func parseFloatABI(s string) string {
+	s = strings.ToLower(s)
+	switch {
+	case strings.HasSuffix(s, "eabihf"):
+		return FloatHard
+	case strings.HasPrefix(s, "android"):
+		return FloatSoftFP
+	case strings.HasSuffix(s, "eabi"):
+		return FloatSoft
+	}
+	return ""
+}
+
+// fillArchTraits sets endianness and pointer width from the architecture
+// when they are not specified, and the float ABI for the targets where the
+// platform defines it
+func (t *Target) fillArchTraits() {
+	if tr, ok := archTraitsMap[t.Arch]; ok {
+		if t.Endian == "" {
+			t.Endian = tr.endian
+		}
+		if t.PointerWidth == 0 {
+			t.PointerWidth = tr.width
+			if ilp32Environment(t.Environment) {
+				t.PointerWidth = 32
+			}
+		}
+	}
+	if t.Arch == "arm" && t.FloatABI == "" {
+		switch t.OS {
+		case "windows":
+			t.FloatABI = FloatHard
+		case "android":
+			t.FloatABI = FloatSoftFP
+		}
+	}
+}
+
+// FullArch returns the architecture including the sub-architecture, e.g.
+// armv7, riscv64gc
+func (t Target) FullArch() string {
+	switch {
+	case t.SubArch == "":
+		return t.Arch
+	case t.Arch == "x32":
+		return t.SubArch
+	}
+	return t.Arch + t.SubArch
+}
+
+// IsBigEndian checks if the target is big-endian
+func (t Target) IsBigEndian() bool {
+	return t.Endian == BigEndian
+}
+
+// Is64Bit checks if the target uses 64-bit pointers
+func (t Target) Is64Bit() bool {
+	return t.PointerWidth == 64
+}
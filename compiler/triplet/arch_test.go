@@ -0,0 +1,62 @@
+package triplet
+
+import (
+	"testing"
+)
+
+func TestParseTarget_ArchTraits(t *testing.T) {
+	tests := []struct {
+		input    string
+		arch     string
+		subarch  string
+		floatABI string
+		endian   string
+		width    int
+	}{
+		{"x86_64-linux-gnu", "x64", "", "", "little", 64},
+		{"x86_64-linux-gnux32", "x64", "", "", "little", 32},
+		{"aarch64-linux-gnuilp32", "arm64", "", "", "little", 32},
+		{"i686-w64-mingw32", "x32", "i686", "", "little", 32},
+		{"arm-linux-gnueabi", "arm", "", "soft", "little", 32},
+		{"arm-linux-gnueabihf", "arm", "", "hard", "little", 32},
+		{"armv7a-linux-androideabi24", "arm", "v7", "softfp", "little", 32},
+		{"armv7hl-redhat-linux-gnueabi", "arm", "v7", "hard", "little", 32},
+		{"armeb-linux-gnueabi", "arm", "", "soft", "big", 32},
+		{"thumbv7em-none-eabihf", "arm", "v7em", "hard", "little", 32},
+		{"aarch64_be-linux-gnu", "arm64", "", "", "big", 64},
+		{"arm64e-apple-ios", "arm64", "e", "", "little", 64},
+		{"riscv64gc-unknown-linux-gnu", "riscv64", "gc", "", "little", 64},
+		{"mips64el-linux-gnuabi64", "mips64el", "", "", "little", 64},
+		{"powerpc64-linux-gnu", "ppc64", "", "", "big", 64},
+		{"s390x-linux-gnu", "s390x", "", "", "big", 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, _, err := ParseTarget(tt.input)
+			if err != nil {
+				t.Fatalf("ParseTarget(%q) error: %v", tt.input, err)
+			}
+			if got.Arch != tt.arch || got.SubArch != tt.subarch || got.FloatABI != tt.floatABI ||
+				got.Endian != tt.endian || got.PointerWidth != tt.width {
+				t.Errorf("ParseTarget(%q) = %#v", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestTarget_FullArch(t *testing.T) {
+	tests := []struct {
+		target   Target
+		expected string
+	}{
+		{Target{Arch: "x64"}, "x64"},
+		{Target{Arch: "x32", SubArch: "i686"}, "i686"},
+		{Target{Arch: "arm", SubArch: "v7"}, "armv7"},
+		{Target{Arch: "riscv64", SubArch: "gc"}, "riscv64gc"},
+	}
+	for _, tt := range tests {
+		if got := tt.target.FullArch(); got != tt.expected {
+			t.Errorf("FullArch(%#v) = %q, want %q", tt.target, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package triplet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Targets are spelled differently by other tools and ecosystems. The
+// functions below convert targets to and from:
+//   - Rust target triples (x86_64-unknown-linux-gnu)
+//   - Zig targets (x86_64-linux-gnu)
+//   - Debian multiarch tuples (x86_64-linux-gnu) and dpkg architectures (amd64)
+//   - CMake CMAKE_SYSTEM_NAME and CMAKE_SYSTEM_PROCESSOR (Linux, x86_64)
+//   - Android ABI names (x86_64, arm64-v8a)
+
+// gnuArchs maps normalized architectures to the names used in GNU-style
+// triples by Rust, Zig and Debian
+var gnuArchs = map[string]string{
+	"x64":     "x86_64",
+	"x32":     "i686",
+	"arm64":   "aarch64",
+	"ppc64":   "powerpc64",
+	"ppc64le": "powerpc64le",
+	"loong64": "loongarch64",
+}
+
+func gnuArch(arch string) string {
+	if v, ok := gnuArchs[arch]; ok {
+		return v
+	}
+	return arch
+}
+
+// gnuFullArch returns the GNU architecture name with the sub-architecture,
+// x86 sub-architectures are complete names like FullArch returns
+func gnuFullArch(t Target) string {
+	if t.Arch == "x32" && t.SubArch != "" {
+		return t.SubArch
+	}
+	return gnuArch(t.Arch) + t.SubArch
+}
+
+// ilp32Suffix returns the environment suffix of the x32 and ilp32 ABIs
+func ilp32Suffix(t Target) string {
+	if !ilp32Environment(t.Environment) {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimPrefix(t.Environment, "gnu"), "musl")
+}
+
+// armEABI returns the environment suffix that spells the arm float ABI
+func armEABI(t Target) string {
+	if t.FloatABI == FloatSoft {
+		return "eabi"
+	}
+	return "eabihf"
+}
+
+// Rust returns the Rust target triple
+func (t Target) Rust() (string, error) {
+	arch := gnuArch(t.Arch)
+	eabi := ""
+	switch {
+	case t.Arch == "riscv64":
+		arch = "riscv64gc"
+	case t.Arch == "arm" && (t.OS == "android" || strings.HasPrefix(t.SubArch, "v7")):
+		arch, eabi = "armv7", armEABI(t)
+	case t.Arch == "arm" && t.SubArch == "v5te":
+		arch, eabi = "armv5te", armEABI(t)
+	case t.Arch == "arm":
+		eabi = armEABI(t)
+	case t.Arch == "mips64" || t.Arch == "mips64el":
+		eabi = "abi64"
+	}
+	switch t.OS {
+	case "linux":
+		switch t.LibC {
+		case "musl":
+			if ilp32Environment(t.Environment) {
+				break
+			}
+			return arch + "-unknown-linux-musl" + eabi, nil
+		case "glibc", "", "unknown":
+			switch t.Environment {
+			case "gnux32":
+				eabi = "x32"
+			case "gnuilp32":
+				eabi = "_ilp32"
+			}
+			return arch + "-unknown-linux-gnu" + eabi, nil
+		}
+	case "android":
+		if t.Arch == "arm" {
+			return arch + "-linux-androideabi", nil
+		}
+		return arch + "-linux-android", nil
+	case "windows":
+		switch t.LibC {
+		case "msvcrt":
+			return arch + "-pc-windows-msvc", nil
+		case "mingw", "", "unknown":
+			return arch + "-pc-windows-gnu", nil
+		}
+	case "darwin":
+		return arch + "-apple-darwin", nil
+	case "ios":
+		return arch + "-apple-ios", nil
+	case "freebsd", "netbsd", "openbsd", "dragonfly", "illumos":
+		return arch + "-unknown-" + t.OS, nil
+	case "solaris":
+		return arch + "-pc-solaris", nil
+	case "aix":
+		return arch + "-ibm-aix", nil
+	case "emscripten":
+		return arch + "-unknown-emscripten", nil
+	case "wasi":
+		p := t.WASIPreview()
+		if p == 0 {
+			p = 1
+		}
+		if t.HasThreads() {
+			return arch + "-wasip" + strconv.Itoa(p) + "-threads", nil
+		}
+		return arch + "-wasip" + strconv.Itoa(p), nil
+	case "none":
+		if t.Arch == "arm" {
+			// Rust names bare-metal arm targets by instruction set and
+			// core (thumbv7em-none-eabihf), there is no mapping for them
+			break
+		}
+		return arch + "-unknown-none", nil
+	case "", "unknown":
+		if t.IsWasm() {
+			return arch + "-unknown-unknown", nil
+		}
+	}
+	return "", &ErrInvalidTarget{t.String(), "no Rust equivalent"}
+}
+
+// ParseRust parses a Rust target triple
+func ParseRust(s string) (Target, error) {
+	t, _, err := ParseTarget(s)
+	if err != nil {
+		return Target{}, err
+	}
+	if strings.HasPrefix(t.Arch, "riscv64") {
+		t.Arch = "riscv64"
+	}
+	return t, t.Validate()
+}
+
+// Zig returns the target as accepted by zig -target
+func (t Target) Zig() (string, error) {
+	arch := gnuArch(t.Arch)
+	eabi := ""
+	switch t.Arch {
+	case "x32":
+		arch = "x86"
+	case "arm":
+		eabi = armEABI(t)
+	default:
+		eabi = ilp32Suffix(t)
+	}
+	switch t.OS {
+	case "linux":
+		switch t.LibC {
+		case "musl":
+			return arch + "-linux-musl" + eabi, nil
+		case "glibc", "", "unknown":
+			if t.LibCVersion != "" {
+				eabi += "." + t.LibCVersion
+			}
+			return arch + "-linux-gnu" + eabi, nil
+		}
+	case "android":
+		if t.Arch == "arm" {
+			return arch + "-linux-androideabi", nil
+		}
+		return arch + "-linux-android", nil
+	case "windows":
+		switch t.LibC {
+		case "msvcrt":
+			return arch + "-windows-msvc", nil
+		case "mingw", "", "unknown":
+			return arch + "-windows-gnu", nil
+		}
+	case "darwin":
+		return arch + "-macos", nil
+	case "wasi":
+		if t.WASIPreview() > 1 || t.HasThreads() {
+			break
+		}
+		return arch + "-wasi", nil
+	case "ios", "freebsd", "netbsd", "openbsd", "dragonfly", "illumos", "solaris", "aix", "emscripten":
+		return arch + "-" + t.OS, nil
+	case "none":
+		return arch + "-freestanding", nil
+	case "", "unknown":
+		if t.IsWasm() {
+			return arch + "-freestanding", nil
+		}
+	}
+	return "", &ErrInvalidTarget{t.String(), "no Zig equivalent"}
+}
+
+// ParseZig parses a zig target (arch-os[.version]-abi[.version]). The
+// lower bound of a version range is kept as the minimum OS version, the abi
+// version as the minimum C library version.
+func ParseZig(s string) (Target, error) {
+	ss := strings.Split(s, "-")
+	for i, v := range ss {
+		// os version ranges: aarch64-macos.13...14-none
+		if p := strings.Index(v, "..."); p > 0 && i > 0 {
+			ss[i] = v[:p]
+		}
+		switch ss[i] {
+		case "x86":
+			ss[i] = "i386"
+		case "freestanding":
+			ss[i] = "none"
+		}
+	}
+	t, _, err := ParseTarget(strings.Join(ss, "-"))
+	if err != nil {
+		return Target{}, err
+	}
+	return t, t.Validate()
+}
+
+// debianArchs maps multiarch tuples to dpkg architectures
+var debianArchs = map[string]string{
+	"x86_64-linux-gnu":        "amd64",
+	"x86_64-linux-gnux32":     "x32",
+	"i386-linux-gnu":          "i386",
+	"aarch64-linux-gnu":       "arm64",
+	"arm-linux-gnueabihf":     "armhf",
+	"arm-linux-gnueabi":       "armel",
+	"powerpc64le-linux-gnu":   "ppc64el",
+	"powerpc64-linux-gnu":     "ppc64",
+	"riscv64-linux-gnu":       "riscv64",
+	"s390x-linux-gnu":         "s390x",
+	"mips64el-linux-gnuabi64": "mips64el",
+	"mipsel-linux-gnu":        "mipsel",
+	"loongarch64-linux-gnu":   "loong64",
+	"x86_64-linux-musl":       "musl-linux-amd64",
+	"aarch64-linux-musl":      "musl-linux-arm64",
+}
+
+// Debian returns the Debian multiarch tuple, as used in /usr/lib/<tuple>
+// and for cross compiler prefixes (<tuple>-gcc)
+func (t Target) Debian() (string, error) {
+	if t.OS != "linux" {
+		return "", &ErrInvalidTarget{t.String(), "Debian multiarch tuples are defined only for linux"}
+	}
+	libc := "gnu"
+	switch t.LibC {
+	case "musl":
+		libc = "musl"
+	case "glibc", "", "unknown":
+	default:
+		return "", &ErrInvalidTarget{t.String(), "no Debian multiarch equivalent"}
+	}
+	arch := gnuArch(t.Arch)
+	switch {
+	case t.Arch == "x32":
+		arch = "i386"
+	case t.Arch == "arm":
+		libc += armEABI(t)
+	case t.Arch == "mips64el" || t.Arch == "mips64":
+		libc += "abi64"
+	default:
+		libc += ilp32Suffix(t)
+	}
+	s := arch + "-linux-" + libc
+	if _, ok := debianArchs[s]; !ok {
+		return "", &ErrInvalidTarget{t.String(), "no Debian multiarch equivalent"}
+	}
+	return s, nil
+}
+
+// DebianArch returns the dpkg architecture name (amd64, arm64, armhf, ...)
+func (t Target) DebianArch() (string, error) {
+	s, err := t.Debian()
+	if err != nil {
+		return "", err
+	}
+	return debianArchs[s], nil
+}
+
+// ParseDebian parses a Debian multiarch tuple or a dpkg architecture name
+func ParseDebian(s string) (Target, error) {
+	for tuple, arch := range debianArchs {
+		if arch == s {
+			s = tuple
+			break
+		}
+	}
+	if _, ok := debianArchs[s]; !ok {
+		return Target{}, &ErrInvalidTarget{s, "unknown Debian architecture"}
+	}
+	t, _, err := ParseTarget(s)
+	switch s {
+	case "arm-linux-gnueabihf":
+		t.SubArch = "v7" // armhf baseline
+	case "arm-linux-gnueabi":
+		t.SubArch = "v5te" // armel baseline
+	}
+	return t, err
+}
+
+// CMakeSystem holds the CMake variables that describe a target
+type CMakeSystem struct {
+	Name      string `json:"name" yaml:"name"`           // CMAKE_SYSTEM_NAME
+	Processor string `json:"processor" yaml:"processor"` // CMAKE_SYSTEM_PROCESSOR
+}
+
+var cmakeSystemNames = map[string]string{
+	"linux":      "Linux",
+	"windows":    "Windows",
+	"darwin":     "Darwin",
+	"ios":        "iOS",
+	"android":    "Android",
+	"freebsd":    "FreeBSD",
+	"netbsd":     "NetBSD",
+	"openbsd":    "OpenBSD",
+	"dragonfly":  "DragonFly",
+	"solaris":    "SunOS",
+	"illumos":    "SunOS",
+	"aix":        "AIX",
+	"emscripten": "Emscripten",
+	"wasi":       "WASI",
+	"none":       "Generic",
+}
+
+// windowsProcessors are the CMAKE_SYSTEM_PROCESSOR values on Windows, they
+// come from PROCESSOR_ARCHITECTURE
+var windowsProcessors = map[string]string{
+	"x64":   "AMD64",
+	"x32":   "X86",
+	"arm64": "ARM64",
+	"arm":   "ARM",
+}
+
+// CMake returns CMAKE_SYSTEM_NAME and CMAKE_SYSTEM_PROCESSOR for the target,
+// processor names follow what CMake reports natively on each system
+func (t Target) CMake() (CMakeSystem, error) {
+	name, ok := cmakeSystemNames[t.OS]
+	if !ok {
+		return CMakeSystem{}, &ErrInvalidTarget{t.String(), "no CMake system name"}
+	}
+	p := gnuArch(t.Arch)
+	switch t.OS {
+	case "windows":
+		if v, ok := windowsProcessors[t.Arch]; ok {
+			p = v
+		}
+	case "darwin", "ios":
+		if t.Arch == "arm64" {
+			p = "arm64"
+		}
+	case "android":
+		if t.Arch == "arm" {
+			p = "armv7-a"
+		}
+	default:
+		p = gnuFullArch(t)
+	}
+	if p == "" || p == "unknown" {
+		return CMakeSystem{}, &ErrInvalidTarget{t.String(), "unknown architecture"}
+	}
+	return CMakeSystem{Name: name, Processor: p}, nil
+}
+
+// ParseCMake converts CMAKE_SYSTEM_NAME and CMAKE_SYSTEM_PROCESSOR values
+// to a target
+func ParseCMake(name, processor string) (Target, error) {
+	t := Target{}
+	for os, n := range cmakeSystemNames {
+		if strings.EqualFold(n, name) && os != "illumos" {
+			t.OS = os
+			break
+		}
+	}
+	if t.OS == "" {
+		return Target{}, &ErrInvalidTarget{name, "unknown CMake system name"}
+	}
+	processor = strings.TrimSuffix(processor, "-a")
+	arch, ok := ParseArch(processor)
+	if !ok {
+		return Target{}, &ErrInvalidTarget{processor, "unknown CMake system processor"}
+	}
+	t.Arch = arch
+	t.SubArch = ParseSubArch(processor)
+	if t.OS == "android" {
+		t.LibC = "bionic"
+	}
+	t.ObjectFormat = DefaultObjectFormat(t.OS, t.Arch)
+	t.fillArchTraits()
+	return t, nil
+}
+
+var androidABIs = map[string]string{
+	"arm64":   "arm64-v8a",
+	"arm":     "armeabi-v7a",
+	"x32":     "x86",
+	"x64":     "x86_64",
+	"riscv64": "riscv64",
+}
+
+// AndroidABI returns the Android ABI name (ANDROID_ABI, jniLibs directory)
+func (t Target) AndroidABI() (string, error) {
+	if t.OS != "android" {
+		return "", &ErrInvalidTarget{t.String(), "not an android target"}
+	}
+	if v, ok := androidABIs[t.Arch]; ok {
+		return v, nil
+	}
+	return "", &ErrInvalidTarget{t.String(), "no Android ABI equivalent"}
+}
+
+// ParseAndroidABI converts an Android ABI name to a target
+func ParseAndroidABI(abi string) (Target, error) {
+	for arch, v := range androidABIs {
+		if v == abi {
+			t := Target{Arch: arch, OS: "android", ObjectFormat: ELF, LibC: "bionic"}
+			if arch == "arm" {
+				t.SubArch = "v7"
+			}
+			t.fillArchTraits()
+			return t, nil
+		}
+	}
+	return Target{}, &ErrInvalidTarget{abi, "unknown Android ABI"}
+}
+
+// String returns the CMake variables as command line definitions
+func (s CMakeSystem) String() string {
+	return fmt.Sprintf("-DCMAKE_SYSTEM_NAME=%s -DCMAKE_SYSTEM_PROCESSOR=%s", s.Name, s.Processor)
+}
@@ -0,0 +1,258 @@
+package triplet
+
+import (
+	"testing"
+)
+
+func TestDialects(t *testing.T) {
+	type cmake struct{ name, processor string }
+	tests := []struct {
+		target  Target
+		rust    string
+		zig     string
+		debian  string
+		dpkg    string
+		cmake   cmake
+		android string
+	}{
+		{
+			target: Target{Arch: "x64", OS: "linux", ObjectFormat: "elf", Environment: "gnu", LibC: "glibc"},
+			rust:   "x86_64-unknown-linux-gnu", zig: "x86_64-linux-gnu",
+			debian: "x86_64-linux-gnu", dpkg: "amd64",
+			cmake: cmake{"Linux", "x86_64"},
+		},
+		{
+			target: Target{Arch: "x64", OS: "linux", ObjectFormat: "elf", Environment: "musl", LibC: "musl"},
+			rust:   "x86_64-unknown-linux-musl", zig: "x86_64-linux-musl",
+			debian: "x86_64-linux-musl", dpkg: "musl-linux-amd64",
+			cmake: cmake{"Linux", "x86_64"},
+		},
+		{
+			target: Target{Arch: "x64", OS: "linux", ObjectFormat: "elf", Environment: "gnux32", LibC: "glibc"},
+			rust:   "x86_64-unknown-linux-gnux32", zig: "x86_64-linux-gnux32",
+			debian: "x86_64-linux-gnux32", dpkg: "x32",
+			cmake: cmake{"Linux", "x86_64"},
+		},
+		{
+			target: Target{Arch: "x32", OS: "linux", ObjectFormat: "elf", Environment: "gnu", LibC: "glibc"},
+			rust:   "i686-unknown-linux-gnu", zig: "x86-linux-gnu",
+			debian: "i386-linux-gnu", dpkg: "i386",
+			cmake: cmake{"Linux", "i686"},
+		},
+		{
+			target: Target{Arch: "arm64", OS: "linux", ObjectFormat: "elf", Environment: "gnu", LibC: "glibc"},
+			rust:   "aarch64-unknown-linux-gnu", zig: "aarch64-linux-gnu",
+			debian: "aarch64-linux-gnu", dpkg: "arm64",
+			cmake: cmake{"Linux", "aarch64"},
+		},
+		{
+			target: Target{Arch: "arm", SubArch: "v7", OS: "linux", ObjectFormat: "elf", Environment: "gnueabihf", LibC: "glibc", FloatABI: "hard"},
+			rust:   "armv7-unknown-linux-gnueabihf", zig: "arm-linux-gnueabihf",
+			debian: "arm-linux-gnueabihf", dpkg: "armhf",
+			cmake: cmake{"Linux", "armv7"},
+		},
+		{
+			target: Target{Arch: "arm", SubArch: "v5te", OS: "linux", ObjectFormat: "elf", Environment: "gnueabi", LibC: "glibc", FloatABI: "soft"},
+			rust:   "armv5te-unknown-linux-gnueabi", zig: "arm-linux-gnueabi",
+			debian: "arm-linux-gnueabi", dpkg: "armel",
+			cmake: cmake{"Linux", "armv5te"},
+		},
+		{
+			target: Target{Arch: "ppc64le", OS: "linux", ObjectFormat: "elf", Environment: "gnu", LibC: "glibc"},
+			rust:   "powerpc64le-unknown-linux-gnu", zig: "powerpc64le-linux-gnu",
+			debian: "powerpc64le-linux-gnu", dpkg: "ppc64el",
+			cmake: cmake{"Linux", "powerpc64le"},
+		},
+		{
+			target: Target{Arch: "riscv64", OS: "linux", ObjectFormat: "elf", Environment: "gnu", LibC: "glibc"},
+			rust:   "riscv64gc-unknown-linux-gnu", zig: "riscv64-linux-gnu",
+			debian: "riscv64-linux-gnu", dpkg: "riscv64",
+			cmake: cmake{"Linux", "riscv64"},
+		},
+		{
+			target: Target{Arch: "mips64el", OS: "linux", ObjectFormat: "elf", LibC: "glibc"},
+			rust:   "mips64el-unknown-linux-gnuabi64", zig: "mips64el-linux-gnu",
+			debian: "mips64el-linux-gnuabi64", dpkg: "mips64el",
+			cmake: cmake{"Linux", "mips64el"},
+		},
+		{
+			target: Target{Arch: "x64", OS: "windows", ObjectFormat: "pe", Environment: "msvc", LibC: "msvcrt"},
+			rust:   "x86_64-pc-windows-msvc", zig: "x86_64-windows-msvc",
+			cmake: cmake{"Windows", "AMD64"},
+		},
+		{
+			target: Target{Arch: "arm64", OS: "windows", ObjectFormat: "pe", Environment: "gnu", LibC: "mingw"},
+			rust:   "aarch64-pc-windows-gnu", zig: "aarch64-windows-gnu",
+			cmake: cmake{"Windows", "ARM64"},
+		},
+		{
+			target: Target{Arch: "arm64", OS: "darwin", ObjectFormat: "macho", LibC: "unknown"},
+			rust:   "aarch64-apple-darwin", zig: "aarch64-macos",
+			cmake: cmake{"Darwin", "arm64"},
+		},
+		{
+			target: Target{Arch: "arm64", OS: "ios", ObjectFormat: "macho", LibC: "unknown"},
+			rust:   "aarch64-apple-ios", zig: "aarch64-ios",
+			cmake: cmake{"iOS", "arm64"},
+		},
+		{
+			target: Target{Arch: "arm64", OS: "android", ObjectFormat: "elf", Environment: "android", LibC: "bionic"},
+			rust:   "aarch64-linux-android", zig: "aarch64-linux-android",
+			cmake: cmake{"Android", "aarch64"}, android: "arm64-v8a",
+		},
+		{
+			target: Target{Arch: "arm", SubArch: "v7", OS: "android", ObjectFormat: "elf", Environment: "androideabi", LibC: "bionic", FloatABI: "softfp"},
+			rust:   "armv7-linux-androideabi", zig: "arm-linux-androideabi",
+			cmake: cmake{"Android", "armv7-a"}, android: "armeabi-v7a",
+		},
+		{
+			target: Target{Arch: "x64", OS: "android", ObjectFormat: "elf", Environment: "android", LibC: "bionic"},
+			rust:   "x86_64-linux-android", zig: "x86_64-linux-android",
+			cmake: cmake{"Android", "x86_64"}, android: "x86_64",
+		},
+		{
+			target: Target{Arch: "arm", SubArch: "v7em", OS: "none", ObjectFormat: "elf", FloatABI: "hard"},
+			zig:    "arm-freestanding",
+			cmake:  cmake{"Generic", "armv7em"},
+		},
+		{
+			target: Target{Arch: "x64", OS: "freebsd", ObjectFormat: "elf", LibC: "unknown"},
+			rust:   "x86_64-unknown-freebsd", zig: "x86_64-freebsd",
+			cmake: cmake{"FreeBSD", "x86_64"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target.String(), func(t *testing.T) {
+			check := func(dialect, got, want string, err error) {
+				if err != nil {
+					t.Errorf("%s: unexpected error: %v", dialect, err)
+				} else if got != want {
+					t.Errorf("%s = %q, want %q", dialect, got, want)
+				}
+			}
+			parsed := func(dialect string, got Target, err error) {
+				if err != nil {
+					t.Errorf("%s: unexpected parse error: %v", dialect, err)
+				} else if !got.Match(tt.target) {
+					t.Errorf("%s: parsed %#v, want %#v", dialect, got, tt.target)
+				}
+			}
+
+			s, err := tt.target.Rust()
+			if tt.rust == "" {
+				if err == nil {
+					t.Errorf("Rust = %q, want an error", s)
+				}
+			} else {
+				check("Rust", s, tt.rust, err)
+				p, err := ParseRust(tt.rust)
+				parsed("ParseRust", p, err)
+			}
+
+			s, err = tt.target.Zig()
+			check("Zig", s, tt.zig, err)
+			p, err := ParseZig(tt.zig)
+			parsed("ParseZig", p, err)
+
+			s, err = tt.target.Debian()
+			if tt.debian == "" {
+				if err == nil {
+					t.Errorf("Debian = %q, want an error", s)
+				}
+			} else {
+				check("Debian", s, tt.debian, err)
+				s, err = tt.target.DebianArch()
+				check("DebianArch", s, tt.dpkg, err)
+				p, err = ParseDebian(tt.debian)
+				parsed("ParseDebian", p, err)
+				p, err = ParseDebian(tt.dpkg)
+				parsed("ParseDebian(dpkg)", p, err)
+			}
+
+			cm, err := tt.target.CMake()
+			check("CMake", cm.Name+" "+cm.Processor, tt.cmake.name+" "+tt.cmake.processor, err)
+			p, err = ParseCMake(tt.cmake.name, tt.cmake.processor)
+			if err == nil && p.LibC == "" {
+				// CMake does not specify the C library
+				p.LibC = tt.target.LibC
+			}
+			parsed("ParseCMake", p, err)
+
+			s, err = tt.target.AndroidABI()
+			if tt.android == "" {
+				if err == nil {
+					t.Errorf("AndroidABI = %q, want an error", s)
+				}
+			} else {
+				check("AndroidABI", s, tt.android, err)
+				p, err = ParseAndroidABI(tt.android)
+				parsed("ParseAndroidABI", p, err)
+			}
+		})
+	}
+}
+
+func TestDialects_ILP32(t *testing.T) {
+	tests := []struct {
+		triplet string
+		rust    string
+		zig     string
+		debian  string
+	}{
+		{"x86_64-linux-muslx32", "", "x86_64-linux-muslx32", ""},
+		{"aarch64-linux-gnuilp32", "aarch64-unknown-linux-gnu_ilp32", "aarch64-linux-gnuilp32", ""},
+	}
+	for _, tt := range tests {
+		tgt, _, err := ParseTarget(tt.triplet)
+		if err != nil {
+			t.Fatalf("ParseTarget(%q) error: %v", tt.triplet, err)
+		}
+		for _, d := range []struct {
+			name string
+			fn   func() (string, error)
+			want string
+		}{{"Rust", tgt.Rust, tt.rust}, {"Zig", tgt.Zig, tt.zig}, {"Debian", tgt.Debian, tt.debian}} {
+			got, err := d.fn()
+			if got != d.want || (err != nil) != (d.want == "") {
+				t.Errorf("%s %s() = %q, %v, want %q", tt.triplet, d.name, got, err, d.want)
+			}
+		}
+	}
+}
+
+func TestParseZig_Versions(t *testing.T) {
+	got, err := ParseZig("aarch64-macos.13...14-none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Arch != "arm64" || got.OS != "darwin" {
+		t.Errorf("ParseZig() = %#v", got)
+	}
+}
+
+func TestTarget_CMake_Parsed(t *testing.T) {
+	tests := []struct {
+		triplet   string
+		processor string
+	}{
+		{"i686-linux-gnu", "i686"},
+		{"i586-linux-musl", "i586"},
+		{"x86_64-linux-gnu", "x86_64"},
+		{"armv7-linux-gnueabihf", "armv7"},
+		{"aarch64-linux-gnu", "aarch64"},
+		{"i686-w64-mingw32", "X86"},
+	}
+	for _, tt := range tests {
+		target, _, err := ParseTarget(tt.triplet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cm, err := target.CMake()
+		if err != nil {
+			t.Errorf("%s: %v", tt.triplet, err)
+		} else if cm.Processor != tt.processor {
+			t.Errorf("%s: CMAKE_SYSTEM_PROCESSOR = %s, want %s", tt.triplet, cm.Processor, tt.processor)
+		}
+	}
+}
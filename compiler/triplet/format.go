@@ -0,0 +1,138 @@
+package triplet
+
+import (
+	"strings"
+)
+
+// Object file formats
+const (
+	ELF   = "elf"
+	PE    = "pe" // PE/COFF, used by both msvc and mingw targets
+	MachO = "macho"
+	Wasm  = "wasm"
+	XCOFF = "xcoff"
+	GOFF  = "goff"
+)
+
+// objectFormatMap maps object format names, as spelled in the trailing
+// component of LLVM triples (x86_64-pc-windows-msvc-elf), to their
+// normalized form
+var objectFormatMap = map[string]string{
+	"elf":   ELF,
+	"pe":    PE,
+	"coff":  PE,
+	"macho": MachO,
+	"wasm":  Wasm,
+	"xcoff": XCOFF,
+	"goff":  GOFF,
+}
+
+// environments lists the environment components known to LLVM and GNU
+// triples. Entries are matched as prefixes, longer names first, so that
+// versioned spellings like android24 and gnu.2.31 map to android and gnu.
+var environments = []string{
+	"androideabi", "android",
+	"gnuabin32", "gnuabi64", "gnueabihf", "gnueabi", "gnuf32", "gnuf64",
+	"gnusf", "gnux32", "gnuilp32", "gnu",
+	"musleabihf", "musleabi", "muslx32", "musl",
+	"eabihf", "eabi",
+	"msvc", "itanium", "cygnus", "coreclr", "code16",
+	"simulator", "macabi", "ohos", "uclibc", "threads",
+}
+
+// environmentAliases maps GNU spellings to the LLVM environment names
+var environmentAliases = map[string]string{
+	"mingw32": "gnu",
+	"mingw64": "gnu",
+	"mingw":   "gnu",
+	"cygwin":  "cygnus",
+	"msys":    "cygnus",
+}
+
+// ParseObjectFormat parses and normalizes an object format name.
+// Returns the normalized name and whether it was recognized.
+func ParseObjectFormat(s string) (string, bool) {
+	s = strings.ToLower(s)
+	if v, ok := objectFormatMap[s]; ok {
+		return v, true
+	}
+	return s, false
+}
+
+// NormalizeObjectFormat normalizes an object format name
+func NormalizeObjectFormat(s string) string {
+	n, _ := ParseObjectFormat(s)
+	return n
+}
+
+// ParseEnvironment parses and normalizes the environment component of a
+// triple (gnu, gnueabihf, musl, msvc, android, eabi, ...). Version suffixes
+// are dropped: android24 gives android.
+func ParseEnvironment(s string) (string, bool) {
+	s = strings.ToLower(s)
+	if v, ok := environmentAliases[s]; ok {
+		return v, true
+	}
+	for _, e := range environments {
+		if s == e {
+			return e, true
+		}
+	}
+	if e, v := splitEnvironment(s); v != "" {
+		return e, true
+	}
+	return s, false
+}
+
+// splitEnvironment separates the version from a known environment:
+// android24, gnu.2.31 (zig)
+func splitEnvironment(s string) (env, version string) {
+	s = strings.ToLower(s)
+	for _, e := range environments {
+		if !strings.HasPrefix(s, e) {
+			continue
+		}
+		if v := strings.TrimPrefix(s[len(e):], "."); isVersion(v) {
+			return e, v
+		}
+	}
+	return s, ""
+}
+
+// NormalizeEnvironment normalizes an environment name
+func NormalizeEnvironment(s string) string {
+	n, _ := ParseEnvironment(s)
+	return n
+}
+
+// isVersion checks if s is a dotted version number like 24 or 14.0
+func isVersion(s string) bool {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultObjectFormat returns the object format that the OS and the
+// architecture imply, following the LLVM defaults. Returns "unknown" when
+// neither is known.
+func DefaultObjectFormat(os, arch string) string {
+	switch {
+	case arch == "wasm32" || arch == "wasm64":
+		return Wasm
+	case os == "darwin" || os == "ios":
+		return MachO
+	case os == "windows" || os == "cygwin" || os == "msys":
+		return PE
+	case os == "aix":
+		return XCOFF
+	case (os == "" || os == "unknown") && (arch == "" || arch == "unknown"):
+		return "unknown"
+	}
+	return ELF
+}
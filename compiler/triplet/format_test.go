@@ -0,0 +1,206 @@
+package triplet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// triples as printed by clang -print-target-triple, gcc -dumpmachine and
+// rustc --print target-list, and the ones exercised by LLVM TripleTest
+func TestParseTarget_LLVMCorpus(t *testing.T) {
+	tests := []struct {
+		input  string
+		arch   string
+		os     string
+		format string
+		env    string
+		libc   string
+	}{
+		{"x86_64-pc-linux-gnu", "x64", "linux", "elf", "gnu", "glibc"},
+		{"x86_64-unknown-linux-gnu", "x64", "linux", "elf", "gnu", "glibc"},
+		{"x86_64-linux-gnux32", "x64", "linux", "elf", "gnux32", "glibc"},
+		{"x86_64-unknown-linux-musl", "x64", "linux", "elf", "musl", "musl"},
+		{"x86_64-alpine-linux-musl", "x64", "linux", "elf", "musl", "musl"},
+		{"i386-pc-linux-gnu", "x32", "linux", "elf", "gnu", "glibc"},
+		{"i686-linux-gnu", "x32", "linux", "elf", "gnu", "glibc"},
+		{"aarch64-linux-gnu", "arm64", "linux", "elf", "gnu", "glibc"},
+		{"aarch64-unknown-linux-gnu", "arm64", "linux", "elf", "gnu", "glibc"},
+		{"aarch64_be-linux-gnu", "arm64", "linux", "elf", "gnu", "glibc"},
+		{"aarch64-linux-gnu_ilp32", "arm64", "linux", "elf", "", "glibc"},
+		{"arm-linux-gnueabi", "arm", "linux", "elf", "gnueabi", "glibc"},
+		{"arm-linux-gnueabihf", "arm", "linux", "elf", "gnueabihf", "glibc"},
+		{"armv7-unknown-linux-musleabihf", "arm", "linux", "elf", "musleabihf", "musl"},
+		{"armv7l-unknown-linux-gnueabihf", "arm", "linux", "elf", "gnueabihf", "glibc"},
+		{"mips-linux-gnu", "mips", "linux", "elf", "gnu", "glibc"},
+		{"mipsel-linux-gnu", "mipsel", "linux", "elf", "gnu", "glibc"},
+		{"mips64el-linux-gnuabi64", "mips64el", "linux", "elf", "gnuabi64", "glibc"},
+		{"mips64-linux-gnuabin32", "mips64", "linux", "elf", "gnuabin32", "glibc"},
+		{"powerpc-linux-gnu", "powerpc", "linux", "elf", "gnu", "glibc"},
+		{"powerpc64le-linux-gnu", "ppc64le", "linux", "elf", "gnu", "glibc"},
+		{"riscv64-linux-gnu", "riscv64", "linux", "elf", "gnu", "glibc"},
+		{"riscv64-unknown-elf", "riscv64", "unknown", "elf", "", "unknown"},
+		{"riscv32-unknown-none-elf", "riscv32", "none", "elf", "", "unknown"},
+		{"loongarch64-linux-gnuf64", "loong64", "linux", "elf", "gnuf64", "glibc"},
+		{"s390x-ibm-linux", "s390x", "linux", "elf", "", "unknown"},
+		{"sparc64-linux-gnu", "sparc64", "linux", "elf", "gnu", "glibc"},
+		{"arm-none-eabi", "arm", "none", "elf", "eabi", "unknown"},
+		{"thumbv7em-none-eabihf", "arm", "none", "elf", "eabihf", "unknown"},
+		{"aarch64-none-elf", "arm64", "none", "elf", "", "unknown"},
+		{"avr-unknown-unknown", "avr", "unknown", "elf", "", "unknown"},
+		{"x86_64-pc-windows-msvc", "x64", "windows", "pe", "msvc", "msvcrt"},
+		{"i686-pc-windows-msvc", "x32", "windows", "pe", "msvc", "msvcrt"},
+		{"aarch64-pc-windows-msvc", "arm64", "windows", "pe", "msvc", "msvcrt"},
+		{"x86_64-pc-windows-gnu", "x64", "windows", "pe", "gnu", "mingw"},
+		{"x86_64-w64-windows-gnu", "x64", "windows", "pe", "gnu", "mingw"},
+		{"x86_64-w64-mingw32", "x64", "windows", "pe", "gnu", "mingw"},
+		{"i686-w64-mingw32", "x32", "windows", "pe", "gnu", "mingw"},
+		{"x86_64-pc-windows-msvc-elf", "x64", "windows", "elf", "msvc", "msvcrt"},
+		{"x86_64-pc-windows-coff", "x64", "windows", "pe", "", "unknown"},
+		{"x86_64-pc-cygwin", "x64", "cygwin", "pe", "cygnus", "glibc"},
+		{"x86_64-apple-darwin", "x64", "darwin", "macho", "", "unknown"},
+		{"arm64-apple-darwin23.1.0", "arm64", "darwin", "macho", "", "unknown"},
+		{"arm64-apple-macosx14.0.0", "arm64", "darwin", "macho", "", "unknown"},
+		{"arm64-apple-ios17.0", "arm64", "ios", "macho", "", "unknown"},
+		{"arm64-apple-ios17.0-simulator", "arm64", "ios", "macho", "simulator", "unknown"},
+		{"x86_64-apple-ios13.1-macabi", "x64", "ios", "macho", "macabi", "unknown"},
+		{"aarch64-linux-android", "arm64", "android", "elf", "android", "bionic"},
+		{"aarch64-linux-android34", "arm64", "android", "elf", "android", "bionic"},
+		{"armv7a-linux-androideabi21", "arm", "android", "elf", "androideabi", "bionic"},
+		{"x86_64-unknown-freebsd14.0", "x64", "freebsd", "elf", "", "unknown"},
+		{"x86_64-unknown-netbsd", "x64", "netbsd", "elf", "", "unknown"},
+		{"x86_64-unknown-openbsd", "x64", "openbsd", "elf", "", "unknown"},
+		{"x86_64-pc-solaris2.11", "x64", "solaris", "elf", "", "unknown"},
+		{"x86_64-unknown-illumos", "x64", "illumos", "elf", "", "unknown"},
+		{"x86_64-unknown-haiku", "x64", "haiku", "elf", "", "unknown"},
+		{"powerpc64-ibm-aix7.2.0.0", "ppc64", "aix", "xcoff", "", "unknown"},
+		{"wasm32-unknown-emscripten", "wasm32", "emscripten", "wasm", "", "unknown"},
+		{"wasm32-wasi", "wasm32", "wasi", "wasm", "", "unknown"},
+		{"wasm32-unknown-unknown", "wasm32", "unknown", "wasm", "", "unknown"},
+		{"wasm64-unknown-unknown", "wasm64", "unknown", "wasm", "", "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, _, err := ParseTarget(tt.input)
+			if err != nil {
+				t.Fatalf("ParseTarget(%q) error: %v", tt.input, err)
+			}
+			if got.Arch != tt.arch || got.OS != tt.os || got.ObjectFormat != tt.format ||
+				got.Environment != tt.env || got.LibC != tt.libc {
+				t.Errorf("ParseTarget(%q) = %s/%s/%s/%s/%s, want %s/%s/%s/%s/%s", tt.input,
+					got.Arch, got.OS, got.ObjectFormat, got.Environment, got.LibC,
+					tt.arch, tt.os, tt.format, tt.env, tt.libc)
+			}
+		})
+	}
+}
+
+func TestTarget_MatchEnvironment(t *testing.T) {
+	mingw, _, _ := ParseTarget("x86_64-w64-mingw32")
+	msvc, _, _ := ParseTarget("x86_64-pc-windows-msvc")
+	if mingw.ObjectFormat != msvc.ObjectFormat {
+		t.Errorf("object formats differ: %q and %q", mingw.ObjectFormat, msvc.ObjectFormat)
+	}
+	if mingw.Match(msvc) {
+		t.Errorf("mingw target matches msvc target")
+	}
+}
+
+func TestLegacyABI(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		yaml     string
+		expected Full
+	}{
+		{
+			name: "msvc",
+			json: `{"os":"windows","arch":"x64","abi":"pe","libc":"msvcrt","original":"x86_64-pc-windows-msvc"}`,
+			yaml: "os: windows\narch: x64\nabi: pe\nlibc: msvcrt\noriginal: x86_64-pc-windows-msvc\n",
+			expected: Full{
+				Target:   Target{OS: "windows", Arch: "x64", ObjectFormat: "pe", Environment: "msvc", LibC: "msvcrt"},
+				Original: "x86_64-pc-windows-msvc",
+			},
+		},
+		{
+			name: "mingw",
+			json: `{"os":"windows","arch":"x64","abi":"pe","libc":"mingw","vendors":["w64"]}`,
+			yaml: "os: windows\narch: x64\nabi: pe\nlibc: mingw\nvendors: [w64]\n",
+			expected: Full{
+				Target:  Target{OS: "windows", Arch: "x64", ObjectFormat: "pe", Environment: "gnu", LibC: "mingw"},
+				Vendors: []string{"w64"},
+			},
+		},
+		{
+			name: "darwin",
+			json: `{"os":"darwin","arch":"arm64","abi":"marcho"}`,
+			yaml: "os: darwin\narch: arm64\nabi: marcho\n",
+			expected: Full{
+				Target: Target{OS: "darwin", Arch: "arm64", ObjectFormat: "macho"},
+			},
+		},
+		{
+			name: "eabi",
+			json: `{"os":"none","arch":"arm","abi":"eabi"}`,
+			yaml: "os: none\narch: arm\nabi: eabi\n",
+			expected: Full{
+				Target: Target{OS: "none", Arch: "arm", ObjectFormat: "elf", Environment: "eabi"},
+			},
+		},
+		{
+			name: "current",
+			json: `{"os":"linux","arch":"x64","object-format":"elf","environment":"gnu","libc":"glibc"}`,
+			yaml: "os: linux\narch: x64\nobject-format: elf\nenvironment: gnu\nlibc: glibc\n",
+			expected: Full{
+				Target: Target{OS: "linux", Arch: "x64", ObjectFormat: "elf", Environment: "gnu", LibC: "glibc"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fj, fy Full
+			if err := json.Unmarshal([]byte(tt.json), &fj); err != nil {
+				t.Fatalf("json: %v", err)
+			}
+			if err := yaml.Unmarshal([]byte(tt.yaml), &fy); err != nil {
+				t.Fatalf("yaml: %v", err)
+			}
+			for _, got := range []Full{fj, fy} {
+				if got.Target != tt.expected.Target || got.Original != tt.expected.Original ||
+					len(got.Vendors) != len(tt.expected.Vendors) {
+					t.Errorf("got %#v, want %#v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestFull_RoundTrip(t *testing.T) {
+	f, err := ParseFull("armv7-unknown-linux-gnueabihf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Full
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Target != f.Target || got.Original != f.Original {
+		t.Errorf("json round trip: got %#v, want %#v", got, f)
+	}
+	b, err = yaml.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = Full{}
+	if err := yaml.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Target != f.Target || got.Original != f.Original {
+		t.Errorf("yaml round trip: got %#v, want %#v", got, f)
+	}
+}
@@ -0,0 +1,203 @@
+package triplet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GoTarget is a Go build target as specified with GOOS, GOARCH and the
+// architecture-specific GOARM and GO386 variables
+type GoTarget struct {
+	GOOS   string `json:"goos" yaml:"goos"`
+	GOARCH string `json:"goarch" yaml:"goarch"`
+	GOARM  string `json:"goarm,omitempty" yaml:"goarm,omitempty"` // 5|6|7, empty for the Go default
+	GO386  string `json:"go386,omitempty" yaml:"go386,omitempty"` // sse2|softfloat, empty for the Go default
+}
+
+// goArchs maps normalized architectures to GOARCH values
+var goArchs = map[string]string{
+	"x64":      "amd64",
+	"x32":      "386",
+	"arm":      "arm",
+	"arm64":    "arm64",
+	"loong64":  "loong64",
+	"mips":     "mips",
+	"mipsel":   "mipsle",
+	"mips64":   "mips64",
+	"mips64el": "mips64le",
+	"ppc64":    "ppc64",
+	"ppc64le":  "ppc64le",
+	"riscv64":  "riscv64",
+	"s390x":    "s390x",
+	"wasm32":   "wasm",
+}
+
+// goOSes maps normalized operating systems to GOOS values. Go uses js for
+// the browser (emscripten-compatible) and wasip1 for WASI.
+var goOSes = map[string]string{
+	"aix":        "aix",
+	"android":    "android",
+	"darwin":     "darwin",
+	"dragonfly":  "dragonfly",
+	"freebsd":    "freebsd",
+	"illumos":    "illumos",
+	"ios":        "ios",
+	"emscripten": "js",
+	"linux":      "linux",
+	"netbsd":     "netbsd",
+	"openbsd":    "openbsd",
+	"solaris":    "solaris",
+	"wasi":       "wasip1",
+	"windows":    "windows",
+}
+
+// GoOSArch converts the target to Go GOOS/GOARCH values. For arm targets
+// with an explicit sub-architecture (v5, v6, v7) GOARM is set as well.
+func (t Target) GoOSArch() (GoTarget, error) {
+	g := GoTarget{GOOS: goOSes[t.OS]}
+	if g.GOOS == "" {
+		return GoTarget{}, &ErrInvalidTarget{t.String(), fmt.Sprintf("operating system '%s' is not supported by Go", t.OS)}
+	}
+	if t.Arch == "arm" {
+		g.GOARM = armVersion(t.SubArch)
+	}
+	g.GOARCH = goArchs[t.Arch]
+	if g.GOARCH == "" {
+		return GoTarget{}, &ErrInvalidTarget{t.String(), fmt.Sprintf("architecture '%s' is not supported by Go", t.Arch)}
+	}
+	if ilp32Environment(t.Environment) {
+		return GoTarget{}, &ErrInvalidTarget{t.String(), fmt.Sprintf("environment '%s' is not supported by Go", t.Environment)}
+	}
+	if (g.GOARCH == "wasm") != (t.IsEmscripten() || t.IsWASI()) {
+		return GoTarget{}, &ErrInvalidTarget{t.String(), "Go supports wasm only with js and wasip1"}
+	}
+	if p := t.WASIPreview(); p > 1 || t.IsWASI() && t.HasThreads() {
+		return GoTarget{}, &ErrInvalidTarget{t.String(), "Go supports only WASI preview 1 without threads"}
+	}
+	return g, nil
+}
+
+// FromGo converts Go GOOS/GOARCH values to a target. Fields that Go does not
+// determine are left empty, they act as wildcards in Match: e.g. linux
+// targets match both glibc and musl toolchains.
+//
+// GOARM selects the arm sub-architecture (v5, v6, v7), GO386
+// affects only the code generated by Go and is validated but otherwise
+// ignored.
+func FromGo(goos, goarch, goarm, go386 string) (Target, error) {
+	t := Target{}
+	for os, g := range goOSes {
+		if g == goos {
+			t.OS = os
+			break
+		}
+	}
+	if t.OS == "" {
+		return Target{}, &ErrInvalidTarget{goos + "/" + goarch, fmt.Sprintf("unsupported GOOS '%s'", goos)}
+	}
+	for arch, g := range goArchs {
+		if g == goarch {
+			t.Arch = arch
+			break
+		}
+	}
+	if t.Arch == "" {
+		return Target{}, &ErrInvalidTarget{goos + "/" + goarch, fmt.Sprintf("unsupported GOARCH '%s'", goarch)}
+	}
+
+	if goarm != "" {
+		if goarch != "arm" {
+			return Target{}, &ErrInvalidTarget{goos + "/" + goarch, "GOARM is valid only for arm"}
+		}
+		// GOARM=7,softfloat and GOARM=7,hardfloat since Go 1.22
+		v, _, _ := strings.Cut(goarm, ",")
+		switch v {
+		case "5", "6", "7":
+			t.SubArch = "v" + v
+		default:
+			return Target{}, &ErrInvalidTarget{goos + "/" + goarch, fmt.Sprintf("invalid GOARM '%s'", goarm)}
+		}
+	}
+	switch go386 {
+	case "", "sse2", "softfloat":
+	default:
+		return Target{}, &ErrInvalidTarget{goos + "/" + goarch, fmt.Sprintf("invalid GO386 '%s'", go386)}
+	}
+
+	switch t.OS {
+	case "windows":
+		// cgo requires a gcc-compatible mingw toolchain
+		t.Environment, t.LibC = "gnu", "mingw"
+	case "android":
+		t.LibC = "bionic"
+	case "emscripten", "wasi":
+		if t.Arch != "wasm32" {
+			return Target{}, &ErrInvalidTarget{goos + "/" + goarch, "js and wasip1 require GOARCH=wasm"}
+		}
+		if t.OS == "wasi" {
+			t.OSVersion = "0.1"
+		}
+	}
+	t.ObjectFormat = DefaultObjectFormat(t.OS, t.Arch)
+	if t.IsWasm() && !t.IsEmscripten() && !t.IsWASI() {
+		return Target{}, &ErrInvalidTarget{goos + "/" + goarch, "GOARCH=wasm requires GOOS=js or GOOS=wasip1"}
+	}
+	t.fillArchTraits()
+	return t, nil
+}
+
+// ilp32Environment checks for the environments that run 32-bit pointer code
+// on a 64-bit architecture (x86_64 x32 ABI, aarch64 ILP32)
+func ilp32Environment(env string) bool {
+	switch env {
+	case "gnux32", "muslx32", "gnuilp32":
+		return true
+	}
+	return false
+}
+
+// armVersion extracts the GOARM value from arm sub-architectures such as
+// v7, v6k, v5te
+func armVersion(sub string) string {
+	if len(sub) < 2 || sub[0] != 'v' {
+		return ""
+	}
+	switch sub[1] {
+	case '5', '6', '7':
+		return sub[1:2]
+	}
+	return ""
+}
+
+// Env returns GOOS, GOARCH, and when specified GOARM and GO386 as
+// environment variable assignments
+func (g GoTarget) Env() []string {
+	ret := []string{"GOOS=" + g.GOOS, "GOARCH=" + g.GOARCH}
+	if g.GOARM != "" {
+		ret = append(ret, "GOARM="+g.GOARM)
+	}
+	if g.GO386 != "" {
+		ret = append(ret, "GO386="+g.GO386)
+	}
+	return ret
+}
+
+func (g GoTarget) String() string {
+	return g.GOOS + "/" + g.GOARCH
+}
+
+// MatchGo checks if a toolchain for this target can be used for cgo builds
+// for the Go target. Unlike Match, it accepts arm toolchains without an
+// explicit architecture version for any GOARM, and rejects msvc toolchains
+// which cgo does not support.
+func (t Target) MatchGo(g GoTarget) bool {
+	tg, err := t.GoOSArch()
+	if err != nil || tg.GOOS != g.GOOS || tg.GOARCH != g.GOARCH {
+		return false
+	}
+	goarm, _, _ := strings.Cut(g.GOARM, ",")
+	if tg.GOARM != "" && goarm != "" && tg.GOARM != goarm {
+		return false
+	}
+	return t.LibC != "msvcrt"
+}
@@ -0,0 +1,139 @@
+package triplet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromGo_RoundTrip(t *testing.T) {
+	// go tool dist list, except plan9 which has no C toolchains
+	dist := []string{
+		"aix/ppc64", "android/386", "android/amd64", "android/arm", "android/arm64",
+		"darwin/amd64", "darwin/arm64", "dragonfly/amd64", "freebsd/386", "freebsd/amd64",
+		"freebsd/arm", "freebsd/arm64", "freebsd/riscv64", "illumos/amd64", "ios/amd64",
+		"ios/arm64", "js/wasm", "linux/386", "linux/amd64", "linux/arm", "linux/arm64",
+		"linux/loong64", "linux/mips", "linux/mips64", "linux/mips64le", "linux/mipsle",
+		"linux/ppc64", "linux/ppc64le", "linux/riscv64", "linux/s390x", "netbsd/386",
+		"netbsd/amd64", "netbsd/arm", "netbsd/arm64", "openbsd/386", "openbsd/amd64",
+		"openbsd/arm", "openbsd/arm64", "openbsd/ppc64", "openbsd/riscv64", "solaris/amd64",
+		"wasip1/wasm", "windows/386", "windows/amd64", "windows/arm64",
+	}
+	for _, s := range dist {
+		goos, goarch, _ := strings.Cut(s, "/")
+		tgt, err := FromGo(goos, goarch, "", "")
+		if err != nil {
+			t.Errorf("FromGo(%s) unexpected error: %v", s, err)
+			continue
+		}
+		g, err := tgt.GoOSArch()
+		if err != nil {
+			t.Errorf("%s: GoOSArch() unexpected error: %v", s, err)
+			continue
+		}
+		if g.String() != s {
+			t.Errorf("%s: round trip gives %s (target %s)", s, g, tgt)
+		}
+		if !tgt.MatchGo(g) {
+			t.Errorf("%s: target %s does not match itself", s, tgt)
+		}
+	}
+}
+
+func TestGoOSArch(t *testing.T) {
+	tests := []struct {
+		triplet string
+		goos    string
+		goarch  string
+		goarm   string
+		err     bool
+	}{
+		{"x86_64-linux-gnu", "linux", "amd64", "", false},
+		{"i686-w64-mingw32", "windows", "386", "", false},
+		{"aarch64-linux-android", "android", "arm64", "", false},
+		{"armv7a-linux-androideabi", "android", "arm", "7", false},
+		{"arm-linux-gnueabihf", "linux", "arm", "", false},
+		{"armv6-unknown-linux-gnueabihf", "linux", "arm", "6", false},
+		{"mipsel-linux-gnu", "linux", "mipsle", "", false},
+		{"powerpc64le-linux-gnu", "linux", "ppc64le", "", false},
+		{"loongarch64-linux-gnu", "linux", "loong64", "", false},
+		{"arm64-apple-ios", "ios", "arm64", "", false},
+		{"arm64-apple-macosx14.0", "darwin", "arm64", "", false},
+		{"wasm32-unknown-emscripten", "js", "wasm", "", false},
+		{"wasm32-wasi", "wasip1", "wasm", "", false},
+		{"x86_64-unknown-illumos", "illumos", "amd64", "", false},
+		{"avr-none-elf", "", "", "", true},
+		{"x86_64-linux-gnux32", "", "", "", true},
+		{"x86_64-linux-muslx32", "", "", "", true},
+		{"aarch64-linux-gnuilp32", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.triplet, func(t *testing.T) {
+			tgt, _, err := ParseTarget(tt.triplet)
+			if err != nil {
+				t.Fatalf("ParseTarget(%q) unexpected error: %v", tt.triplet, err)
+			}
+			g, err := tgt.GoOSArch()
+			if (err != nil) != tt.err {
+				t.Fatalf("GoOSArch() error = %v, want error %v", err, tt.err)
+			}
+			if g.GOOS != tt.goos || g.GOARCH != tt.goarch || g.GOARM != tt.goarm {
+				t.Errorf("GoOSArch() = %s GOARM=%s, want %s/%s GOARM=%s", g, g.GOARM, tt.goos, tt.goarch, tt.goarm)
+			}
+		})
+	}
+}
+
+func TestFromGo(t *testing.T) {
+	tests := []struct {
+		goos, goarch, goarm, go386 string
+		expected                   Target
+		err                        bool
+	}{
+		{"linux", "arm", "7", "", Target{Arch: "arm", SubArch: "v7", OS: "linux", ObjectFormat: "elf", Endian: "little", PointerWidth: 32}, false},
+		{"linux", "arm", "7,hardfloat", "", Target{Arch: "arm", SubArch: "v7", OS: "linux", ObjectFormat: "elf", Endian: "little", PointerWidth: 32}, false},
+		{"windows", "amd64", "", "", Target{Arch: "x64", OS: "windows", ObjectFormat: "pe", Environment: "gnu", LibC: "mingw", Endian: "little", PointerWidth: 64}, false},
+		{"android", "arm64", "", "", Target{Arch: "arm64", OS: "android", ObjectFormat: "elf", LibC: "bionic", Endian: "little", PointerWidth: 64}, false},
+		{"android", "arm", "", "", Target{Arch: "arm", OS: "android", ObjectFormat: "elf", LibC: "bionic", FloatABI: "softfp", Endian: "little", PointerWidth: 32}, false},
+		{"linux", "386", "", "softfloat", Target{Arch: "x32", OS: "linux", ObjectFormat: "elf", Endian: "little", PointerWidth: 32}, false},
+		{"linux", "mips64le", "", "", Target{Arch: "mips64el", OS: "linux", ObjectFormat: "elf", Endian: "little", PointerWidth: 64}, false},
+		{"linux", "amd64", "7", "", Target{}, true},
+		{"linux", "arm", "8", "", Target{}, true},
+		{"linux", "wasm", "", "", Target{}, true},
+		{"plan9", "amd64", "", "", Target{}, true},
+	}
+	for _, tt := range tests {
+		got, err := FromGo(tt.goos, tt.goarch, tt.goarm, tt.go386)
+		if (err != nil) != tt.err {
+			t.Errorf("FromGo(%s, %s, %s, %s) error = %v", tt.goos, tt.goarch, tt.goarm, tt.go386, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("FromGo(%s, %s, %s, %s) = %#v, want %#v", tt.goos, tt.goarch, tt.goarm, tt.go386, got, tt.expected)
+		}
+	}
+}
+
+func TestTarget_MatchGo(t *testing.T) {
+	tests := []struct {
+		triplet string
+		goarm   string
+		goos    string
+		goarch  string
+		want    bool
+	}{
+		{"arm-linux-gnueabihf", "7", "linux", "arm", true},
+		{"armv7-linux-gnueabihf", "7", "linux", "arm", true},
+		{"armv6-linux-gnueabihf", "7", "linux", "arm", false},
+		{"x86_64-w64-mingw32", "", "windows", "amd64", true},
+		{"x86_64-windows-msvc", "", "windows", "amd64", false},
+		{"aarch64-linux-gnu", "", "linux", "amd64", false},
+		{"x86_64-linux-gnux32", "", "linux", "amd64", false},
+	}
+	for _, tt := range tests {
+		tgt, _, _ := ParseTarget(tt.triplet)
+		g := GoTarget{GOOS: tt.goos, GOARCH: tt.goarch, GOARM: tt.goarm}
+		if got := tgt.MatchGo(g); got != tt.want {
+			t.Errorf("%s MatchGo(%s GOARM=%s) = %v, want %v", tt.triplet, g, tt.goarm, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package triplet
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Earlier versions stored a single "abi" field that mixed object formats
+// (elf, pe, marcho) with environments (eabi, eabisim). Documents written by
+// them are still accepted: the field is split into ObjectFormat and
+// Environment when these are not present.
+
+// migrateABI fills ObjectFormat and Environment from a legacy abi value
+func (t *Target) migrateABI(abi string) {
+	if abi == "" || t.ObjectFormat != "" || t.Environment != "" {
+		return
+	}
+	switch abi {
+	case "marcho":
+		t.ObjectFormat = MachO
+	case "unknown":
+		t.ObjectFormat = DefaultObjectFormat(t.OS, t.Arch)
+	case "eabi", "eabisim":
+		t.ObjectFormat, t.Environment = ELF, abi
+	default:
+		if v, ok := ParseObjectFormat(abi); ok {
+			t.ObjectFormat = v
+		} else if v, ok := ParseEnvironment(abi); ok {
+			t.ObjectFormat, t.Environment = DefaultObjectFormat(t.OS, t.Arch), v
+		} else {
+			t.ObjectFormat = abi
+		}
+	}
+	if t.ObjectFormat == PE && t.Environment == "" {
+		// msvc and mingw were told apart only by the C library
+		switch t.LibC {
+		case "msvcrt":
+			t.Environment = "msvc"
+		case "mingw":
+			t.Environment = "gnu"
+		}
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the legacy abi field
+// and the text form in a string
+func (t *Target) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		return t.unmarshalTextJSON(b)
+	}
+	type plain Target
+	v := struct {
+		*plain
+		ABI string `json:"abi"`
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	t.migrateABI(v.ABI)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, accepting the legacy abi field
+// and the text form in a scalar
+func (t *Target) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return t.unmarshalTextYAML(node)
+	}
+	type plain Target
+	v := struct {
+		plain `yaml:",inline"`
+		ABI   string `yaml:"abi"`
+	}{}
+	if err := node.Decode(&v); err != nil {
+		return err
+	}
+	*t = Target(v.plain)
+	t.migrateABI(v.ABI)
+	return nil
+}
+
+// fullExtra holds the fields that Full adds to Target
+type fullExtra struct {
+	Original string   `json:"original" yaml:"original"`
+	Vendors  []string `json:"vendors" yaml:"vendors"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It is required because the
+// method promoted from the embedded Target would skip the Full fields. The
+// text form in a string is kept as Original.
+func (f *Full) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return f.unmarshalText(s)
+	}
+	var x fullExtra
+	if err := json.Unmarshal(b, &x); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &f.Target); err != nil {
+		return err
+	}
+	f.Original, f.Vendors = x.Original, x.Vendors
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler. It is required because the
+// method promoted from the embedded Target would skip the Full fields. The
+// text form in a scalar is kept as Original.
+func (f *Full) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return f.unmarshalText(node.Value)
+	}
+	var x fullExtra
+	if err := node.Decode(&x); err != nil {
+		return err
+	}
+	if err := node.Decode(&f.Target); err != nil {
+		return err
+	}
+	f.Original, f.Vendors = x.Original, x.Vendors
+	return nil
+}
+
+// unmarshalText sets the target from its text form
+func (f *Full) unmarshalText(s string) error {
+	t, err := ParseText(s)
+	if err != nil {
+		return err
+	}
+	*f = Full{Target: t, Original: s}
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler. yaml.v3 does not encode inlined
+// fields that implement yaml.Unmarshaler, so Target is inlined as a plain
+// struct.
+func (f Full) MarshalYAML() (interface{}, error) {
+	type plain Target
+	return struct {
+		plain    `yaml:",inline"`
+		Original string   `yaml:"original,omitempty"`
+		Vendors  []string `yaml:"vendors,flow,omitempty"`
+	}{plain(f.Target), f.Original, f.Vendors}, nil
+}
@@ -0,0 +1,262 @@
+package triplet
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Naming describes how the linker and the build tools name the files
+// produced for a target
+type Naming struct {
+	ExecutableSuffix string `json:"executable-suffix,omitempty" yaml:"executable-suffix,omitempty"` // .exe, .js, .wasm
+	ObjectSuffix     string `json:"object-suffix" yaml:"object-suffix"`                             // .o, .obj
+	StaticPrefix     string `json:"static-prefix,omitempty" yaml:"static-prefix,omitempty"`
+	StaticSuffix     string `json:"static-suffix" yaml:"static-suffix"` // .a, .lib
+	SharedPrefix     string `json:"shared-prefix,omitempty" yaml:"shared-prefix,omitempty"`
+	SharedSuffix     string `json:"shared-suffix" yaml:"shared-suffix"` // .so, .dll, .dylib
+	ImportPrefix     string `json:"import-prefix,omitempty" yaml:"import-prefix,omitempty"`
+	ImportSuffix     string `json:"import-suffix,omitempty" yaml:"import-suffix,omitempty"` // .lib, .dll.a, empty when not used
+	DebugSuffix      string `json:"debug-suffix" yaml:"debug-suffix"`                       // .pdb, .dSYM, .debug
+}
+
+// IsMSVC checks if the target uses the msvc environment. Windows targets
+// that specify neither the environment nor the C library default to msvc,
+// as in LLVM.
+func (t Target) IsMSVC() bool {
+	if t.OS != "windows" {
+		return false
+	}
+	switch {
+	case t.Environment == "msvc" || t.LibC == "msvcrt":
+		return true
+	case t.Environment == "" && (t.LibC == "" || t.LibC == "unknown"):
+		return true
+	}
+	return false
+}
+
+// Naming returns the file naming conventions of the target. These follow
+// the defaults of the platform linkers and match the CMake platform files.
+func (t Target) Naming() Naming {
+	switch {
+	case t.IsMSVC():
+		return Naming{
+			ExecutableSuffix: ".exe",
+			ObjectSuffix:     ".obj",
+			StaticSuffix:     ".lib",
+			SharedSuffix:     ".dll",
+			ImportSuffix:     ".lib",
+			DebugSuffix:      ".pdb",
+		}
+	case t.OS == "windows":
+		// mingw
+		return Naming{
+			ExecutableSuffix: ".exe",
+			ObjectSuffix:     ".o",
+			StaticPrefix:     "lib",
+			StaticSuffix:     ".a",
+			SharedPrefix:     "lib",
+			SharedSuffix:     ".dll",
+			ImportPrefix:     "lib",
+			ImportSuffix:     ".dll.a",
+			DebugSuffix:      ".debug",
+		}
+	case t.OS == "cygwin" || t.OS == "msys":
+		prefix := "cyg"
+		if t.OS == "msys" {
+			prefix = "msys-"
+		}
+		return Naming{
+			ExecutableSuffix: ".exe",
+			ObjectSuffix:     ".o",
+			StaticPrefix:     "lib",
+			StaticSuffix:     ".a",
+			SharedPrefix:     prefix,
+			SharedSuffix:     ".dll",
+			ImportPrefix:     "lib",
+			ImportSuffix:     ".dll.a",
+			DebugSuffix:      ".debug",
+		}
+	case t.IsDarwin():
+		return Naming{
+			ObjectSuffix: ".o",
+			StaticPrefix: "lib",
+			StaticSuffix: ".a",
+			SharedPrefix: "lib",
+			SharedSuffix: ".dylib",
+			DebugSuffix:  ".dSYM",
+		}
+	case t.OS == "emscripten":
+		// the .js loader is accompanied by the .wasm module
+		return Naming{
+			ExecutableSuffix: ".js",
+			ObjectSuffix:     ".o",
+			StaticPrefix:     "lib",
+			StaticSuffix:     ".a",
+			SharedPrefix:     "lib",
+			SharedSuffix:     ".wasm",
+			DebugSuffix:      ".debug.wasm",
+		}
+	case t.IsWasm():
+		return Naming{
+			ExecutableSuffix: ".wasm",
+			ObjectSuffix:     ".o",
+			StaticPrefix:     "lib",
+			StaticSuffix:     ".a",
+			SharedPrefix:     "lib",
+			SharedSuffix:     ".so",
+			DebugSuffix:      ".debug.wasm",
+		}
+	}
+	return Naming{
+		ObjectSuffix: ".o",
+		StaticPrefix: "lib",
+		StaticSuffix: ".a",
+		SharedPrefix: "lib",
+		SharedSuffix: ".so",
+		DebugSuffix:  ".debug",
+	}
+}
+
+// Executable returns the executable file name, e.g. app.exe on windows.
+// Names that already have the suffix are returned unchanged.
+func (t Target) Executable(name string) string {
+	return withSuffix(name, t.Naming().ExecutableSuffix)
+}
+
+// ExecutableFiles returns all the files that make up an executable: the
+// emscripten .js loader also needs the .wasm module next to it.
+func (t Target) ExecutableFiles(name string) []string {
+	exe := t.Executable(name)
+	if t.OS == "emscripten" {
+		return []string{exe, strings.TrimSuffix(exe, ".js") + ".wasm"}
+	}
+	return []string{exe}
+}
+
+// ObjectFile returns the object file name for the source file, e.g.
+// main.cpp gives main.o or main.obj
+func (t Target) ObjectFile(src string) string {
+	return strings.TrimSuffix(src, filepath.Ext(src)) + t.Naming().ObjectSuffix
+}
+
+// StaticLibrary returns the static library file name, e.g. libfoo.a or
+// foo.lib
+func (t Target) StaticLibrary(name string) string {
+	n := t.Naming()
+	return n.StaticPrefix + name + n.StaticSuffix
+}
+
+// ImportLibrary returns the import library file name for a DLL, e.g.
+// foo.lib for msvc or libfoo.dll.a for mingw. Returns an empty string for
+// the targets that link shared libraries directly.
+func (t Target) ImportLibrary(name string) string {
+	n := t.Naming()
+	if n.ImportSuffix == "" {
+		return ""
+	}
+	return n.ImportPrefix + name + n.ImportSuffix
+}
+
+// SharedLibrary returns the file name of the shared library, including the
+// version when the platform puts it in the file name:
+//   - elf: libfoo.so.1.2.3
+//   - darwin: libfoo.1.2.3.dylib
+//   - cygwin: cygfoo-1.dll, with the major version only
+//   - windows: foo.dll or libfoo.dll, without a version
+func (t Target) SharedLibrary(name, version string) string {
+	n := t.Naming()
+	base := n.SharedPrefix + name
+	switch {
+	case version == "":
+		return base + n.SharedSuffix
+	case t.IsDarwin():
+		return base + "." + version + n.SharedSuffix
+	case t.OS == "cygwin" || t.OS == "msys":
+		return base + "-" + majorVersion(version) + n.SharedSuffix
+	case n.SharedSuffix == ".so" && !t.IsWasm():
+		return base + n.SharedSuffix + "." + version
+	}
+	return base + n.SharedSuffix
+}
+
+// Soname returns the name that executables record to load the shared
+// library at run time: the ELF DT_SONAME (libfoo.so.1) or the Mach-O
+// install name (libfoo.1.dylib). Only the major version is kept.
+func (t Target) Soname(name, version string) string {
+	return t.SharedLibrary(name, majorVersion(version))
+}
+
+// SharedLibraryLinks returns the names of a versioned shared library as
+// installed: the real file, the soname symlink, and the development link
+// name that the linker finds with -lfoo. On platforms without versioned
+// names all three are the same.
+func (t Target) SharedLibraryLinks(name, version string) (real, soname, link string) {
+	return t.SharedLibrary(name, version), t.Soname(name, version), t.SharedLibrary(name, "")
+}
+
+// DebugFile returns the name of the separate debug information for the
+// artifact: app.pdb for app.exe with msvc, app.dSYM on darwin,
+// libfoo.so.debug elsewhere
+func (t Target) DebugFile(artifact string) string {
+	n := t.Naming()
+	switch {
+	case t.IsMSVC():
+		return strings.TrimSuffix(artifact, filepath.Ext(artifact)) + n.DebugSuffix
+	case t.IsWasm():
+		for _, ext := range []string{".js", ".wasm"} {
+			artifact = strings.TrimSuffix(artifact, ext)
+		}
+	}
+	return artifact + n.DebugSuffix
+}
+
+// LibrarySearchNames returns the file names the linker tries, in order, in
+// each library directory when resolving -lname (or name.lib with msvc).
+// With static set, only static archives are considered, as with -static or
+// -Bstatic.
+func (t Target) LibrarySearchNames(name string, static bool) []string {
+	switch {
+	case t.IsMSVC():
+		return []string{name + ".lib"}
+	case t.OS == "windows" || t.OS == "cygwin" || t.OS == "msys":
+		// GNU ld on PE, see "ld: WIN32" in the ld manual
+		if static {
+			return []string{"lib" + name + ".a", name + ".lib"}
+		}
+		ret := []string{
+			"lib" + name + ".dll.a", name + ".dll.a",
+			"lib" + name + ".a", name + ".lib",
+		}
+		if p := t.Naming().SharedPrefix; p != "lib" {
+			ret = append(ret, p+name+".dll")
+		}
+		return append(ret, "lib"+name+".dll", name+".dll")
+	case t.IsDarwin():
+		// ld64 with the default -search_paths_first
+		if static {
+			return []string{"lib" + name + ".a"}
+		}
+		return []string{"lib" + name + ".tbd", "lib" + name + ".dylib", "lib" + name + ".a"}
+	case t.OS == "emscripten":
+		return []string{"lib" + name + ".a"}
+	}
+	if static {
+		return []string{"lib" + name + ".a"}
+	}
+	return []string{"lib" + name + ".so", "lib" + name + ".a"}
+}
+
+func withSuffix(name, suffix string) string {
+	if suffix == "" || strings.HasSuffix(strings.ToLower(name), suffix) {
+		return name
+	}
+	return name + suffix
+}
+
+func majorVersion(v string) string {
+	if i := strings.IndexByte(v, '.'); i >= 0 {
+		return v[:i]
+	}
+	return v
+}
@@ -0,0 +1,117 @@
+package triplet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTarget_Naming(t *testing.T) {
+	tests := []struct {
+		triple  string
+		exe     string
+		static  string
+		shared  string
+		soname  string
+		link    string
+		implib  string
+		object  string
+		debug   string
+		search  []string
+		sstatic []string
+	}{
+		{
+			triple: "x86_64-linux-gnu",
+			exe:    "app", static: "libfoo.a",
+			shared: "libfoo.so.1.2.3", soname: "libfoo.so.1", link: "libfoo.so",
+			object: "main.o", debug: "app.debug",
+			search:  []string{"libfoo.so", "libfoo.a"},
+			sstatic: []string{"libfoo.a"},
+		},
+		{
+			triple: "x86_64-pc-windows-msvc",
+			exe:    "app.exe", static: "foo.lib",
+			shared: "foo.dll", soname: "foo.dll", link: "foo.dll", implib: "foo.lib",
+			object: "main.obj", debug: "app.pdb",
+			search:  []string{"foo.lib"},
+			sstatic: []string{"foo.lib"},
+		},
+		{
+			triple: "x86_64-w64-mingw32",
+			exe:    "app.exe", static: "libfoo.a",
+			shared: "libfoo.dll", soname: "libfoo.dll", link: "libfoo.dll", implib: "libfoo.dll.a",
+			object: "main.o", debug: "app.exe.debug",
+			search:  []string{"libfoo.dll.a", "foo.dll.a", "libfoo.a", "foo.lib", "libfoo.dll", "foo.dll"},
+			sstatic: []string{"libfoo.a", "foo.lib"},
+		},
+		{
+			triple: "x86_64-pc-cygwin",
+			exe:    "app.exe", static: "libfoo.a",
+			shared: "cygfoo-1.dll", soname: "cygfoo-1.dll", link: "cygfoo.dll", implib: "libfoo.dll.a",
+			object: "main.o", debug: "app.exe.debug",
+			search:  []string{"libfoo.dll.a", "foo.dll.a", "libfoo.a", "foo.lib", "cygfoo.dll", "libfoo.dll", "foo.dll"},
+			sstatic: []string{"libfoo.a", "foo.lib"},
+		},
+		{
+			triple: "arm64-apple-darwin",
+			exe:    "app", static: "libfoo.a",
+			shared: "libfoo.1.2.3.dylib", soname: "libfoo.1.dylib", link: "libfoo.dylib",
+			object: "main.o", debug: "app.dSYM",
+			search:  []string{"libfoo.tbd", "libfoo.dylib", "libfoo.a"},
+			sstatic: []string{"libfoo.a"},
+		},
+		{
+			triple: "wasm32-unknown-emscripten",
+			exe:    "app.js", static: "libfoo.a",
+			shared: "libfoo.wasm", soname: "libfoo.wasm", link: "libfoo.wasm",
+			object: "main.o", debug: "app.debug.wasm",
+			search:  []string{"libfoo.a"},
+			sstatic: []string{"libfoo.a"},
+		},
+		{
+			triple: "wasm32-wasi",
+			exe:    "app.wasm", static: "libfoo.a",
+			shared: "libfoo.so", soname: "libfoo.so", link: "libfoo.so",
+			object: "main.o", debug: "app.debug.wasm",
+			search:  []string{"libfoo.so", "libfoo.a"},
+			sstatic: []string{"libfoo.a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.triple, func(t *testing.T) {
+			target, _, err := ParseTarget(tt.triple)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check := func(what, got, want string) {
+				if got != want {
+					t.Errorf("%s = %q, want %q", what, got, want)
+				}
+			}
+			exe := target.Executable("app")
+			check("Executable", exe, tt.exe)
+			check("Executable (idempotent)", target.Executable(exe), tt.exe)
+			check("StaticLibrary", target.StaticLibrary("foo"), tt.static)
+			real, soname, link := target.SharedLibraryLinks("foo", "1.2.3")
+			check("SharedLibrary", real, tt.shared)
+			check("Soname", soname, tt.soname)
+			check("link name", link, tt.link)
+			check("ImportLibrary", target.ImportLibrary("foo"), tt.implib)
+			check("ObjectFile", target.ObjectFile("src/main.cpp"), "src/"+tt.object)
+			check("DebugFile", target.DebugFile(exe), tt.debug)
+			if got := target.LibrarySearchNames("foo", false); !reflect.DeepEqual(got, tt.search) {
+				t.Errorf("LibrarySearchNames = %v, want %v", got, tt.search)
+			}
+			if got := target.LibrarySearchNames("foo", true); !reflect.DeepEqual(got, tt.sstatic) {
+				t.Errorf("LibrarySearchNames(static) = %v, want %v", got, tt.sstatic)
+			}
+		})
+	}
+}
+
+func TestTarget_ExecutableFiles(t *testing.T) {
+	target, _, _ := ParseTarget("wasm32-unknown-emscripten")
+	got := target.ExecutableFiles("app")
+	if want := []string{"app.js", "app.wasm"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExecutableFiles = %v, want %v", got, want)
+	}
+}
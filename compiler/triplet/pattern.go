@@ -0,0 +1,289 @@
+package triplet
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Pattern matches targets by the components of their text form. Each
+// component is a glob (*, ?, [...]) with optional {a,b} alternatives, and
+// missing trailing components match anything:
+//
+//	arm*-linux-*-musl        any 32-bit arm or arm64 linux target with musl
+//	{x64,arm64}-windows      x64 and arm64 windows targets
+//	*-android.26             android targets with API level 26 or newer
+//	arm-linux+float-abi=hard hard float arm linux targets
+//
+// Versions are minimums, as in Target.Match. Literal components are
+// normalized like triple components, so x86_64 matches x64 and macos
+// matches darwin. Empty target fields match any pattern component.
+type Pattern struct {
+	text  string
+	comps [textCount]patternComponent
+	quals map[string]string
+}
+
+type patternComponent struct {
+	alts    []string // nil matches anything
+	version string   // minimum version
+}
+
+// ParsePattern parses a target pattern
+func ParsePattern(s string) (Pattern, error) {
+	p := Pattern{text: s}
+	main, quals, _ := strings.Cut(s, "+")
+	parts := splitOutsideBraces(main, '-')
+	if len(parts) > textCount {
+		return Pattern{}, &ErrInvalidTarget{s, "too many components in pattern"}
+	}
+	for i, part := range parts {
+		if i == textOS || i == textLibC {
+			if name, version, _ := strings.Cut(part, "."); isVersion(version) {
+				part, p.comps[i].version = name, version
+			}
+		}
+		if part == "*" || part == "" {
+			continue
+		}
+		alts, err := expandBraces(part)
+		if err != nil {
+			return Pattern{}, &ErrInvalidTarget{s, err.Error()}
+		}
+		for _, a := range alts {
+			if _, err := path.Match(a, ""); err != nil {
+				return Pattern{}, &ErrInvalidTarget{s, fmt.Sprintf("invalid glob '%s'", a)}
+			}
+		}
+		p.comps[i].alts = alts
+	}
+	if quals != "" {
+		p.quals = map[string]string{}
+		for _, q := range strings.Split(quals, "+") {
+			k, v, _ := strings.Cut(q, "=")
+			switch k {
+			case qualFloatABI, qualEndian, qualPointerWidth:
+				p.quals[k] = v
+			default:
+				return Pattern{}, &ErrInvalidTarget{s, fmt.Sprintf("unknown qualifier '%s'", k)}
+			}
+		}
+	}
+	return p, nil
+}
+
+// MustParsePattern is like ParsePattern but panics on errors
+func MustParsePattern(s string) Pattern {
+	p, err := ParsePattern(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// IsPattern checks if s uses the pattern syntax rather than naming a
+// single target
+func IsPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[{")
+}
+
+// String returns the pattern as it was parsed
+func (p Pattern) String() string {
+	return p.text
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (p Pattern) MarshalText() ([]byte, error) {
+	return []byte(p.text), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (p *Pattern) UnmarshalText(b []byte) error {
+	v, err := ParsePattern(string(b))
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// Match checks if the target matches the pattern
+func (p Pattern) Match(t Target) bool {
+	if !p.comps[textArch].match(t.Arch, func(a string) bool {
+		if isGlob(a) {
+			return globMatch(a, t.Arch) || globMatch(a, t.FullArch())
+		}
+		sub := ParseSubArch(a)
+		return NormalizeArch(a) == t.Arch && (sub == "" || t.SubArch == "" || sub == t.SubArch)
+	}) {
+		return false
+	}
+	if !p.comps[textOS].matchField(t.OS, NormalizeOS) || !t.OSVersionAtLeast(p.comps[textOS].version) {
+		return false
+	}
+	if !p.comps[textFormat].matchField(t.ObjectFormat, NormalizeObjectFormat) {
+		return false
+	}
+	if !p.comps[textLibC].matchField(t.LibC, NormalizeLibC) || !t.LibCVersionAtLeast(p.comps[textLibC].version) {
+		return false
+	}
+	if !p.comps[textEnv].matchField(t.Environment, NormalizeEnvironment) {
+		return false
+	}
+	for k, v := range p.quals {
+		var got string
+		switch k {
+		case qualFloatABI:
+			got = t.FloatABI
+		case qualEndian:
+			got = t.Endian
+		case qualPointerWidth:
+			if t.PointerWidth != 0 {
+				got = strconv.Itoa(t.PointerWidth)
+			}
+		}
+		if got != "" && !globMatch(v, got) {
+			return false
+		}
+	}
+	return true
+}
+
+// Specificity scores how narrowly the pattern selects targets: literal
+// components count more than globs and alternatives, versions and
+// qualifiers add to the score. Use it to pick the most specific of several
+// matching patterns.
+func (p Pattern) Specificity() int {
+	n := 0
+	for _, c := range p.comps {
+		switch {
+		case len(c.alts) == 1 && !isGlob(c.alts[0]):
+			n += 2
+		case len(c.alts) > 0:
+			n++
+		}
+		if c.version != "" {
+			n++
+		}
+	}
+	for _, v := range p.quals {
+		if isGlob(v) {
+			n++
+		} else {
+			n += 2
+		}
+	}
+	return n
+}
+
+// Specificity counts the fields that the target specifies. The fields
+// derived from the architecture (endianness, pointer width) do not count.
+// Use it to pick the most specific of several matching targets.
+func (t Target) Specificity() int {
+	n := 0
+	for _, v := range []string{t.Arch, t.SubArch, t.OS, t.OSVersion, t.ObjectFormat,
+		t.Environment, t.LibC, t.LibCVersion, t.FloatABI} {
+		if v != "" && v != "unknown" {
+			n++
+		}
+	}
+	return n
+}
+
+// match checks if any of the alternatives matches, empty values match
+func (c patternComponent) match(v string, alt func(string) bool) bool {
+	if c.alts == nil || v == "" {
+		return true
+	}
+	for _, a := range c.alts {
+		if alt(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchField matches the alternatives as globs, or as literals after
+// normalization
+func (c patternComponent) matchField(v string, normalize func(string) string) bool {
+	return c.match(v, func(a string) bool {
+		if isGlob(a) {
+			return globMatch(a, v)
+		}
+		return normalize(a) == v
+	})
+}
+
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+func globMatch(pattern, s string) bool {
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
+
+// splitOutsideBraces splits s at sep, except inside {...}
+func splitOutsideBraces(s string, sep byte) []string {
+	ret := []string{}
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				ret = append(ret, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(ret, s[start:])
+}
+
+// expandBraces expands {a,b} alternatives: arm{v7,v8}* gives armv7* and
+// armv8*
+func expandBraces(s string) ([]string, error) {
+	lb := strings.IndexByte(s, '{')
+	if lb < 0 {
+		if strings.IndexByte(s, '}') >= 0 {
+			return nil, fmt.Errorf("unbalanced braces in '%s'", s)
+		}
+		return []string{s}, nil
+	}
+	depth, rb := 0, -1
+	for i := lb; i < len(s) && rb < 0; i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				rb = i
+			}
+		}
+	}
+	if rb < 0 {
+		return nil, fmt.Errorf("unbalanced braces in '%s'", s)
+	}
+	rest, err := expandBraces(s[rb+1:])
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+	for _, alt := range splitOutsideBraces(s[lb+1:rb], ',') {
+		inner, err := expandBraces(alt)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range inner {
+			for _, r := range rest {
+				ret = append(ret, s[:lb]+i+r)
+			}
+		}
+	}
+	return ret, nil
+}
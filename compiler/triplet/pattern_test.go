@@ -0,0 +1,71 @@
+package triplet
+
+import (
+	"testing"
+)
+
+func TestPattern_Match(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		triple   string
+		expected bool
+	}{
+		{"arm*-linux-*-musl", "armv7-unknown-linux-musleabihf", true},
+		{"arm*-linux-*-musl", "aarch64-linux-musl", true},
+		{"arm*-linux-*-musl", "arm-linux-gnueabihf", false},
+		{"arm*-linux-*-musl", "x86_64-linux-musl", false},
+		{"{x64,arm64}-windows", "x86_64-pc-windows-msvc", true},
+		{"{x64,arm64}-windows", "aarch64-w64-mingw32", true},
+		{"{x64,arm64}-windows", "i686-w64-mingw32", false},
+		{"{x64,arm64}-windows", "x86_64-linux-gnu", false},
+		{"x86_64-linux", "x86_64-pc-linux-gnu", true},
+		{"armv7-*", "armv7a-linux-androideabi21", true},
+		{"armv7-*", "arm-linux-gnueabi", true},
+		{"armv7-*", "armv6-linux-gnueabihf", false},
+		{"*-android.26", "aarch64-linux-android28", true},
+		{"*-android.26", "aarch64-linux-android24", false},
+		{"*-macos.11", "arm64-apple-darwin23.1.0", true},
+		{"*-linux-*-glibc.2.31", "x86_64-linux-gnu.2.35", true},
+		{"*-linux-*-glibc.2.31", "x86_64-linux-gnu.2.28", false},
+		{"arm-linux+float-abi=hard", "arm-linux-gnueabihf", true},
+		{"arm-linux+float-abi=hard", "arm-linux-gnueabi", false},
+		{"*-*-*-*-{gnu,musl}*", "arm-linux-gnueabihf", true},
+		{"*-*-*-*-{gnu,musl}*", "x86_64-pc-windows-msvc", false},
+		{"*", "x86_64-pc-windows-msvc", true},
+	}
+	for _, tt := range tests {
+		p, err := ParsePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("ParsePattern(%q): %v", tt.pattern, err)
+		}
+		target, _, _ := ParseTarget(tt.triple)
+		if got := p.Match(target); got != tt.expected {
+			t.Errorf("%q.Match(%s) = %v, want %v", tt.pattern, tt.triple, got, tt.expected)
+		}
+	}
+}
+
+func TestParsePattern_Errors(t *testing.T) {
+	for _, s := range []string{"{x64,arm64-linux", "x64}-linux", "[-linux", "x64+color=red", "a-b-c-d-e-f"} {
+		if _, err := ParsePattern(s); err == nil {
+			t.Errorf("ParsePattern(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestSpecificity(t *testing.T) {
+	patterns := []string{"*", "arm*", "{x64,arm64}-linux", "x64-linux", "x64-linux-*-glibc.2.31"}
+	for i := 1; i < len(patterns); i++ {
+		a, b := MustParsePattern(patterns[i-1]), MustParsePattern(patterns[i])
+		if a.Specificity() >= b.Specificity() {
+			t.Errorf("Specificity(%q) = %d, not less than Specificity(%q) = %d",
+				a, a.Specificity(), b, b.Specificity())
+		}
+	}
+	generic := Target{Arch: "x64", OS: "linux"}
+	full, _, _ := ParseTarget("x86_64-linux-gnu")
+	if generic.Specificity() >= full.Specificity() {
+		t.Errorf("Specificity(%s) = %d, not less than Specificity(%s) = %d",
+			generic, generic.Specificity(), full, full.Specificity())
+	}
+}
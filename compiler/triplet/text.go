@@ -0,0 +1,223 @@
+package triplet
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The text form of a target lists the components in a fixed order:
+//
+//	arch-os[.version]-format-libc[.version]-environment[+key=value...]
+//
+// for example x64-linux-elf-glibc-gnu, armv7-android.24-elf-bionic-androideabi
+// or arm64-darwin.11.0-macho-unknown. Empty fields are spelled as *, and
+// trailing empty fields are omitted: x64-windows. The arch component
+// includes the sub-architecture (armv7, riscv64gc).
+//
+// Endianness, pointer width and float ABI are derived from the other
+// components the way ParseTarget does it. They are written as qualifiers
+// only when they differ from the derived values:
+// arm-linux-elf-glibc-gnueabi+float-abi=hard, arm64-linux-elf-glibc-gnu+endian=big.
+
+// text component positions
+const (
+	textArch = iota
+	textOS
+	textFormat
+	textLibC
+	textEnv
+	textCount
+)
+
+// text qualifier keys
+const (
+	qualFloatABI     = "float-abi"
+	qualEndian       = "endian"
+	qualPointerWidth = "pointer-width"
+)
+
+// derived returns the target with the fields that the text form does not
+// spell explicitly filled from the other fields
+func (t Target) derived() Target {
+	d := Target{Arch: t.Arch, OS: t.OS, Environment: t.Environment}
+	if d.Arch == "arm" {
+		d.FloatABI = parseFloatABI(d.Environment)
+	}
+	d.fillArchTraits()
+	return d
+}
+
+// Text returns the canonical text form of the target
+func (t Target) Text() string {
+	withVersion := func(name, version string) string {
+		if version == "" {
+			return name
+		}
+		if name == "" {
+			name = "*"
+		}
+		return name + "." + version
+	}
+	parts := make([]string, textCount)
+	parts[textArch] = t.FullArch()
+	parts[textOS] = withVersion(t.OS, t.OSVersion)
+	parts[textFormat] = t.ObjectFormat
+	parts[textLibC] = withVersion(t.LibC, t.LibCVersion)
+	parts[textEnv] = t.Environment
+	n := 0
+	for i, p := range parts {
+		if p == "" {
+			parts[i] = "*"
+		} else {
+			n = i + 1
+		}
+	}
+	s := strings.Join(parts[:n], "-")
+	if s == "" {
+		s = "*"
+	}
+
+	d := t.derived()
+	if t.FloatABI != "" && t.FloatABI != d.FloatABI {
+		s += "+" + qualFloatABI + "=" + t.FloatABI
+	}
+	if t.Endian != "" && t.Endian != d.Endian {
+		s += "+" + qualEndian + "=" + t.Endian
+	}
+	if t.PointerWidth != 0 && t.PointerWidth != d.PointerWidth {
+		s += "+" + qualPointerWidth + "=" + strconv.Itoa(t.PointerWidth)
+	}
+	return s
+}
+
+// ParseText parses the canonical text form of a target. The derived fields
+// that are not given as qualifiers are filled as in ParseTarget, so the
+// text of any target produced by ParseTarget parses back to the same
+// target.
+func ParseText(s string) (Target, error) {
+	t := Target{}
+	if s == "" || s == "*" {
+		return t, nil
+	}
+	main, quals, _ := strings.Cut(s, "+")
+	parts := strings.Split(main, "-")
+	if len(parts) > textCount {
+		return Target{}, &ErrInvalidTarget{s, "too many components"}
+	}
+	for i, p := range parts {
+		if p == "*" || p == "" {
+			continue
+		}
+		name, version := p, ""
+		if i == textOS || i == textLibC {
+			name, version, _ = strings.Cut(p, ".")
+			if version != "" && !isVersion(version) {
+				return Target{}, &ErrInvalidTarget{s, fmt.Sprintf("invalid version '%s'", version)}
+			}
+			if name == "*" {
+				name = ""
+			}
+		}
+		switch i {
+		case textArch:
+			arch, ok := ParseArch(name)
+			if !ok && name != "unknown" {
+				return Target{}, &ErrInvalidTarget{s, fmt.Sprintf("unknown architecture '%s'", name)}
+			}
+			t.Arch, t.SubArch = arch, ParseSubArch(name)
+		case textOS:
+			t.OS, t.OSVersion = NormalizeOS(name), version
+		case textFormat:
+			f, ok := ParseObjectFormat(name)
+			if !ok && name != "unknown" {
+				return Target{}, &ErrInvalidTarget{s, fmt.Sprintf("unknown object format '%s'", name)}
+			}
+			t.ObjectFormat = f
+		case textLibC:
+			t.LibC, t.LibCVersion = NormalizeLibC(name), version
+		case textEnv:
+			t.Environment = NormalizeEnvironment(name)
+		}
+	}
+
+	d := t.derived()
+	t.FloatABI, t.Endian, t.PointerWidth = d.FloatABI, d.Endian, d.PointerWidth
+	if quals != "" {
+		for _, q := range strings.Split(quals, "+") {
+			k, v, _ := strings.Cut(q, "=")
+			switch k {
+			case qualFloatABI:
+				t.FloatABI = v
+			case qualEndian:
+				t.Endian = v
+			case qualPointerWidth:
+				w, err := strconv.Atoi(v)
+				if err != nil {
+					return Target{}, &ErrInvalidTarget{s, fmt.Sprintf("invalid pointer width '%s'", v)}
+				}
+				t.PointerWidth = w
+			default:
+				return Target{}, &ErrInvalidTarget{s, fmt.Sprintf("unknown qualifier '%s'", k)}
+			}
+		}
+	}
+	return t, nil
+}
+
+// MarshalText implements encoding.TextMarshaler with the canonical text form
+func (t Target) MarshalText() ([]byte, error) {
+	return []byte(t.Text()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseText
+func (t *Target) UnmarshalText(b []byte) error {
+	v, err := ParseText(string(b))
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Targets are written as objects,
+// MarshalText would otherwise take precedence.
+func (t Target) MarshalJSON() ([]byte, error) {
+	type plain Target
+	return json.Marshal(plain(t))
+}
+
+// MarshalYAML implements yaml.Marshaler. Targets are written as mappings,
+// MarshalText would otherwise take precedence.
+func (t Target) MarshalYAML() (interface{}, error) {
+	type plain Target
+	return plain(t), nil
+}
+
+// MarshalJSON implements json.Marshaler, the method promoted from the
+// embedded Target would skip the Full fields
+func (f Full) MarshalJSON() ([]byte, error) {
+	type plain Target
+	return json.Marshal(struct {
+		plain
+		Original string   `json:"original,omitempty"`
+		Vendors  []string `json:"vendors,omitempty"`
+	}{plain(f.Target), f.Original, f.Vendors})
+}
+
+// unmarshalTextJSON decodes the JSON string form of a target
+func (t *Target) unmarshalTextJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return t.UnmarshalText([]byte(s))
+}
+
+// unmarshalTextYAML decodes the YAML scalar form of a target
+func (t *Target) unmarshalTextYAML(node *yaml.Node) error {
+	return t.UnmarshalText([]byte(node.Value))
+}
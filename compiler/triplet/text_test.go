@@ -0,0 +1,132 @@
+package triplet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestTarget_TextRoundTrip(t *testing.T) {
+	for _, s := range []string{
+		"x86_64-pc-linux-gnu",
+		"x86_64-linux-gnux32",
+		"i686-w64-mingw32",
+		"x86_64-pc-windows-msvc",
+		"x86_64-pc-windows-msvc-elf",
+		"aarch64_be-linux-gnu",
+		"arm-linux-gnueabi",
+		"armv7hl-redhat-linux-gnueabi",
+		"armeb-linux-gnueabi",
+		"armv7a-linux-androideabi21",
+		"thumbv7em-none-eabihf",
+		"arm64e-apple-ios",
+		"arm64-apple-darwin23.1.0",
+		"arm64-apple-ios17.0-simulator",
+		"riscv64gc-unknown-linux-gnu",
+		"mips64el-linux-gnuabi64",
+		"powerpc64-ibm-aix7.2.0.0",
+		"wasm32-unknown-emscripten",
+		"wasm32-wasi",
+		"x86_64-linux-gnu.2.31",
+		"avr-unknown-unknown",
+	} {
+		want, _, err := ParseTarget(s)
+		if err != nil {
+			t.Fatalf("ParseTarget(%q): %v", s, err)
+		}
+		text := want.Text()
+		got, err := ParseText(text)
+		if err != nil {
+			t.Errorf("%s: ParseText(%q): %v", s, text, err)
+		} else if got != want {
+			t.Errorf("%s: ParseText(%q)\ngot  = %#v\nwant = %#v", s, text, got, want)
+		}
+	}
+}
+
+func TestTarget_Text(t *testing.T) {
+	tests := []struct {
+		target   Target
+		expected string
+	}{
+		{Target{}, "*"},
+		{Target{Arch: "x64", OS: "windows"}, "x64-windows"},
+		{Target{Arch: "x64", LibC: "musl"}, "x64-*-*-musl"},
+		{Target{Arch: "arm", SubArch: "v7", OS: "android", OSVersion: "24", ObjectFormat: "elf", LibC: "bionic", Environment: "androideabi", FloatABI: "softfp", Endian: "little", PointerWidth: 32},
+			"armv7-android.24-elf-bionic-androideabi"},
+		{Target{Arch: "arm", OS: "linux", ObjectFormat: "elf", LibC: "glibc", Environment: "gnueabi", FloatABI: "hard"},
+			"arm-linux-elf-glibc-gnueabi+float-abi=hard"},
+		{Target{Arch: "arm64", OS: "linux", Endian: "big"}, "arm64-linux+endian=big"},
+	}
+	for _, tt := range tests {
+		if got := tt.target.Text(); got != tt.expected {
+			t.Errorf("Text() = %q, want %q", got, tt.expected)
+		}
+	}
+}
+
+func TestParseText_Errors(t *testing.T) {
+	for _, s := range []string{
+		"x64-linux-elf-glibc-gnu-extra",
+		"x64-linux-gnu",
+		"foo-linux",
+		"x64-linux.abc",
+		"x64-linux+color=red",
+	} {
+		if _, err := ParseText(s); err == nil {
+			t.Errorf("ParseText(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestTarget_TextEncoding(t *testing.T) {
+	type doc struct {
+		Target Target `json:"target" yaml:"target"`
+	}
+	var d doc
+	if err := json.Unmarshal([]byte(`{"target":"arm64-linux-elf-musl"}`), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Target.Arch != "arm64" || d.Target.LibC != "musl" {
+		t.Errorf("json string: %#v", d.Target)
+	}
+	d = doc{}
+	if err := yaml.Unmarshal([]byte("target: x64-windows-pe-msvcrt-msvc\n"), &d); err != nil {
+		t.Fatal(err)
+	}
+	if !d.Target.IsMSVC() {
+		t.Errorf("yaml scalar: %#v", d.Target)
+	}
+
+	// Full accepts the text form as well
+	type fullDoc struct {
+		Target Full `json:"target" yaml:"target"`
+	}
+	var fd fullDoc
+	if err := json.Unmarshal([]byte(`{"target":"arm64-linux-elf-musl"}`), &fd); err != nil {
+		t.Fatal(err)
+	}
+	if fd.Target.Arch != "arm64" || fd.Target.LibC != "musl" || fd.Target.Original != "arm64-linux-elf-musl" {
+		t.Errorf("json string: %#v", fd.Target)
+	}
+	fd = fullDoc{}
+	if err := yaml.Unmarshal([]byte("target: x64-windows-pe-msvcrt-msvc\n"), &fd); err != nil {
+		t.Fatal(err)
+	}
+	if !fd.Target.IsMSVC() || fd.Target.Original != "x64-windows-pe-msvcrt-msvc" {
+		t.Errorf("yaml scalar: %#v", fd.Target)
+	}
+	if err := json.Unmarshal([]byte(`{"target":"x64-linux+color=red"}`), &fd); err == nil {
+		t.Errorf("json string with an invalid target succeeded")
+	}
+
+	// objects are still written as objects
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"target":{"os":"windows","arch":"x64","object-format":"pe","environment":"msvc","libc":"msvcrt","endian":"little","pointer-width":64}}`; string(b) != want {
+		t.Errorf("json.Marshal = %s, want %s", b, want)
+	}
+}
@@ -7,72 +7,65 @@ import (
 
 // Package triplet provides functionality for parsing and handling compiler target triplets.
 // Target triplets are commonly used in compiler toolchains to specify the target architecture,
-// operating system, and ABI (e.g., x86_64-linux-gnu, aarch64-linux-android).
+// operating system, and environment (e.g., x86_64-linux-gnu, aarch64-linux-android).
 
 // archNorm maps various architecture names to their normalized form.
 // This includes common variants and aliases for CPU architectures.
 var archNorm = map[string]string{
-	"x64":       "x64",       // 64-bit x86
-	"amd64":     "x64",       // AMD64/Intel64
-	"x86_64":    "x64",       // Standard x86_64
-	"x32":       "x32",       // x32 ABI
-	"86":        "x32",       // Generic x86
-	"x86":       "x32",       // 32-bit x86
-	"386":       "x32",       // Intel 386
-	"i386":      "x32",       // Intel 386
-	"486":       "x32",       // Intel 486
-	"i486":      "x32",       // Intel 486
-	"586":       "x32",       // Intel Pentium
-	"i586":      "x32",       // Intel Pentium
-	"686":       "x32",       // Intel Pentium Pro
-	"i686":      "x32",       // Intel Pentium Pro
-	"arm":       "arm",       // 32-bit ARM
-	"arm32":     "arm",       // 32-bit ARM
-	"arm64":     "arm64",     // 64-bit ARM
-	"aarch64":   "arm64",     // ARM64
-	"ia64":      "ia64",      // Intel Itanium
-	"powerpc":   "powerpc",   // PowerPC
-	"powerpcle": "powerpcle", // PowerPC Little Endian
-	"s390":      "s390",      // IBM System/390
-	"s390x":     "s390x",     // IBM System/390x
-	"sparc":     "sparc",     // SPARC
-	"sparc64":   "sparc64",   // SPARC64
-	"sparcv9":   "sparc64",   // SPARC V9
-	"c6x":       "c6x",       // TI C6x DSP
-	"tilegx":    "tilegx",    // Tilera TILE-Gx
-	"tilegxbe":  "tilegxbe",  // Tilera TILE-Gx Big Endian
-	"tilepro":   "tilepro",   // Tilera TILEPro
-}
-
-// abiMap maps various ABI/environment names to their normalized form.
-// This includes common toolchain environments and binary interfaces.
-var abiMap = map[string]string{
-	"eabi":      "eabi",    // Embedded ABI
-	"eabisim":   "eabisim", // Embedded ABI Simulator
-	"mingw32":   "pe",      // MinGW 32-bit
-	"mingw":     "pe",      // MinGW
-	"mingw64":   "pe",      // MinGW 64-bit
-	"w64":       "pe",      // Windows 64-bit
-	"msvc":      "pe",      // Microsoft Visual C++
-	"windows":   "pe",      // Windows
-	"cygwin":    "pe",      // Cygwin
-	"msys":      "pe",      // MSYS
-	"elf":       "elf",     // ELF format
-	"netbsd":    "elf",     // NetBSD
-	"openbsd":   "elf",     // OpenBSD
-	"freebsd":   "elf",     // FreeBSD
-	"aix":       "elf",     // AIX
-	"gnueabi":   "elf",     // GNU EABI
-	"gnueabihf": "elf",     // GNU EABI Hard Float
+	"x64":         "x64",       // 64-bit x86
+	"amd64":       "x64",       // AMD64/Intel64
+	"x86_64":      "x64",       // Standard x86_64
+	"x32":         "x32",       // x32 ABI
+	"86":          "x32",       // Generic x86
+	"x86":         "x32",       // 32-bit x86
+	"386":         "x32",       // Intel 386
+	"i386":        "x32",       // Intel 386
+	"486":         "x32",       // Intel 486
+	"i486":        "x32",       // Intel 486
+	"586":         "x32",       // Intel Pentium
+	"i586":        "x32",       // Intel Pentium
+	"686":         "x32",       // Intel Pentium Pro
+	"i686":        "x32",       // Intel Pentium Pro
+	"arm":         "arm",       // 32-bit ARM
+	"arm32":       "arm",       // 32-bit ARM
+	"arm64":       "arm64",     // 64-bit ARM
+	"aarch64":     "arm64",     // ARM64
+	"ia64":        "ia64",      // Intel Itanium
+	"powerpc":     "powerpc",   // PowerPC
+	"powerpcle":   "powerpcle", // PowerPC Little Endian
+	"ppc64":       "ppc64",     // 64-bit PowerPC
+	"powerpc64":   "ppc64",     // 64-bit PowerPC
+	"ppc64le":     "ppc64le",   // 64-bit PowerPC Little Endian
+	"powerpc64le": "ppc64le",   // 64-bit PowerPC Little Endian
+	"loong64":     "loong64",   // LoongArch
+	"loongarch64": "loong64",   // LoongArch
+	"wasm32":      "wasm32",    // WebAssembly
+	"wasm64":      "wasm64",    // WebAssembly with 64-bit memory
+	"s390":        "s390",      // IBM System/390
+	"s390x":       "s390x",     // IBM System/390x
+	"sparc":       "sparc",     // SPARC
+	"sparc64":     "sparc64",   // SPARC64
+	"sparcv9":     "sparc64",   // SPARC V9
+	"c6x":         "c6x",       // TI C6x DSP
+	"tilegx":      "tilegx",    // Tilera TILE-Gx
+	"tilegxbe":    "tilegxbe",  // Tilera TILE-Gx Big Endian
+	"tilepro":     "tilepro",   // Tilera TILEPro
 }
 
 // Target represents a normalized compiler target triplet.
 // The format typically follows: architecture-operating_system-environment
 type Target struct {
-	OS   string `json:"os,omitempty" yaml:"os,omitempty"`     // Operating system (e.g., linux, windows)
-	Arch string `json:"arch,omitempty" yaml:"arch,omitempty"` // Architecture (e.g., x64, arm64)
-	ABI  string `json:"abi,omitempty" yaml:"abi,omitempty"`   // ABI/environment (e.g., elf, pe)
-	LibC string `json:"libc,omitempty" yaml:"libc,omitempty"` // C library (e.g., glibc, msvcrt)
+	OS           string `json:"os,omitempty" yaml:"os,omitempty"`                       // Operating system (e.g., linux, windows)
+	OSVersion    string `json:"os-version,omitempty" yaml:"os-version,omitempty"`       // Minimum OS version (e.g., 11.0 for macOS, 24 for the Android API level)
+	Arch         string `json:"arch,omitempty" yaml:"arch,omitempty"`                   // Architecture (e.g., x64, arm64)
+	SubArch      string `json:"subarch,omitempty" yaml:"subarch,omitempty"`             // Sub-architecture (e.g., v7 for armv7a, gc for riscv64gc)
+	ObjectFormat string `json:"object-format,omitempty" yaml:"object-format,omitempty"` // Object file format (e.g., elf, pe, macho)
+	Environment  string `json:"environment,omitempty" yaml:"environment,omitempty"`     // Environment/ABI component (e.g., gnu, gnueabihf, msvc, android)
+	LibC         string `json:"libc,omitempty" yaml:"libc,omitempty"`                   // C library (e.g., glibc, msvcrt)
+	LibCVersion  string `json:"libc-version,omitempty" yaml:"libc-version,omitempty"`   // Minimum C library version (e.g., 2.31 for glibc)
+	FloatABI     string `json:"float-abi,omitempty" yaml:"float-abi,omitempty"`         // hard|softfp|soft, where it varies
+	Endian       string `json:"endian,omitempty" yaml:"endian,omitempty"`               // little|big
+	PointerWidth int    `json:"pointer-width,omitempty" yaml:"pointer-width,omitempty"` // 16|32|64
 }
 
 // Full extends Target with original string and vendor information
@@ -95,8 +88,10 @@ func ParseFull(target string) (Full, error) {
 }
 
 // ParseTarget parses a target triplet string into its components.
-// It attempts to identify the architecture, OS, ABI, and C library
-// from the hyphen-separated components of the target string.
+// It attempts to identify the architecture, OS, object format, environment,
+// and C library from the hyphen-separated components of the target string.
+// The object format is derived from the OS and the architecture unless the
+// triple spells it explicitly.
 func ParseTarget(target string) (t Target, vendors []string, err error) {
 	if target == "" {
 		return Target{}, []string{}, fmt.Errorf("empty target string")
@@ -105,7 +100,6 @@ func ParseTarget(target string) (t Target, vendors []string, err error) {
 	t = Target{
 		OS:   "unknown",
 		Arch: "unknown",
-		ABI:  "unknown",
 		LibC: "unknown",
 	}
 	vendors = []string{}
@@ -118,29 +112,56 @@ func ParseTarget(target string) (t Target, vendors []string, err error) {
 		if v, ok := ParseArch(s); ok {
 			if t.Arch == "unknown" {
 				t.Arch = v
+				_, t.SubArch, t.Endian = splitArch(s)
+				if strings.HasSuffix(s, "hl") {
+					t.FloatABI = FloatHard
+				}
 				skip[s] = struct{}{}
 			}
 			break
 		}
 	}
 
-	// Find operating system component
+	// Find operating system component, android triples also mention linux
 	for _, s := range segments {
 		if v, ok := ParseOS(s); ok {
-			if t.OS == "none" || t.OS == "unknown" {
+			if t.OS == "none" || t.OS == "unknown" || (t.OS == "linux" && v == "android") {
 				t.OS = v
+				t.OSVersion = parseOSVersion(v, s)
 				skip[s] = struct{}{}
 			}
 		}
 	}
 
-	// Find ABI component
-	for _, s := range segments {
-		if v, ok := ParseABI(s); ok {
-			if t.ABI == "unknown" {
-				t.ABI = v
-				skip[s] = struct{}{}
-			}
+	// Find explicit object format component
+	for _, s := range segments[1:] {
+		if v, ok := ParseObjectFormat(s); ok {
+			t.ObjectFormat = v
+			skip[s] = struct{}{}
+			break
+		}
+	}
+	if t.ObjectFormat == "" {
+		t.ObjectFormat = DefaultObjectFormat(t.OS, t.Arch)
+	}
+
+	// Find environment component, it never comes first
+	env := ""
+	for _, s := range segments[1:] {
+		if v, ok := ParseEnvironment(s); ok {
+			t.Environment, env = v, s
+			skip[s] = struct{}{}
+			break
+		}
+	}
+	if _, ver := splitEnvironment(env); ver != "" {
+		switch {
+		case strings.HasPrefix(t.Environment, "android"):
+			// API level: aarch64-linux-android24
+			t.OSVersion = ver
+		case strings.HasPrefix(t.Environment, "gnu"), strings.HasPrefix(t.Environment, "musl"):
+			// zig: x86_64-linux-gnu.2.31
+			t.LibCVersion = ver
 		}
 	}
 
@@ -151,42 +172,86 @@ func ParseTarget(target string) (t Target, vendors []string, err error) {
 		}
 	}
 
-	// Attempt to identify C library from vendor components
+	// Attempt to identify C library from the environment, then from vendor
+	// components
+	if v, ok := ParseLibC(env); ok {
+		t.LibC = v
+	}
 	for i, s := range vendors {
 		if v, ok := ParseLibC(s); ok {
-			t.LibC = v
+			if t.LibC == "unknown" {
+				t.LibC = v
+			}
 			if s == "msvc" || s == "mingw" || s == "mingw32" || s == "mingw64" || s == "w64" {
 				vendors = append(vendors[:i], vendors[i+1:]...)
 			}
 			break
 		}
 	}
+	switch {
+	case t.OS == "android" && t.LibC == "unknown":
+		t.LibC = "bionic"
+	case t.OS == "windows" && t.LibC == "glibc":
+		// windows-gnu targets (clang, rust, zig) use mingw-w64
+		t.LibC = "mingw"
+	}
+
+	// Float ABI is spelled in the environment component for arm
+	if t.Arch == "arm" && t.FloatABI == "" {
+		for _, s := range segments[1:] {
+			if v := parseFloatABI(s); v != "" {
+				t.FloatABI = v
+				break
+			}
+		}
+	}
+	t.fillArchTraits()
 
 	return
 }
 
 // Match checks if this target matches another target.
-// Empty fields in either target are treated as wildcards.
+// Empty fields in either target are treated as wildcards. The OS and C
+// library versions in other are minimums: a target for android26 matches
+// android24, but not the other way around.
 func (t *Target) Match(other Target) bool {
 	if t.Arch != "" && other.Arch != "" && t.Arch != other.Arch {
 		return false
 	}
+	if t.SubArch != "" && other.SubArch != "" && t.SubArch != other.SubArch {
+		return false
+	}
+	if t.FloatABI != "" && other.FloatABI != "" && t.FloatABI != other.FloatABI {
+		return false
+	}
+	if t.Endian != "" && other.Endian != "" && t.Endian != other.Endian {
+		return false
+	}
+	if t.PointerWidth != 0 && other.PointerWidth != 0 && t.PointerWidth != other.PointerWidth {
+		return false
+	}
 	if t.OS != "" && other.OS != "" && t.OS != other.OS {
 		return false
 	}
-	if t.ABI != "" && other.ABI != "" && t.ABI != other.ABI {
+	if t.ObjectFormat != "" && other.ObjectFormat != "" && t.ObjectFormat != other.ObjectFormat {
+		return false
+	}
+	if t.Environment != "" && other.Environment != "" && t.Environment != other.Environment {
 		return false
 	}
 	if t.LibC != "" && other.LibC != "" && t.LibC != other.LibC {
 		return false
 	}
+	if !t.OSVersionAtLeast(other.OSVersion) || !t.LibCVersionAtLeast(other.LibCVersion) {
+		return false
+	}
 	return true
 }
 
 // ParseArch parses and normalizes an architecture string.
 // Returns the normalized architecture name and whether it was recognized.
 func ParseArch(arch string) (string, bool) {
-	arch = strings.ToLower(arch)
+	arch, _, _ = splitArch(arch)
 	if norm, ok := archNorm[arch]; ok {
 		return norm, true
 	}
@@ -223,13 +288,14 @@ var osMap = map[string]string{
 	// Unix-like systems
 	"linux":     "linux",
 	"darwin":    "darwin",
+	"macos":     "darwin",
 	"freebsd":   "freebsd",
 	"netbsd":    "netbsd",
 	"openbsd":   "openbsd",
 	"dragonfly": "dragonfly",
 	"solaris":   "solaris",
 	"sunos":     "solaris",
-	"illumos":   "solaris",
+	"illumos":   "illumos",
 	"aix":       "aix",
 	"hpux":      "hpux",
 	"ios":       "ios",
@@ -268,30 +334,6 @@ func NormalizeOS(os string) string {
 	return n
 }
 
-func ParseABI(abi string) (string, bool) {
-	abi = strings.ToLower(abi)
-	if normalized, ok := abiMap[abi]; ok {
-		return normalized, true
-	}
-
-	// Handle prefixes
-	if strings.HasPrefix(abi, "linux") ||
-		strings.HasPrefix(abi, "uclinux") ||
-		strings.HasPrefix(abi, "solaris") {
-		return "elf", true
-	}
-	if strings.HasPrefix(abi, "darwin") {
-		return "marcho", true
-	}
-
-	return abi, false
-}
-
-func NormalizeABI(abi string) string {
-	n, _ := ParseABI(abi)
-	return n
-}
-
 func ParseLibC(libc string) (string, bool) {
 	libc = strings.ToLower(libc)
 	switch libc {
@@ -303,9 +345,17 @@ func ParseLibC(libc string) (string, bool) {
 		return "glibc", true
 	case "mcvcrt", "msvc":
 		return "msvcrt", true
-	default:
-		return libc, false
+	case "bionic":
+		return "bionic", true
 	}
+	// arm and mips variants: gnueabihf, gnuabi64, musleabihf, ...
+	switch {
+	case strings.HasPrefix(libc, "gnu"):
+		return "glibc", true
+	case strings.HasPrefix(libc, "musl"):
+		return "musl", true
+	}
+	return libc, false
 }
 
 func NormalizeLibC(libc string) string {
@@ -317,21 +367,9 @@ func (t Target) IsValid() bool {
 	return t.OS != "unknown" && t.Arch != "unknown"
 }
 
+// String returns the canonical text form of the target, see Text
 func (t Target) String() string {
-	var parts []string
-	if t.Arch != "" && t.Arch != "unknown" {
-		parts = append(parts, t.Arch)
-	}
-	if t.OS != "" && t.OS != "unknown" {
-		parts = append(parts, t.OS)
-	}
-	if t.ABI != "" && t.ABI != "unknown" {
-		parts = append(parts, t.ABI)
-	}
-	if t.LibC != "" && t.LibC != "unknown" {
-		parts = append(parts, t.LibC)
-	}
-	return strings.Join(parts, "-")
+	return t.Text()
 }
 
 // Define specific error types
@@ -349,20 +387,28 @@ func (t Target) Validate() error {
 	if t.Arch == "unknown" {
 		return &ErrInvalidTarget{t.String(), "unknown architecture"}
 	}
-	if t.OS == "unknown" {
+	if t.OS == "unknown" && !t.IsWasm() {
+		// bare wasm32-unknown-unknown targets run without an OS
 		return &ErrInvalidTarget{t.String(), "unknown operating system"}
 	}
 	return nil
 }
 
-// Add constructor for Target
-func NewTarget(arch, os, abi, libc string) Target {
-	return Target{
-		Arch: NormalizeArch(arch),
-		OS:   NormalizeOS(os),
-		ABI:  NormalizeABI(abi),
-		LibC: NormalizeLibC(libc),
+// Add constructor for Target, the object format is derived from os and arch
+func NewTarget(arch, os, env, libc string) Target {
+	t := Target{
+		Arch:        NormalizeArch(arch),
+		SubArch:     ParseSubArch(arch),
+		OS:          NormalizeOS(os),
+		Environment: NormalizeEnvironment(env),
+		LibC:        NormalizeLibC(libc),
 	}
+	t.ObjectFormat = DefaultObjectFormat(t.OS, t.Arch)
+	if t.Arch == "arm" {
+		t.FloatABI = parseFloatABI(env)
+	}
+	t.fillArchTraits()
+	return t
 }
 
 // Add helper methods for common checks
@@ -394,7 +440,3 @@ func (t Target) IsPOSIX() bool {
 func (t Target) IsEmbedded() bool {
 	return t.OS == "none" || t.OS == "baremetal" || t.OS == "vxworks"
 }
-
-func (t Target) IsWasm() bool {
-	return t.OS == "emscripten" || t.OS == "wasi"
-}
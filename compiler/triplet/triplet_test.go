@@ -16,13 +16,17 @@ func TestParseFull(t *testing.T) {
 			input: "x86_64-linux-gnu",
 			expected: Full{
 				Target: Target{
-					Arch: "x64",
-					OS:   "linux",
-					ABI:  "elf",
-					LibC: "glibc",
+					Arch:         "x64",
+					OS:           "linux",
+					ObjectFormat: "elf",
+					Environment:  "gnu",
+					LibC:         "glibc",
+
+					Endian:       "little",
+					PointerWidth: 64,
 				},
 				Original: "x86_64-linux-gnu",
-				Vendors:  []string{"gnu"},
+				Vendors:  []string{},
 			},
 		},
 		{
@@ -30,13 +34,17 @@ func TestParseFull(t *testing.T) {
 			input: "aarch64-linux-gnu",
 			expected: Full{
 				Target: Target{
-					Arch: "arm64",
-					OS:   "linux",
-					ABI:  "elf",
-					LibC: "glibc",
+					Arch:         "arm64",
+					OS:           "linux",
+					ObjectFormat: "elf",
+					Environment:  "gnu",
+					LibC:         "glibc",
+
+					Endian:       "little",
+					PointerWidth: 64,
 				},
 				Original: "aarch64-linux-gnu",
-				Vendors:  []string{"gnu"},
+				Vendors:  []string{},
 			},
 		},
 		{
@@ -44,10 +52,14 @@ func TestParseFull(t *testing.T) {
 			input: "x86_64-windows-msvc",
 			expected: Full{
 				Target: Target{
-					Arch: "x64",
-					OS:   "windows",
-					ABI:  "pe",
-					LibC: "msvcrt",
+					Arch:         "x64",
+					OS:           "windows",
+					ObjectFormat: "pe",
+					Environment:  "msvc",
+					LibC:         "msvcrt",
+
+					Endian:       "little",
+					PointerWidth: 64,
 				},
 				Original: "x86_64-windows-msvc",
 				Vendors:  []string{},
@@ -58,10 +70,13 @@ func TestParseFull(t *testing.T) {
 			input: "arm-darwin",
 			expected: Full{
 				Target: Target{
-					Arch: "arm",
-					OS:   "darwin",
-					ABI:  "marcho",
-					LibC: "unknown",
+					Arch:         "arm",
+					OS:           "darwin",
+					ObjectFormat: "macho",
+					LibC:         "unknown",
+
+					Endian:       "little",
+					PointerWidth: 32,
 				},
 				Original: "arm-darwin",
 				Vendors:  []string{},
@@ -92,16 +107,16 @@ func TestTarget_Match(t *testing.T) {
 		{
 			name: "exact match",
 			target: Target{
-				Arch: "x64",
-				OS:   "linux",
-				ABI:  "elf",
-				LibC: "glibc",
+				Arch:         "x64",
+				OS:           "linux",
+				ObjectFormat: "elf",
+				LibC:         "glibc",
 			},
 			other: Target{
-				Arch: "x64",
-				OS:   "linux",
-				ABI:  "elf",
-				LibC: "glibc",
+				Arch:         "x64",
+				OS:           "linux",
+				ObjectFormat: "elf",
+				LibC:         "glibc",
 			},
 			expected: true,
 		},
@@ -112,10 +127,10 @@ func TestTarget_Match(t *testing.T) {
 				OS:   "linux",
 			},
 			other: Target{
-				Arch: "x64",
-				OS:   "linux",
-				ABI:  "elf",
-				LibC: "glibc",
+				Arch:         "x64",
+				OS:           "linux",
+				ObjectFormat: "elf",
+				LibC:         "glibc",
 			},
 			expected: true,
 		},
@@ -131,6 +146,24 @@ func TestTarget_Match(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name:     "no match - different float abi",
+			target:   Target{Arch: "arm", OS: "linux", FloatABI: "soft"},
+			other:    Target{Arch: "arm", OS: "linux", FloatABI: "hard"},
+			expected: false,
+		},
+		{
+			name:     "no match - different endianness",
+			target:   Target{Arch: "arm64", OS: "linux", Endian: "big"},
+			other:    Target{Arch: "arm64", OS: "linux", Endian: "little"},
+			expected: false,
+		},
+		{
+			name:     "partial match - unspecified subarch",
+			target:   Target{Arch: "arm", OS: "linux"},
+			other:    Target{Arch: "arm", SubArch: "v7", OS: "linux"},
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -156,6 +189,10 @@ func TestParseArch(t *testing.T) {
 		{"aarch64", "arm64", true},
 		{"arm", "arm", true},
 		{"riscv64", "riscv64", true},
+		{"riscv64gc", "riscv64", true},
+		{"armv7a", "arm", true},
+		{"thumbv7em", "arm", true},
+		{"aarch64_be", "arm64", true},
 		{"unknown", "unknown", false},
 	}
 
@@ -201,29 +238,65 @@ func TestParseOS(t *testing.T) {
 	}
 }
 
-func TestParseABI(t *testing.T) {
+func TestParseObjectFormat(t *testing.T) {
 	tests := []struct {
 		input       string
 		expected    string
 		shouldMatch bool
 	}{
 		{"elf", "elf", true},
+		{"coff", "pe", true},
+		{"pe", "pe", true},
+		{"macho", "macho", true},
+		{"wasm", "wasm", true},
+		{"xcoff", "xcoff", true},
+		{"marcho", "marcho", false},
+		{"linux", "linux", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := ParseObjectFormat(tt.input)
+			if ok != tt.shouldMatch {
+				t.Errorf("ParseObjectFormat(%q) match = %v, want %v", tt.input, ok, tt.shouldMatch)
+			}
+			if tt.shouldMatch && got != tt.expected {
+				t.Errorf("ParseObjectFormat(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseEnvironment(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		shouldMatch bool
+	}{
+		{"gnu", "gnu", true},
+		{"gnueabihf", "gnueabihf", true},
+		{"gnuabi64", "gnuabi64", true},
+		{"musleabihf", "musleabihf", true},
 		{"eabi", "eabi", true},
-		{"mingw32", "pe", true},
-		{"msvc", "pe", true},
-		{"linux", "elf", true},
-		{"darwin", "marcho", true},
+		{"msvc", "msvc", true},
+		{"mingw32", "gnu", true},
+		{"cygwin", "cygnus", true},
+		{"android24", "android", true},
+		{"androideabi16", "androideabi", true},
+		{"simulator", "simulator", true},
+		{"gnuxyz", "gnuxyz", false},
+		{"linux", "linux", false},
 		{"unknown", "unknown", false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			got, ok := ParseABI(tt.input)
+			got, ok := ParseEnvironment(tt.input)
 			if ok != tt.shouldMatch {
-				t.Errorf("ParseABI(%q) match = %v, want %v", tt.input, ok, tt.shouldMatch)
+				t.Errorf("ParseEnvironment(%q) match = %v, want %v", tt.input, ok, tt.shouldMatch)
 			}
 			if tt.shouldMatch && got != tt.expected {
-				t.Errorf("ParseABI(%q) = %q, want %q", tt.input, got, tt.expected)
+				t.Errorf("ParseEnvironment(%q) = %q, want %q", tt.input, got, tt.expected)
 			}
 		})
 	}
@@ -265,21 +338,21 @@ func TestTarget_String(t *testing.T) {
 		{
 			name: "full target",
 			target: Target{
-				Arch: "x64",
-				OS:   "linux",
-				ABI:  "elf",
-				LibC: "glibc",
+				Arch:         "x64",
+				OS:           "linux",
+				ObjectFormat: "elf",
+				LibC:         "glibc",
 			},
 			expected: "x64-linux-elf-glibc",
 		},
 		{
 			name: "partial target",
 			target: Target{
-				Arch: "arm64",
-				OS:   "darwin",
-				ABI:  "marcho",
+				Arch:         "arm64",
+				OS:           "darwin",
+				ObjectFormat: "macho",
 			},
-			expected: "arm64-darwin-marcho",
+			expected: "arm64-darwin-macho",
 		},
 		{
 			name: "minimal target",
@@ -0,0 +1,118 @@
+package triplet
+
+import (
+	"strconv"
+	"strings"
+)
+
+// splitVersion separates a trailing version from an OS component:
+// darwin21.6.0, macosx11.0, ios14, solaris2.11, or the zig spelling with a
+// dot: macos.13. Returns the component unchanged when it has no version.
+func splitVersion(s string) (name, version string) {
+	i := len(s)
+	for i > 0 && (s[i-1] >= '0' && s[i-1] <= '9' || s[i-1] == '.') {
+		i--
+	}
+	name, version = s[:i], strings.TrimPrefix(s[i:], ".")
+	if name == "" || !isVersion(version) {
+		return s, ""
+	}
+	return name, version
+}
+
+// parseOSVersion returns the version spelled in the OS component of a
+// triple. Darwin kernel versions are converted to macOS versions, wasi
+// preview names to the preview level. The windows components (mingw32, w64)
+// do not carry an OS version.
+func parseOSVersion(os, s string) string {
+	switch os {
+	case "windows":
+		return ""
+	case "wasi":
+		return parseWASIPreview(s)
+	}
+	name, v := splitVersion(s)
+	if v != "" && strings.EqualFold(name, "darwin") {
+		return darwinToMacOS(v)
+	}
+	return v
+}
+
+// darwinToMacOS converts a darwin kernel version to the macOS version, the
+// way LLVM does for the darwin triples: darwin19 is 10.15, darwin20 is 11,
+// darwin24 is 15, and darwin25 is 26
+func darwinToMacOS(v string) string {
+	major, err := strconv.Atoi(majorVersion(v))
+	if err != nil {
+		return v
+	}
+	switch {
+	case major < 4:
+		return v
+	case major < 20:
+		return "10." + strconv.Itoa(major-4)
+	case major < 25:
+		return strconv.Itoa(major - 9)
+	}
+	return strconv.Itoa(major + 1)
+}
+
+// CompareVersions compares dotted numeric versions. Missing components
+// compare as zero, so 11 and 11.0 are equal.
+func CompareVersions(v1, v2 string) int {
+	a, b := strings.Split(v1, "."), strings.Split(v2, ".")
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x, _ = strconv.Atoi(a[i])
+		}
+		if i < len(b) {
+			y, _ = strconv.Atoi(b[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// OSVersionAtLeast checks if the target OS version is v or newer. Targets
+// that do not specify the OS version satisfy any minimum. WASI preview
+// levels are not compatible with each other and must be equal.
+func (t Target) OSVersionAtLeast(v string) bool {
+	if t.OSVersion == "" || v == "" {
+		return true
+	}
+	if t.OS == "wasi" {
+		return CompareVersions(t.OSVersion, v) == 0
+	}
+	return CompareVersions(t.OSVersion, v) >= 0
+}
+
+// LibCVersionAtLeast checks if the target C library version is v or newer.
+// Targets that do not specify the version satisfy any minimum.
+func (t Target) LibCVersionAtLeast(v string) bool {
+	return t.LibCVersion == "" || v == "" || CompareVersions(t.LibCVersion, v) >= 0
+}
+
+// AndroidAPI returns the Android API level, or 0 when it is not specified
+func (t Target) AndroidAPI() int {
+	if t.OS != "android" {
+		return 0
+	}
+	n, _ := strconv.Atoi(majorVersion(t.OSVersion))
+	return n
+}
+
+// IsSimulator checks if the target is an Apple simulator
+func (t Target) IsSimulator() bool {
+	return t.Environment == "simulator"
+}
+
+// IsMacCatalyst checks if the target is an iOS app built for macOS
+func (t Target) IsMacCatalyst() bool {
+	return t.Environment == "macabi"
+}
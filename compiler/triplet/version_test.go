@@ -0,0 +1,103 @@
+package triplet
+
+import (
+	"testing"
+)
+
+func TestParseTarget_Versions(t *testing.T) {
+	tests := []struct {
+		input     string
+		os        string
+		osVersion string
+		env       string
+		libc      string
+		libcVer   string
+	}{
+		{"x86_64-linux-gnu", "linux", "", "gnu", "glibc", ""},
+		{"arm64-apple-darwin21.6.0", "darwin", "12", "", "unknown", ""},
+		{"x86_64-apple-darwin19", "darwin", "10.15", "", "unknown", ""},
+		{"arm64-apple-darwin25.0.0", "darwin", "26", "", "unknown", ""},
+		{"arm64-apple-macosx11.0", "darwin", "11.0", "", "unknown", ""},
+		{"arm64-apple-ios14-simulator", "ios", "14", "simulator", "unknown", ""},
+		{"x86_64-apple-ios13.1-macabi", "ios", "13.1", "macabi", "unknown", ""},
+		{"aarch64-linux-android24", "android", "24", "android", "bionic", ""},
+		{"armv7a-linux-androideabi21", "android", "21", "androideabi", "bionic", ""},
+		{"x86_64-unknown-freebsd14.0", "freebsd", "14.0", "", "unknown", ""},
+		{"x86_64-pc-solaris2.11", "solaris", "2.11", "", "unknown", ""},
+		{"x86_64-w64-mingw32", "windows", "", "gnu", "mingw", ""},
+		{"x86_64-linux-gnu.2.31", "linux", "", "gnu", "glibc", "2.31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, _, err := ParseTarget(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.OS != tt.os || got.OSVersion != tt.osVersion || got.Environment != tt.env ||
+				got.LibC != tt.libc || got.LibCVersion != tt.libcVer {
+				t.Errorf("ParseTarget(%q) = %#v", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestParseZig_LibCVersion(t *testing.T) {
+	got, err := ParseZig("x86_64-linux.5.10...6.1-gnu.2.31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.OSVersion != "5.10" || got.LibC != "glibc" || got.LibCVersion != "2.31" {
+		t.Errorf("ParseZig() = %#v", got)
+	}
+	if s, _ := got.Zig(); s != "x86_64-linux-gnu.2.31" {
+		t.Errorf("Zig() = %q", s)
+	}
+}
+
+func TestTarget_MatchVersions(t *testing.T) {
+	parse := func(s string) Target {
+		ret, _, err := ParseTarget(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ret
+	}
+	tests := []struct {
+		target   string
+		required string
+		expected bool
+	}{
+		{"aarch64-linux-android26", "aarch64-linux-android24", true},
+		{"aarch64-linux-android26", "aarch64-linux-android26", true},
+		{"aarch64-linux-android24", "aarch64-linux-android26", false},
+		{"aarch64-linux-android", "aarch64-linux-android26", true},
+		{"aarch64-linux-android24", "aarch64-linux-android", true},
+		{"arm64-apple-darwin23.1.0", "arm64-apple-macosx11.0", true},
+		{"arm64-apple-macosx11.0", "arm64-apple-macosx12", false},
+		{"x86_64-linux-gnu.2.35", "x86_64-linux-gnu.2.31", true},
+		{"x86_64-linux-gnu.2.28", "x86_64-linux-gnu.2.31", false},
+	}
+	for _, tt := range tests {
+		target, required := parse(tt.target), parse(tt.required)
+		if got := target.Match(required); got != tt.expected {
+			t.Errorf("%s.Match(%s) = %v, want %v", tt.target, tt.required, got, tt.expected)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		v1, v2   string
+		expected int
+	}{
+		{"11", "11.0", 0},
+		{"10.15", "11", -1},
+		{"2.31", "2.4", 1},
+		{"26", "26.0.1", -1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.v1, tt.v2); got != tt.expected {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.v1, tt.v2, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package triplet
+
+import (
+	"strconv"
+	"strings"
+)
+
+// WebAssembly targets come in three flavors:
+//
+//	wasm32-unknown-emscripten  browser and node.js, emscripten runtime
+//	wasm32-wasip1              WASI preview 1 (wasm32-wasi is the old name)
+//	wasm32-wasip2              WASI preview 2, the component model
+//	wasm32-wasip1-threads      WASI preview 1 with wasi-threads
+//	wasm32-unknown-unknown     bare WebAssembly, no system interface
+//
+// The WASI preview level is kept in OSVersion as 0.1 or 0.2, so that the text
+// form spells it as wasi.0.1 and patterns can select it. The threads
+// proposal is kept in Environment.
+
+// parseWASIPreview returns the preview level spelled in the wasi OS
+// component: wasip2 gives 0.2. The unversioned wasi is preview 1.
+func parseWASIPreview(s string) string {
+	s = strings.ToLower(s)
+	switch {
+	case s == "wasi":
+		return "0.1"
+	case strings.HasPrefix(s, "wasip") && isVersion(s[5:]):
+		return "0." + s[5:]
+	}
+	return ""
+}
+
+// IsWasm checks if the target is a WebAssembly target, regardless of the
+// system interface
+func (t Target) IsWasm() bool {
+	return t.Arch == "wasm32" || t.Arch == "wasm64"
+}
+
+// IsWASI checks if the target uses the WebAssembly System Interface
+func (t Target) IsWASI() bool {
+	return t.OS == "wasi"
+}
+
+// IsEmscripten checks if the target uses the emscripten runtime
+func (t Target) IsEmscripten() bool {
+	return t.OS == "emscripten"
+}
+
+// WASIPreview returns the WASI preview level (1 for wasip1, 2 for wasip2),
+// or 0 when the target is not a WASI target or does not specify the level
+func (t Target) WASIPreview() int {
+	if t.OS != "wasi" {
+		return 0
+	}
+	_, minor, _ := strings.Cut(t.OSVersion, ".")
+	n, _ := strconv.Atoi(majorVersion(minor))
+	return n
+}
+
+// HasThreads checks if the target enables the wasi-threads proposal
+func (t Target) HasThreads() bool {
+	return t.Environment == "threads"
+}
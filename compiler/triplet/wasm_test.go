@@ -0,0 +1,138 @@
+package triplet
+
+import (
+	"testing"
+)
+
+func TestParseTarget_Wasm(t *testing.T) {
+	tests := []struct {
+		input   string
+		os      string
+		preview int
+		env     string
+		wasi    bool
+		emsc    bool
+	}{
+		{"wasm32-wasi", "wasi", 1, "", true, false},
+		{"wasm32-wasip1", "wasi", 1, "", true, false},
+		{"wasm32-unknown-wasip1", "wasi", 1, "", true, false},
+		{"wasm32-wasip2", "wasi", 2, "", true, false},
+		{"wasm32-wasip1-threads", "wasi", 1, "threads", true, false},
+		{"wasm32-wasi-threads", "wasi", 1, "threads", true, false},
+		{"wasm64-wasip1", "wasi", 1, "", true, false},
+		{"wasm32-unknown-emscripten", "emscripten", 0, "", false, true},
+		{"wasm32-unknown-unknown", "unknown", 0, "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, _, err := ParseTarget(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.IsWasm() || got.ObjectFormat != Wasm {
+				t.Errorf("ParseTarget(%q) = %#v, want a wasm target", tt.input, got)
+			}
+			if got.OS != tt.os || got.WASIPreview() != tt.preview || got.Environment != tt.env ||
+				got.IsWASI() != tt.wasi || got.IsEmscripten() != tt.emsc {
+				t.Errorf("ParseTarget(%q) = %#v", tt.input, got)
+			}
+			if got.HasThreads() != (tt.env == "threads") {
+				t.Errorf("HasThreads() = %v", got.HasThreads())
+			}
+		})
+	}
+}
+
+func TestTarget_WasmMatch(t *testing.T) {
+	tests := []struct {
+		target, other string
+		want          bool
+	}{
+		{"wasm32-wasip1", "wasm32-wasi", true},
+		{"wasm32-wasip2", "wasm32-wasip2", true},
+		{"wasm32-wasip2", "wasm32-wasip1", false},
+		{"wasm32-wasip1", "wasm32-wasip2", false},
+		{"wasm32-wasip1-threads", "wasm32-wasip1-threads", true},
+		{"wasm32-unknown-emscripten", "wasm32-wasip1", false},
+		{"wasm64-wasip1", "wasm32-wasip1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+"/"+tt.other, func(t *testing.T) {
+			target := mustParseTarget(t, tt.target)
+			if got := target.Match(mustParseTarget(t, tt.other)); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	p := MustParsePattern("wasm32-wasi.0.2")
+	for s, want := range map[string]bool{"wasm32-wasip2": true, "wasm32-wasip1": false} {
+		if got := p.Match(mustParseTarget(t, s)); got != want {
+			t.Errorf("%s.Match(%s) = %v, want %v", p, s, got, want)
+		}
+	}
+}
+
+func TestWasmDialects(t *testing.T) {
+	tests := []struct {
+		input string
+		rust  string
+		zig   string
+		cmake string
+		text  string
+	}{
+		{"wasm32-wasi", "wasm32-wasip1", "wasm32-wasi", "WASI", "wasm32-wasi.0.1-wasm-unknown"},
+		{"wasm32-wasip2", "wasm32-wasip2", "", "WASI", "wasm32-wasi.0.2-wasm-unknown"},
+		{"wasm32-wasip1-threads", "wasm32-wasip1-threads", "", "WASI", "wasm32-wasi.0.1-wasm-unknown-threads"},
+		{"wasm32-unknown-emscripten", "wasm32-unknown-emscripten", "wasm32-emscripten", "Emscripten", "wasm32-emscripten-wasm-unknown"},
+		{"wasm32-unknown-unknown", "wasm32-unknown-unknown", "wasm32-freestanding", "", "wasm32-unknown-wasm-unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			target, _, err := ParseTarget(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s, err := target.Rust(); err != nil || s != tt.rust {
+				t.Errorf("Rust() = %q, %v, want %q", s, err, tt.rust)
+			}
+			if s, err := target.Zig(); (tt.zig == "") != (err != nil) || s != tt.zig {
+				t.Errorf("Zig() = %q, %v, want %q", s, err, tt.zig)
+			}
+			if cm, err := target.CMake(); (tt.cmake == "") != (err != nil) || cm.Name != tt.cmake {
+				t.Errorf("CMake() = %q, %v, want %q", cm.Name, err, tt.cmake)
+			}
+			if s := target.Text(); s != tt.text {
+				t.Errorf("Text() = %q, want %q", s, tt.text)
+			}
+			if p, err := ParseRust(tt.rust); err != nil || !p.Match(target) {
+				t.Errorf("ParseRust(%q) = %#v, %v", tt.rust, p, err)
+			}
+		})
+	}
+}
+
+func TestGoOSArch_WASI(t *testing.T) {
+	g, err := FromGo("wasip1", "wasm", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.WASIPreview() != 1 {
+		t.Errorf("FromGo(wasip1) = %#v, want preview 1", g)
+	}
+	for _, s := range []string{"wasm32-wasip2", "wasm32-wasip1-threads", "wasm32-unknown-unknown"} {
+		target, _, _ := ParseTarget(s)
+		if _, err := target.GoOSArch(); err == nil {
+			t.Errorf("%s: GoOSArch() succeeded, want an error", s)
+		}
+	}
+}
+
+func mustParseTarget(t *testing.T, s string) Target {
+	t.Helper()
+	target, _, err := ParseTarget(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
@@ -0,0 +1,131 @@
+// Package diag parses the diagnostics that gcc, clang and msvc print into
+// structured records and writes them as JSON, SARIF or CI annotations.
+package diag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Note = Severity(iota)
+	Remark
+	Warning
+	Error
+	Fatal
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Note:
+		return "note"
+	case Remark:
+		return "remark"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	case Fatal:
+		return "fatal error"
+	default:
+		return "<INVALID-Severity>"
+	}
+}
+
+// ParseSeverity parses the severity as compilers spell it
+func ParseSeverity(s string) (Severity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "note", "message":
+		return Note, nil
+	case "remark":
+		return Remark, nil
+	case "warning", "pedwarn", "anachronism":
+		return Warning, nil
+	case "error", "permerror", "sorry, unimplemented":
+		return Error, nil
+	case "fatal error", "fatal", "internal compiler error", "ice":
+		return Fatal, nil
+	}
+	return Note, fmt.Errorf("unsupported severity '%s'", s)
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	v, err := ParseSeverity(string(text))
+	if err == nil {
+		*s = v
+	}
+	return err
+}
+
+// Location is a position in a source file, the line and the column are
+// 1-based, zero when unknown
+type Location struct {
+	File   string `json:"file,omitempty" yaml:"file,omitempty"`
+	Line   int    `json:"line,omitempty" yaml:"line,omitempty"`
+	Column int    `json:"column,omitempty" yaml:"column,omitempty"`
+}
+
+func (l Location) String() string {
+	s := l.File
+	if l.Line > 0 {
+		s += ":" + strconv.Itoa(l.Line)
+		if l.Column > 0 {
+			s += ":" + strconv.Itoa(l.Column)
+		}
+	}
+	return s
+}
+
+// FixIt is a suggested edit: the text from Start up to, but not including,
+// End is replaced with Replacement
+type FixIt struct {
+	Start       Location `json:"start" yaml:"start"`
+	End         Location `json:"end" yaml:"end"`
+	Replacement string   `json:"replacement" yaml:"replacement"`
+}
+
+// Diagnostic is an error, warning or remark with the notes that follow it
+type Diagnostic struct {
+	Location `yaml:",inline"`
+	Severity Severity `json:"severity" yaml:"severity"`
+	Code     string   `json:"code,omitempty" yaml:"code,omitempty"` // C4996, LNK2019, -Wunused-variable
+	Message  string   `json:"message" yaml:"message"`
+
+	Notes []*Diagnostic `json:"notes,omitempty" yaml:"notes,omitempty"`
+
+	// IncludeStack lists where the file was included from, the innermost
+	// include first
+	IncludeStack []Location `json:"include-stack,omitempty" yaml:"include-stack,omitempty"`
+	FixIts       []FixIt    `json:"fixits,omitempty" yaml:"fixits,omitempty"`
+}
+
+// String formats the diagnostic the way gcc prints it, without the notes
+func (d *Diagnostic) String() string {
+	s := d.Severity.String() + ": " + d.Message
+	if loc := d.Location.String(); loc != "" {
+		s = loc + ": " + s
+	}
+	if d.Code != "" {
+		s += " [" + d.Code + "]"
+	}
+	return s
+}
+
+// Count returns the number of diagnostics with the specified severity or a
+// higher one
+func Count(diags []*Diagnostic, level Severity) int {
+	n := 0
+	for _, d := range diags {
+		if d.Severity >= level {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,97 @@
+package diag
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// gcc -fdiagnostics-format=json
+type gccDiagnostic struct {
+	Kind         string           `json:"kind"`
+	Message      string           `json:"message"`
+	Option       string           `json:"option"`
+	ColumnOrigin *int             `json:"column-origin"`
+	Locations    []gccLocation    `json:"locations"`
+	Children     []*gccDiagnostic `json:"children"`
+	FixIts       []gccFixIt       `json:"fixits"`
+}
+
+type gccLocation struct {
+	Caret  *gccPosition `json:"caret"`
+	Finish *gccPosition `json:"finish"`
+}
+
+type gccPosition struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+type gccFixIt struct {
+	Start  gccPosition `json:"start"`
+	Next   gccPosition `json:"next"`
+	String string      `json:"string"`
+}
+
+// ParseGCCJSON parses the output of gcc -fdiagnostics-format=json, which is
+// a JSON array per translation unit
+func ParseGCCJSON(r io.Reader) ([]*Diagnostic, error) {
+	ret := []*Diagnostic{}
+	dec := json.NewDecoder(r)
+	for {
+		var dd []*gccDiagnostic
+		err := dec.Decode(&dd)
+		if errors.Is(err, io.EOF) {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dd {
+			ret = append(ret, d.convert(1))
+		}
+	}
+}
+
+func (g *gccDiagnostic) convert(origin int) *Diagnostic {
+	if g.ColumnOrigin != nil {
+		origin = *g.ColumnOrigin
+	}
+	// the columns are reported 1-based unless column-origin says otherwise
+	pos := func(p gccPosition) Location {
+		l := Location{File: p.File, Line: p.Line}
+		if p.Column > 0 || origin == 0 {
+			l.Column = p.Column + 1 - origin
+		}
+		return l
+	}
+	d := &Diagnostic{Code: g.Option, Message: g.Message}
+	var err error
+	if d.Severity, err = ParseSeverity(g.Kind); err != nil {
+		d.Severity = Error
+	}
+	if len(g.Locations) > 0 && g.Locations[0].Caret != nil {
+		d.Location = pos(*g.Locations[0].Caret)
+	}
+	for _, c := range g.Children {
+		d.Notes = append(d.Notes, c.convert(origin))
+	}
+	for _, f := range g.FixIts {
+		d.FixIts = append(d.FixIts, FixIt{Start: pos(f.Start), End: pos(f.Next), Replacement: f.String})
+	}
+	return d
+}
+
+// WriteJSON writes the diagnostics as a JSON array
+func WriteJSON(w io.Writer, diags []*Diagnostic) error {
+	if diags == nil {
+		diags = []*Diagnostic{}
+	}
+	buf, err := json.MarshalIndent(diags, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(buf, '\n'))
+	return err
+}
@@ -0,0 +1,33 @@
+package diag
+
+import (
+	"os"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/env"
+)
+
+// LocaleEnv returns the variables that make the compilers of the chain print
+// untranslated diagnostics, which is what the parser recognizes: LC_ALL=C
+// for gcc and clang, VSLANG=1033 for msvc and for the Microsoft linker that
+// clang uses for msvc targets. Both are returned when tc is nil.
+func LocaleEnv(tc *toolchain.Chain) []string {
+	switch {
+	case tc == nil:
+		return []string{"LC_ALL=C", "VSLANG=1033"}
+	case tc.IsMSVC():
+		return []string{"VSLANG=1033"}
+	case tc.Target.IsMSVC():
+		return []string{"LC_ALL=C", "VSLANG=1033"}
+	}
+	return []string{"LC_ALL=C"}
+}
+
+// ForceLocale adds the LocaleEnv variables to the environment, an empty
+// environment stands for the one of the current process
+func ForceLocale(environ []string, tc *toolchain.Chain) []string {
+	if len(environ) == 0 {
+		environ = os.Environ()
+	}
+	return env.Join(env.Merge(env.Split(environ), env.Split(LocaleEnv(tc))))
+}
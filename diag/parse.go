@@ -0,0 +1,307 @@
+package diag
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// gcc and clang: file:line:col: severity: message [-Wflag]
+	gccDiag = regexp.MustCompile(`^(.+?):(?:(\d+):(?:(\d+):)?)? (fatal error|error|warning|note|remark|sorry, unimplemented|internal compiler error): (.*)$`)
+	// GNU ld: file:line: or file:(.section+0x10): undefined reference
+	ldDiag = regexp.MustCompile(`^(?:\S*ld(?:\.\w+)?: )?(.+?):(?:(\d+)|\([^)]*\)): (undefined reference to .+|multiple definition of .+)$`)
+	// msvc and clang-cl: file(line,col): severity C1234: message
+	msvcDiag = regexp.MustCompile(`^\s*(?:\d+>)?(.+?)\((\d+)(?:,(\d+))?(?:,\d+){0,2}\)\s*:\s*(fatal error|error|warning|note|message)(?:\s+([A-Z]+\d+))?\s*:\s*(.*)$`)
+	// msvc tools: LINK : fatal error LNK1104: message
+	msvcToolDiag = regexp.MustCompile(`^\s*(?:\d+>)?(.+?)\s*:\s*(?:Command line\s+)?(fatal error|error|warning)\s+([A-Z]+\d+)\s*:\s*(.*)$`)
+
+	includedFrom = regexp.MustCompile(`^In file included from (.+?):(\d+)(?::(\d+))?[:,]$`)
+	includedNext = regexp.MustCompile(`^\s+from (.+?):(\d+)(?::(\d+))?[:,]$`)
+
+	// -fdiagnostics-parseable-fixits: fix-it:"file":{l:c-l:c}:"text"
+	fixItLine = regexp.MustCompile(`^fix-it:"((?:[^"\\]|\\.)*)":\{(\d+):(\d+)-(\d+):(\d+)\}:"((?:[^"\\]|\\.)*)"$`)
+
+	flagCode    = regexp.MustCompile(`\s\[(-[A-Za-z][^\[\]\s]*)\]$`)
+	msbuildProj = regexp.MustCompile(`\s+\[[^\[\]]+proj\]$`)
+	ansiEscape  = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+	toolName    = regexp.MustCompile(`^(?:[\w.+]+-)?(?:ld(?:\.\w+)?|lld(?:-link)?|wasm-ld|collect2|cc1\w*|gcc|g\+\+|cc|c\+\+|clang(?:\+\+|-cl)?|as|ar)(?:-[\d.]+)?(?:\.exe)?$`)
+)
+
+// Parser collects diagnostics from compiler output. It recognizes the text
+// formats of gcc, clang and msvc, the -fdiagnostics-format=json output of
+// gcc and the -fdiagnostics-parseable-fixits lines. Any other output is
+// ignored, so it can be fed the output of a whole build.
+//
+// A diagnostic is complete when the next one starts, as the notes follow
+// the diagnostic they belong to; call Flush at the end of the output.
+type Parser struct {
+	// Handler, when set, is called for each complete diagnostic
+	Handler func(*Diagnostic)
+
+	mu       sync.Mutex
+	lines    lineBuffer
+	diags    []*Diagnostic
+	cur      *Diagnostic // waiting for notes
+	last     *Diagnostic // receives fix-its
+	includes []Location
+}
+
+// Parse parses the diagnostics in r
+func Parse(r io.Reader) ([]*Diagnostic, error) {
+	p := &Parser{}
+	if _, err := io.Copy(p, r); err != nil {
+		return nil, err
+	}
+	p.Flush()
+	return p.Diagnostics(), nil
+}
+
+// Write parses the output, the last incomplete line is kept until the rest
+// of it arrives or Flush is called
+func (p *Parser) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lines.feed(b, p.parseLine)
+	return len(b), nil
+}
+
+// Flush parses the incomplete line and completes the pending diagnostic
+func (p *Parser) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lines.flush(p.parseLine)
+	p.finish()
+	p.includes = nil
+}
+
+// Diagnostics returns the complete diagnostics in the order they were
+// printed
+func (p *Parser) Diagnostics() []*Diagnostic {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*Diagnostic{}, p.diags...)
+}
+
+// Tee returns a writer that passes the output to w, which may be nil, and
+// parses it. Each tee keeps its own incomplete line, so stdout and stderr
+// of a command can go through separate tees of the same parser. Close
+// parses the last incomplete line.
+func (p *Parser) Tee(w io.Writer) io.WriteCloser {
+	return &tee{p: p, w: w}
+}
+
+type tee struct {
+	p     *Parser
+	w     io.Writer
+	lines lineBuffer
+}
+
+func (t *tee) Write(b []byte) (int, error) {
+	t.p.mu.Lock()
+	t.lines.feed(b, t.p.parseLine)
+	t.p.mu.Unlock()
+	if t.w != nil {
+		return t.w.Write(b)
+	}
+	return len(b), nil
+}
+
+func (t *tee) Close() error {
+	t.p.mu.Lock()
+	t.lines.flush(t.p.parseLine)
+	t.p.mu.Unlock()
+	return nil
+}
+
+type lineBuffer struct {
+	buf []byte
+}
+
+func (lb *lineBuffer) feed(b []byte, line func(string)) {
+	lb.buf = append(lb.buf, b...)
+	for {
+		i := bytes.IndexByte(lb.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line(string(lb.buf[:i]))
+		lb.buf = lb.buf[i+1:]
+	}
+}
+
+func (lb *lineBuffer) flush(line func(string)) {
+	if len(lb.buf) > 0 {
+		line(string(lb.buf))
+		lb.buf = nil
+	}
+}
+
+// finish completes the pending diagnostic
+func (p *Parser) finish() {
+	if p.cur == nil {
+		return
+	}
+	d := p.cur
+	p.cur, p.last = nil, nil
+	p.diags = append(p.diags, d)
+	if p.Handler != nil {
+		p.Handler(d)
+	}
+}
+
+func (p *Parser) parseLine(ln string) {
+	ln = ansiEscape.ReplaceAllString(strings.TrimRight(ln, "\r"), "")
+
+	if t := strings.TrimSpace(ln); strings.HasPrefix(t, "[{") || t == "[]" {
+		if dd, err := ParseGCCJSON(strings.NewReader(t)); err == nil {
+			p.finish()
+			for _, d := range dd {
+				p.cur = d
+				p.finish()
+			}
+			return
+		}
+	}
+	if m := includedFrom.FindStringSubmatch(ln); m != nil {
+		// gcc lists the rest with "from" lines, clang repeats the line for
+		// each level starting from the outermost one
+		p.includes = append([]Location{location(m[1], m[2], m[3])}, p.includes...)
+		return
+	}
+	if m := includedNext.FindStringSubmatch(ln); m != nil && len(p.includes) > 0 {
+		p.includes = append(p.includes, location(m[1], m[2], m[3]))
+		return
+	}
+	if m := fixItLine.FindStringSubmatch(ln); m != nil {
+		if p.last != nil {
+			p.last.FixIts = append(p.last.FixIts, FixIt{
+				Start:       location(unquote(m[1]), m[2], m[3]),
+				End:         location(unquote(m[1]), m[4], m[5]),
+				Replacement: unquote(m[6]),
+			})
+		}
+		return
+	}
+
+	d := parseDiagnostic(ln)
+	if d == nil {
+		return
+	}
+	d.IncludeStack, p.includes = p.includes, nil
+	if d.Severity == Note && p.cur != nil {
+		p.cur.Notes = append(p.cur.Notes, d)
+		p.last = d
+		return
+	}
+	p.finish()
+	p.cur, p.last = d, d
+}
+
+// parseDiagnostic parses a line in one of the text formats
+func parseDiagnostic(ln string) *Diagnostic {
+	if m := msvcDiag.FindStringSubmatch(ln); m != nil {
+		d := &Diagnostic{Location: location(m[1], m[2], m[3]), Code: m[5]}
+		d.Severity, _ = ParseSeverity(m[4])
+		d.Message = msbuildProj.ReplaceAllString(m[6], "")
+		if d.Code == "" {
+			d.Message, d.Code = splitFlagCode(d.Message)
+		}
+		return d
+	}
+	if m := gccDiag.FindStringSubmatch(ln); m != nil {
+		d := &Diagnostic{Location: location(m[1], m[2], m[3])}
+		d.Severity, _ = ParseSeverity(m[4])
+		d.Message, d.Code = splitFlagCode(m[5])
+		if d.Line == 0 && isTool(d.File) {
+			d.File = ""
+		}
+		return d
+	}
+	if m := ldDiag.FindStringSubmatch(ln); m != nil {
+		return &Diagnostic{Location: location(m[1], m[2], ""), Severity: Error, Message: m[3]}
+	}
+	if m := msvcToolDiag.FindStringSubmatch(ln); m != nil {
+		d := &Diagnostic{Location: Location{File: m[1]}, Code: m[3]}
+		d.Severity, _ = ParseSeverity(m[2])
+		d.Message = msbuildProj.ReplaceAllString(m[4], "")
+		if isTool(d.File) {
+			d.File = ""
+		}
+		return d
+	}
+	return nil
+}
+
+// splitFlagCode separates the warning option that gcc and clang append to
+// the message: [-Wunused], [-Werror,-Wunused] or [-Werror=unused]
+func splitFlagCode(msg string) (string, string) {
+	m := flagCode.FindStringSubmatchIndex(msg)
+	if m == nil {
+		return msg, ""
+	}
+	flags := strings.Split(msg[m[2]:m[3]], ",")
+	code := flags[len(flags)-1]
+	if s, ok := strings.CutPrefix(code, "-Werror="); ok {
+		code = "-W" + s
+	}
+	return msg[:m[0]], code
+}
+
+// isTool returns true for the names of compilers, linkers and other tools
+// that prefix the messages that are not about a source file
+func isTool(s string) bool {
+	base := s[strings.LastIndexAny(s, `/\`)+1:]
+	return !strings.ContainsAny(s, `/\.`) || toolName.MatchString(base)
+}
+
+func location(file, line, column string) Location {
+	l := Location{File: file}
+	l.Line, _ = strconv.Atoi(line)
+	l.Column, _ = strconv.Atoi(column)
+	return l
+}
+
+func unquote(s string) string {
+	if v, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return v
+	}
+	return s
+}
+
+// Run runs the command and parses its output on the way to the writers of
+// the command. The parser is flushed when the command exits.
+//
+// When stdout and stderr go to the same writer, they are read through a
+// single pipe and parsed in the order the command wrote them. Otherwise
+// each stream is parsed as it arrives: the lines of a stream keep their
+// order, but the order across the streams is not guaranteed, so a note on
+// one stream may attach to a diagnostic printed on the other.
+func (p *Parser) Run(c *exec.Cmd) error {
+	stdout := p.Tee(c.Stdout)
+	stderr := stdout
+	if !sameWriter(c.Stdout, c.Stderr) {
+		stderr = p.Tee(c.Stderr)
+	}
+	c.Stdout, c.Stderr = stdout, stderr
+	err := c.Run()
+	stdout.Close()
+	stderr.Close()
+	p.Flush()
+	return err
+}
+
+// sameWriter compares the writers like exec.Cmd does, so that a command
+// that writes both streams to the same writer keeps a single pipe
+func sameWriter(a, b io.Writer) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
@@ -0,0 +1,160 @@
+package diag
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const gccOutput = `a.c: In function 'main':
+a.c:2:30: warning: initialization of 'char *' from 'int' makes pointer from integer without a cast [-Wint-conversion]
+    2 | int main(){ int x; char *p = 1; return X(y); }
+      |                              ^
+In file included from b.h:1,
+                 from a.c:1:
+a.h:1:14: error: 'y' undeclared (first use in this function)
+    1 | #define X(a) a+
+      |              ^
+a.c:2:42: note: each undeclared identifier is reported only once for each function it appears in
+a.c:2:17: warning: unused variable 'x' [-Werror=unused-variable]
+cc1: all warnings being treated as errors
+/usr/bin/ld: /tmp/ccCNaLnf.o: in function ` + "`main'" + `:
+l.c:(.text+0x5): undefined reference to ` + "`foo'" + `
+collect2: error: ld returned 1 exit status
+`
+
+const clangOutput = "[1/2] Building CXX object CMakeFiles/app.dir/main.cpp.o\n" +
+	"In file included from src/main.cpp:1:\n" +
+	"In file included from include/app.h:3:\n" +
+	"\x1b[1minclude/util.h:5:10: \x1b[0m\x1b[0;1;35mwarning: \x1b[0m\x1b[1munused variable 'n' [-Wunused-variable]\x1b[0m\n" +
+	"    5 |     int n = 0;\n" +
+	"      |         ^\n" +
+	"src/main.cpp:7:8: error: expected ';' after expression\n" +
+	"    7 |   foo()\n" +
+	"      |        ^\n" +
+	"      |        ;\n" +
+	`fix-it:"src/main.cpp":{7:8-7:8}:";"` + "\n" +
+	"src/main.cpp:9:3: error: no matching function for call to 'bar' [-Werror,-Wfoo]\n" +
+	"src/main.cpp:2:6: note: candidate function not viable: requires 0 arguments, but 1 was provided\n" +
+	`fix-it:"src/main.cpp":{9:7-9:8}:"\"\\n\""` + "\n" +
+	"clang: error: linker command failed with exit code 1 (use -v to see invocation)\n"
+
+const msvcOutput = `[1/3] Building CXX object CMakeFiles/app.dir/main.cpp.obj
+FAILED: CMakeFiles/app.dir/main.cpp.obj
+C:\src\main.cpp(12,5): error C2065: 'x': undeclared identifier
+C:\src\main.cpp(20): warning C4996: 'strcpy': This function or variable may be unsafe.
+C:\Program Files\MSVC\include\string.h(130): note: see declaration of 'strcpy'
+  1>C:\src\util.cpp(3,1): warning C4100: 'p': unreferenced formal parameter [C:\build\app.vcxproj]
+C:\src\x.cpp(4,10): error: use of undeclared identifier 'y' [-Wfoo]
+main.obj : error LNK2019: unresolved external symbol foo referenced in function main
+LINK : fatal error LNK1120: 1 unresolved externals
+cl : Command line warning D9002 : ignoring unknown option '/foo'
+`
+
+const gccJSONOutput = `[{"kind": "error", "column-origin": 1, "children": [{"kind": "note", "locations": [{"caret": {"byte-column": 14, "display-column": 14, "line": 1, "file": "a.h", "column": 14}}], "message": "in definition of macro 'X'"}], "locations": [{"caret": {"line": 2, "file": "a.c", "column": 42}}], "fixits": [{"start": {"line": 2, "file": "a.c", "column": 40}, "next": {"line": 2, "file": "a.c", "column": 41}, "string": "z"}], "message": "'y' undeclared"}, {"kind": "warning", "locations": [{"caret": {"line": 2, "file": "a.c", "column": 17}}], "column-origin": 1, "option": "-Wunused-variable", "message": "unused variable 'x'"}]
+`
+
+// summary formats the diagnostic with the notes, includes and fix-its
+func summary(d *Diagnostic) string {
+	s := d.String()
+	for _, l := range d.IncludeStack {
+		s += " <" + l.String()
+	}
+	for _, f := range d.FixIts {
+		s += " fix(" + f.Start.String() + "-" + f.End.String() + "=" + f.Replacement + ")"
+	}
+	for _, n := range d.Notes {
+		s += " {" + summary(n) + "}"
+	}
+	return s
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{"gcc", gccOutput, []string{
+			"a.c:2:30: warning: initialization of 'char *' from 'int' makes pointer from integer without a cast [-Wint-conversion]",
+			"a.h:1:14: error: 'y' undeclared (first use in this function) <b.h:1 <a.c:1 {a.c:2:42: note: each undeclared identifier is reported only once for each function it appears in}",
+			"a.c:2:17: warning: unused variable 'x' [-Wunused-variable]",
+			"l.c: error: undefined reference to `foo'",
+			"error: ld returned 1 exit status",
+		}},
+		{"clang", clangOutput, []string{
+			"include/util.h:5:10: warning: unused variable 'n' [-Wunused-variable] <include/app.h:3 <src/main.cpp:1",
+			"src/main.cpp:7:8: error: expected ';' after expression fix(src/main.cpp:7:8-src/main.cpp:7:8=;)",
+			"src/main.cpp:9:3: error: no matching function for call to 'bar' [-Wfoo] {src/main.cpp:2:6: note: candidate function not viable: requires 0 arguments, but 1 was provided fix(src/main.cpp:9:7-src/main.cpp:9:8=\"\\n\")}",
+			"error: linker command failed with exit code 1 (use -v to see invocation)",
+		}},
+		{"msvc", msvcOutput, []string{
+			`C:\src\main.cpp:12:5: error: 'x': undeclared identifier [C2065]`,
+			`C:\src\main.cpp:20: warning: 'strcpy': This function or variable may be unsafe. [C4996] {C:\Program Files\MSVC\include\string.h:130: note: see declaration of 'strcpy'}`,
+			`C:\src\util.cpp:3:1: warning: 'p': unreferenced formal parameter [C4100]`,
+			`C:\src\x.cpp:4:10: error: use of undeclared identifier 'y' [-Wfoo]`,
+			`main.obj: error: unresolved external symbol foo referenced in function main [LNK2019]`,
+			`fatal error: 1 unresolved externals [LNK1120]`,
+			`warning: ignoring unknown option '/foo' [D9002]`,
+		}},
+		{"gcc-json", gccJSONOutput, []string{
+			"a.c:2:42: error: 'y' undeclared fix(a.c:2:40-a.c:2:41=z) {a.h:1:14: note: in definition of macro 'X'}",
+			"a.c:2:17: warning: unused variable 'x' [-Wunused-variable]",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dd, err := Parse(strings.NewReader(tt.output))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, d := range dd {
+				got = append(got, summary(d))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Parse() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParser_Stream(t *testing.T) {
+	completed := []string{}
+	p := &Parser{Handler: func(d *Diagnostic) { completed = append(completed, d.Message) }}
+	out := &strings.Builder{}
+	w := p.Tee(out)
+
+	// a diagnostic is complete when the next one starts
+	w.Write([]byte("a.c:1:1: error: first\na.c:1:1: note: more about the first\na.c:2:"))
+	if len(completed) != 0 {
+		t.Errorf("completed %v before the next diagnostic", completed)
+	}
+	w.Write([]byte("1: warning: second\n"))
+	if strings.Join(completed, ",") != "first" {
+		t.Errorf("completed %v, want first", completed)
+	}
+	w.Close()
+	p.Flush()
+	if strings.Join(completed, ",") != "first,second" {
+		t.Errorf("completed %v after Flush", completed)
+	}
+	if !strings.HasSuffix(out.String(), "a.c:2:1: warning: second\n") {
+		t.Errorf("tee output = %q", out.String())
+	}
+}
+
+func TestParser_Run(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	p := &Parser{}
+	c := exec.Command("sh", "-c", `echo "a.c:1:2: error: to stderr" >&2; printf 'b.c:3:4: warning: unterminated'`)
+	if err := p.Run(c); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(p.Diagnostics()); n != 2 {
+		t.Errorf("Run() parsed %d diagnostics, want 2", n)
+	}
+}
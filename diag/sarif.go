@@ -0,0 +1,255 @@
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// SARIFOptions describe the tool that produced the diagnostics
+type SARIFOptions struct {
+	Tool    string // driver name, defaults to "compiler"
+	Version string
+
+	// BaseDir is the root of the sources, typically the repository. Files
+	// under it are written relative to the SRCROOT base, which is what code
+	// scanning services need to match them with the repository files.
+	BaseDir string
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver sarifDriver `json:"driver"`
+	} `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifact `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult            `json:"results"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Rules   []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifArtifact struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifPhysical struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifLocation struct {
+	ID               *int          `json:"id,omitempty"`
+	PhysicalLocation sarifPhysical `json:"physicalLocation"`
+	Message          *sarifMessage `json:"message,omitempty"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+type sarifChange struct {
+	ArtifactLocation sarifArtifact      `json:"artifactLocation"`
+	Replacements     []sarifReplacement `json:"replacements"`
+}
+
+type sarifFix struct {
+	ArtifactChanges []sarifChange `json:"artifactChanges"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId,omitempty"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	Fixes            []sarifFix      `json:"fixes,omitempty"`
+}
+
+// sarifLevel maps the severities to the SARIF levels
+func sarifLevel(s Severity) string {
+	switch {
+	case s >= Error:
+		return "error"
+	case s == Warning:
+		return "warning"
+	}
+	return "note"
+}
+
+// WriteSARIF writes the diagnostics as a SARIF 2.1.0 log with a single run.
+// Notes and include locations become related locations of the result.
+func WriteSARIF(w io.Writer, diags []*Diagnostic, opts SARIFOptions) error {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver = sarifDriver{Name: opts.Tool, Version: opts.Version}
+	if run.Tool.Driver.Name == "" {
+		run.Tool.Driver.Name = "compiler"
+	}
+	base := ""
+	if opts.BaseDir != "" {
+		abs, err := filepath.Abs(opts.BaseDir)
+		if err != nil {
+			return err
+		}
+		base = abs
+		run.OriginalURIBaseIDs = map[string]sarifArtifact{"SRCROOT": {URI: fileURI(abs) + "/"}}
+	}
+	physical := func(l Location) *sarifPhysical {
+		if l.File == "" {
+			return nil
+		}
+		p := &sarifPhysical{ArtifactLocation: artifact(l.File, base)}
+		if l.Line > 0 {
+			p.Region = &sarifRegion{StartLine: l.Line, StartColumn: l.Column}
+		}
+		return p
+	}
+
+	rules := map[string]bool{}
+	for _, d := range diags {
+		r := sarifResult{RuleID: d.Code, Level: sarifLevel(d.Severity), Message: sarifMessage{Text: d.Message}}
+		if d.Code != "" && !rules[d.Code] {
+			rules[d.Code] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: d.Code})
+		}
+		if p := physical(d.Location); p != nil {
+			r.Locations = []sarifLocation{{PhysicalLocation: *p}}
+		}
+		related := func(l Location, msg string) {
+			if p := physical(l); p != nil {
+				id := len(r.RelatedLocations) + 1
+				r.RelatedLocations = append(r.RelatedLocations, sarifLocation{ID: &id, PhysicalLocation: *p, Message: &sarifMessage{Text: msg}})
+			}
+		}
+		for _, l := range d.IncludeStack {
+			related(l, "In file included from here")
+		}
+		fixits := d.FixIts
+		for _, n := range d.Notes {
+			related(n.Location, n.Message)
+			fixits = append(fixits, n.FixIts...)
+		}
+		for _, f := range fixits {
+			r.Fixes = append(r.Fixes, sarifFix{ArtifactChanges: []sarifChange{{
+				ArtifactLocation: artifact(f.Start.File, base),
+				Replacements: []sarifReplacement{{
+					DeletedRegion:   sarifRegion{StartLine: f.Start.Line, StartColumn: f.Start.Column, EndLine: f.End.Line, EndColumn: f.End.Column},
+					InsertedContent: sarifMessage{Text: f.Replacement},
+				}},
+			}}})
+		}
+		run.Results = append(run.Results, r)
+	}
+
+	buf, err := json.MarshalIndent(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(buf, '\n'))
+	return err
+}
+
+// artifact makes the file relative to the base, when it is under it, or
+// turns it into a file URI
+func artifact(fn string, base string) sarifArtifact {
+	if base != "" && filepath.IsAbs(fn) {
+		if rel, err := filepath.Rel(base, fn); err == nil && !strings.HasPrefix(rel, "..") {
+			return sarifArtifact{URI: (&url.URL{Path: filepath.ToSlash(rel)}).String(), URIBaseID: "SRCROOT"}
+		}
+	}
+	if filepath.IsAbs(fn) {
+		return sarifArtifact{URI: fileURI(fn)}
+	}
+	return sarifArtifact{URI: (&url.URL{Path: filepath.ToSlash(fn)}).String()}
+}
+
+func fileURI(fn string) string {
+	p := filepath.ToSlash(fn)
+	if !strings.HasPrefix(p, "/") {
+		// C:/dir
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// WriteGitHubAnnotation writes the diagnostic as a GitHub Actions workflow
+// command, which annotates the file in pull requests. Files under baseDir
+// are written relative to it.
+func WriteGitHubAnnotation(w io.Writer, d *Diagnostic, baseDir string) error {
+	cmd := "notice"
+	switch {
+	case d.Severity >= Error:
+		cmd = "error"
+	case d.Severity == Warning:
+		cmd = "warning"
+	}
+	props := []string{}
+	if d.File != "" {
+		fn := d.File
+		if baseDir != "" && filepath.IsAbs(fn) {
+			if rel, err := filepath.Rel(baseDir, fn); err == nil && !strings.HasPrefix(rel, "..") {
+				fn = rel
+			}
+		}
+		props = append(props, "file="+escapeProperty(filepath.ToSlash(fn)))
+		if d.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", d.Line))
+			if d.Column > 0 {
+				props = append(props, fmt.Sprintf("col=%d", d.Column))
+			}
+		}
+	}
+	if d.Code != "" {
+		props = append(props, "title="+escapeProperty(d.Code))
+	}
+	msg := d.Message
+	for _, n := range d.Notes {
+		msg += "\n" + n.String()
+	}
+	s := "::" + cmd
+	if len(props) > 0 {
+		s += " " + strings.Join(props, ",")
+	}
+	_, err := fmt.Fprintf(w, "%s::%s\n", s, escapeData(msg))
+	return err
+}
+
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
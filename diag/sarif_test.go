@@ -0,0 +1,90 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	base := t.TempDir()
+	src := filepath.Join(base, "src", "main.cpp")
+	diags := []*Diagnostic{
+		{
+			Location: Location{File: src, Line: 9, Column: 3}, Severity: Error, Code: "-Wfoo", Message: "no matching function",
+			IncludeStack: []Location{{File: "other.cpp", Line: 1}},
+			Notes: []*Diagnostic{{
+				Location: Location{File: src, Line: 2, Column: 6}, Severity: Note, Message: "candidate function",
+				FixIts: []FixIt{{Start: Location{File: src, Line: 9, Column: 7}, End: Location{File: src, Line: 9, Column: 8}, Replacement: "x"}},
+			}},
+		},
+		{Severity: Fatal, Code: "LNK1120", Message: "1 unresolved externals"},
+		{Location: Location{File: src, Line: 12}, Severity: Warning, Code: "-Wfoo", Message: "again"},
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteSARIF(buf, diags, SARIFOptions{Tool: "clang", Version: "17.0.6", BaseDir: base}); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version %s with %d runs", log.Version, len(log.Runs))
+	}
+	run := log.Runs[0]
+	if d := run.Tool.Driver; d.Name != "clang" || d.Version != "17.0.6" || len(d.Rules) != 2 {
+		t.Errorf("driver = %+v", d)
+	}
+	if !strings.HasPrefix(run.OriginalURIBaseIDs["SRCROOT"].URI, "file:///") {
+		t.Errorf("SRCROOT = %+v", run.OriginalURIBaseIDs["SRCROOT"])
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("%d results, want 3", len(run.Results))
+	}
+
+	r := run.Results[0]
+	if r.Level != "error" || r.RuleID != "-Wfoo" || len(r.Locations) != 1 {
+		t.Fatalf("result = %+v", r)
+	}
+	loc := r.Locations[0].PhysicalLocation
+	if loc.ArtifactLocation != (sarifArtifact{URI: "src/main.cpp", URIBaseID: "SRCROOT"}) || *loc.Region != (sarifRegion{StartLine: 9, StartColumn: 3}) {
+		t.Errorf("location = %+v %+v", loc.ArtifactLocation, loc.Region)
+	}
+	if len(r.RelatedLocations) != 2 || r.RelatedLocations[1].Message.Text != "candidate function" {
+		t.Errorf("related locations = %+v", r.RelatedLocations)
+	}
+	if len(r.Fixes) != 1 || r.Fixes[0].ArtifactChanges[0].Replacements[0].DeletedRegion != (sarifRegion{StartLine: 9, StartColumn: 7, EndLine: 9, EndColumn: 8}) {
+		t.Errorf("fixes = %+v", r.Fixes)
+	}
+	if r := run.Results[1]; r.Level != "error" || len(r.Locations) != 0 {
+		t.Errorf("tool result = %+v", r)
+	}
+}
+
+func TestWriteGitHubAnnotation(t *testing.T) {
+	base := t.TempDir()
+	tests := []struct {
+		d    *Diagnostic
+		want string
+	}{
+		{&Diagnostic{Location: Location{File: filepath.Join(base, "a,b.c"), Line: 3, Column: 7}, Severity: Warning, Code: "-Wunused", Message: "unused 'x'"},
+			"::warning file=a%2Cb.c,line=3,col=7,title=-Wunused::unused 'x'\n"},
+		{&Diagnostic{Location: Location{File: "/elsewhere/a.h", Line: 1}, Severity: Error, Message: "100% wrong",
+			Notes: []*Diagnostic{{Location: Location{File: "a.c", Line: 2}, Severity: Note, Message: "here"}}},
+			"::error file=/elsewhere/a.h,line=1::100%25 wrong%0Aa.c:2: note: here\n"},
+		{&Diagnostic{Severity: Note, Message: "plain"}, "::notice::plain\n"},
+	}
+	for _, tt := range tests {
+		buf := &bytes.Buffer{}
+		if err := WriteGitHubAnnotation(buf, tt.d, base); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("WriteGitHubAnnotation() = %q, want %q", buf.String(), tt.want)
+		}
+	}
+}
@@ -5,8 +5,6 @@ go 1.22
 require (
 	github.com/adnsv/go-utils v0.8.0
 	github.com/alecthomas/kong v0.9.0
-	github.com/blang/semver v3.5.1+incompatible
-	github.com/blang/semver/v4 v4.0.0
 	github.com/jawher/mow.cli v1.2.0
 	github.com/josephspurrier/goversioninfo v1.4.0
 	github.com/winlabs/gowin32 v0.0.0-20221003142512-0d265587d3c9
@@ -14,4 +12,7 @@ require (
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require github.com/akavel/rsrc v0.10.2 // indirect
+require (
+	github.com/akavel/rsrc v0.10.2 // indirect
+	github.com/blang/semver/v4 v4.0.0 // indirect
+)
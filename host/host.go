@@ -0,0 +1,180 @@
+// Package host describes the platform go-build is running on: operating
+// system, architecture, C library, distribution, kernel and CPU features.
+package host
+
+import (
+	"bufio"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+// Info describes the host platform
+type Info struct {
+	OS          string  `json:"os" yaml:"os"`                                         // normalized, as in triplet.Target
+	Arch        string  `json:"arch" yaml:"arch"`                                     // normalized, as in triplet.Target
+	LibC        string  `json:"libc,omitempty" yaml:"libc,omitempty"`                 // glibc|musl|bionic
+	LibCVersion string  `json:"libc-version,omitempty" yaml:"libc-version,omitempty"` // e.g. 2.36
+	Interpreter string  `json:"interpreter,omitempty" yaml:"interpreter,omitempty"`   // ELF program interpreter of system executables
+	Kernel      string  `json:"kernel,omitempty" yaml:"kernel,omitempty"`             // kernel release
+	Distro      *Distro `json:"distro,omitempty" yaml:"distro,omitempty"`             // OS distribution
+	CPU         CPU     `json:"cpu" yaml:"cpu"`
+}
+
+// Distro identifies an OS distribution, on Linux it is read from os-release
+type Distro struct {
+	ID      string   `json:"id" yaml:"id"`                                    // e.g. debian, alpine, macos
+	IDLike  []string `json:"id-like,omitempty" yaml:"id-like,flow,omitempty"` // related distributions
+	Version string   `json:"version,omitempty" yaml:"version,omitempty"`      // e.g. 12, 3.19.1
+	Name    string   `json:"name,omitempty" yaml:"name,omitempty"`            // pretty name
+}
+
+// CPU describes the host processor
+type CPU struct {
+	Vendor   string   `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	Model    string   `json:"model,omitempty" yaml:"model,omitempty"`
+	Features []string `json:"features,omitempty" yaml:"features,flow,omitempty"`
+}
+
+// Detect queries the host platform
+func Detect() *Info {
+	i := &Info{
+		OS:   triplet.NormalizeOS(runtime.GOOS),
+		Arch: triplet.NormalizeArch(runtime.GOARCH),
+	}
+	if t, err := triplet.FromGo(runtime.GOOS, runtime.GOARCH, "", ""); err == nil {
+		i.OS, i.Arch = t.OS, t.Arch
+	}
+	detect(i)
+	return i
+}
+
+var (
+	currentOnce sync.Once
+	current     *Info
+)
+
+// Current returns the host description, it is detected once and cached
+func Current() *Info {
+	currentOnce.Do(func() {
+		current = Detect()
+	})
+	return current
+}
+
+// Target returns the host description as a target triplet, with the C
+// library version and the endianness and pointer width of the architecture
+func (i *Info) Target() triplet.Target {
+	t := triplet.NewTarget(i.Arch, i.OS, "", i.LibC)
+	t.LibCVersion = i.LibCVersion
+	return t
+}
+
+// HasFeature checks if the host CPU reports the specified feature flag
+func (i *Info) HasFeature(f string) bool {
+	for _, it := range i.CPU.Features {
+		if it == f {
+			return true
+		}
+	}
+	return false
+}
+
+// CanRun checks whether binaries built for the target run natively on the
+// host. Besides OS and architecture it compares the C library when both the
+// host and the target specify it, and requires the host C library to be at
+// least the version the target was built for.
+func (i *Info) CanRun(t triplet.Target) bool {
+	if t.OS != i.OS || t.Arch != i.Arch {
+		return false
+	}
+	if i.LibC != "" && t.LibC != "" && t.LibC != "unknown" && t.LibC != i.LibC {
+		return false
+	}
+	return i.Target().LibCVersionAtLeast(t.LibCVersion)
+}
+
+// parseOSRelease parses the contents of /etc/os-release
+func parseOSRelease(r io.Reader) *Distro {
+	d := &Distro{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		k, v, ok := strings.Cut(strings.TrimSpace(s.Text()), "=")
+		if !ok || strings.HasPrefix(k, "#") {
+			continue
+		}
+		v = strings.Trim(v, `"'`)
+		switch k {
+		case "ID":
+			d.ID = v
+		case "ID_LIKE":
+			d.IDLike = strings.Fields(v)
+		case "VERSION_ID":
+			d.Version = v
+		case "PRETTY_NAME":
+			d.Name = v
+		case "NAME":
+			if d.Name == "" {
+				d.Name = v
+			}
+		}
+	}
+	if d.ID == "" {
+		return nil
+	}
+	return d
+}
+
+// parseCPUInfo parses the contents of /proc/cpuinfo, only the first
+// processor entry is used
+func parseCPUInfo(r io.Reader) CPU {
+	c := CPU{}
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for s.Scan() {
+		ln := s.Text()
+		if strings.TrimSpace(ln) == "" && (c.Model != "" || len(c.Features) > 0) {
+			break
+		}
+		k, v, ok := strings.Cut(ln, ":")
+		if !ok {
+			continue
+		}
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+		switch k {
+		case "vendor_id", "CPU implementer":
+			if c.Vendor == "" {
+				c.Vendor = v
+			}
+		case "model name", "Model", "cpu model", "uarch":
+			if c.Model == "" {
+				c.Model = v
+			}
+		case "flags", "Features", "isa":
+			c.Features = strings.Fields(v)
+		}
+	}
+	return c
+}
+
+// libcFromInterpreter determines the C library flavor from the path of the
+// ELF program interpreter
+func libcFromInterpreter(interp string) string {
+	base := interp
+	if i := strings.LastIndexByte(base, '/'); i >= 0 {
+		base = base[i+1:]
+	}
+	switch {
+	case strings.HasPrefix(base, "ld-musl"):
+		return "musl"
+	case strings.HasPrefix(base, "ld-linux"), strings.HasPrefix(base, "ld64.so"), strings.HasPrefix(base, "ld.so"):
+		return "glibc"
+	case strings.HasPrefix(base, "linker"):
+		return "bionic"
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package host
+
+import (
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+func detect(i *Info) {
+	i.Kernel = command("uname", "-r")
+	if v := command("sw_vers", "-productVersion"); v != "" {
+		i.Distro = &Distro{ID: "macos", Version: v, Name: command("sw_vers", "-productName")}
+	}
+
+	i.CPU.Vendor = command("sysctl", "-n", "machdep.cpu.vendor")
+	i.CPU.Model = command("sysctl", "-n", "machdep.cpu.brand_string")
+	if i.Arch == "arm64" {
+		// hw.optional.arm.FEAT_XXX: 1
+		for _, ln := range strings.Split(command("sysctl", "hw.optional"), "\n") {
+			k, v, ok := strings.Cut(ln, ":")
+			if !ok || strings.TrimSpace(v) != "1" {
+				continue
+			}
+			k = strings.TrimPrefix(k, "hw.optional.")
+			k = strings.TrimPrefix(k, "arm.")
+			i.CPU.Features = append(i.CPU.Features, strings.ToLower(k))
+		}
+	} else {
+		ff := strings.Fields(command("sysctl", "-n", "machdep.cpu.features", "machdep.cpu.leaf7_features"))
+		for _, f := range ff {
+			i.CPU.Features = append(i.CPU.Features, strings.ToLower(f))
+		}
+	}
+	sort.Strings(i.CPU.Features)
+}
+
+func command(name string, args ...string) string {
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
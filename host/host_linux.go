@@ -0,0 +1,111 @@
+package host
+
+import (
+	"bytes"
+	"debug/elf"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// system executables used to find out the program interpreter
+var probeExecutables = []string{"/bin/sh", "/usr/bin/env", "/bin/ls"}
+
+func detect(i *Info) {
+	for _, fn := range probeExecutables {
+		if interp := readInterpreter(fn); interp != "" {
+			i.Interpreter = interp
+			i.LibC = libcFromInterpreter(interp)
+			break
+		}
+	}
+	switch i.LibC {
+	case "glibc":
+		i.LibCVersion = glibcVersion(i.Interpreter)
+	case "musl":
+		i.LibCVersion = muslVersion(i.Interpreter)
+	}
+
+	if buf, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		i.Kernel = strings.TrimSpace(string(buf))
+	}
+	for _, fn := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		if f, err := os.Open(fn); err == nil {
+			i.Distro = parseOSRelease(f)
+			f.Close()
+			break
+		}
+	}
+	if f, err := os.Open("/proc/cpuinfo"); err == nil {
+		i.CPU = parseCPUInfo(f)
+		f.Close()
+	}
+}
+
+// readInterpreter returns the PT_INTERP contents of an ELF executable
+func readInterpreter(fn string) string {
+	f, err := elf.Open(fn)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	for _, p := range f.Progs {
+		if p.Type != elf.PT_INTERP {
+			continue
+		}
+		buf := make([]byte, p.Filesz)
+		if _, err := p.ReadAt(buf, 0); err != nil {
+			return ""
+		}
+		return string(bytes.TrimRight(buf, "\x00"))
+	}
+	return ""
+}
+
+var (
+	reGLibCBanner = regexp.MustCompile(`GNU C Library [^\n]*?version (\d+\.\d+(?:\.\d+)?)`)
+	reMuslVersion = regexp.MustCompile(`(?m)^Version\s+(\S+)`)
+)
+
+// glibcVersion obtains the value gnu_get_libc_version() would return, first
+// from confstr via getconf, then from the banner embedded in libc.so.6
+func glibcVersion(interp string) string {
+	if out, err := exec.Command("getconf", "GNU_LIBC_VERSION").Output(); err == nil {
+		if ff := strings.Fields(string(out)); len(ff) == 2 && ff[0] == "glibc" {
+			return ff[1]
+		}
+	}
+	dirs := []string{}
+	if interp != "" {
+		if p, err := filepath.EvalSymlinks(interp); err == nil {
+			dirs = append(dirs, filepath.Dir(p))
+		}
+		dirs = append(dirs, filepath.Dir(interp))
+	}
+	dirs = append(dirs, "/lib64", "/lib", "/usr/lib")
+	for _, dir := range dirs {
+		buf, err := os.ReadFile(filepath.Join(dir, "libc.so.6"))
+		if err != nil {
+			continue
+		}
+		if m := reGLibCBanner.FindSubmatch(buf); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
+
+// muslVersion runs the musl dynamic loader which prints its version when
+// invoked without arguments
+func muslVersion(interp string) string {
+	if interp == "" {
+		return ""
+	}
+	out, _ := exec.Command(interp).CombinedOutput()
+	if m := reMuslVersion.FindSubmatch(out); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
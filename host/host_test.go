@@ -0,0 +1,120 @@
+package host
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+func TestParseOSRelease(t *testing.T) {
+	const input = `NAME="Alpine Linux"
+ID=alpine
+VERSION_ID=3.19.1
+PRETTY_NAME="Alpine Linux v3.19"
+HOME_URL="https://alpinelinux.org/"
+`
+	got := parseOSRelease(strings.NewReader(input))
+	want := &Distro{ID: "alpine", Version: "3.19.1", Name: "Alpine Linux v3.19"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseOSRelease()\ngot  = %#v\nwant = %#v", got, want)
+	}
+}
+
+func TestParseCPUInfo(t *testing.T) {
+	const input = `processor	: 0
+vendor_id	: GenuineIntel
+model name	: Intel(R) Core(TM) i7-8700 CPU @ 3.20GHz
+flags		: fpu sse sse2 avx avx2
+
+processor	: 1
+vendor_id	: GenuineIntel
+flags		: fpu
+`
+	got := parseCPUInfo(strings.NewReader(input))
+	want := CPU{
+		Vendor:   "GenuineIntel",
+		Model:    "Intel(R) Core(TM) i7-8700 CPU @ 3.20GHz",
+		Features: []string{"fpu", "sse", "sse2", "avx", "avx2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCPUInfo()\ngot  = %#v\nwant = %#v", got, want)
+	}
+}
+
+func TestCanRun(t *testing.T) {
+	alpine := &Info{OS: "linux", Arch: "x64", LibC: "musl"}
+	tests := []struct {
+		target   string
+		expected bool
+	}{
+		{"x86_64-alpine-linux-musl", true},
+		{"x86_64-linux-gnu", false},
+		{"x86_64-pc-linux", true},
+		{"aarch64-alpine-linux-musl", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			target, _, err := triplet.ParseTarget(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := alpine.CanRun(target); got != tt.expected {
+				t.Errorf("CanRun(%q) = %v, want %v", tt.target, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCanRun_LibCVersion(t *testing.T) {
+	debian := &Info{OS: "linux", Arch: "x64", LibC: "glibc", LibCVersion: "2.36"}
+	tests := []struct {
+		target   string
+		expected bool
+	}{
+		{"x86_64-linux-gnu", true},
+		{"x86_64-linux-gnu.2.17", true},
+		{"x86_64-linux-gnu.2.36", true},
+		{"x86_64-linux-gnu.2.38", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			target, _, err := triplet.ParseTarget(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := debian.CanRun(target); got != tt.expected {
+				t.Errorf("CanRun(%q) = %v, want %v", tt.target, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInfo_Target(t *testing.T) {
+	i := &Info{OS: "linux", Arch: "x64", LibC: "glibc", LibCVersion: "2.36"}
+	want := triplet.Target{OS: "linux", Arch: "x64", ObjectFormat: "elf", LibC: "glibc", LibCVersion: "2.36", Endian: "little", PointerWidth: 64}
+	if got := i.Target(); got != want {
+		t.Errorf("Target() = %#v, want %#v", got, want)
+	}
+	i = &Info{OS: "linux", Arch: "arm64", LibC: "musl"}
+	if got := i.Target(); got.Endian != "little" || got.PointerWidth != 64 || got.ObjectFormat != "elf" {
+		t.Errorf("Target() = %#v", got)
+	}
+}
+
+func TestLibCFromInterpreter(t *testing.T) {
+	tests := map[string]string{
+		"/lib/ld-musl-x86_64.so.1":    "musl",
+		"/lib64/ld-linux-x86-64.so.2": "glibc",
+		"/lib/ld-linux-aarch64.so.1":  "glibc",
+		"/lib64/ld64.so.2":            "glibc",
+		"/system/bin/linker64":        "bionic",
+		"/usr/libexec/ld.elf_so":      "",
+	}
+	for interp, want := range tests {
+		if got := libcFromInterpreter(interp); got != want {
+			t.Errorf("libcFromInterpreter(%q) = %q, want %q", interp, got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package inspect
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	arMagic     = "!<arch>\n"
+	arHeaderLen = 60
+)
+
+// inspectArchive inspects the members of a static library (ar archive, as
+// used by both unix and msvc .lib files) and returns the target of the first
+// object member. Symbol tables, long name tables and the short import
+// records of msvc import libraries are skipped.
+func inspectArchive(r io.ReaderAt, size int64) (*Info, error) {
+	off := int64(len(arMagic))
+	hdr := make([]byte, arHeaderLen)
+	for off+arHeaderLen <= size {
+		if _, err := r.ReadAt(hdr, off); err != nil {
+			return nil, err
+		}
+		name := strings.TrimSpace(string(hdr[0:16]))
+		n, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil || n < 0 {
+			return nil, ErrUnknownFormat
+		}
+		data, dataSize := off+arHeaderLen, n
+		if rest, ok := strings.CutPrefix(name, "#1/"); ok {
+			// BSD long names precede the member data
+			if l, err := strconv.ParseInt(rest, 10, 64); err == nil && l >= 0 && l <= n {
+				name = ""
+				data, dataSize = data+l, n-l
+			}
+		}
+		if !isArchiveIndex(name) {
+			if i, err := Reader(io.NewSectionReader(r, data, dataSize), dataSize); err == nil && i.Kind == Object {
+				i.Kind = Archive
+				return i, nil
+			}
+		}
+		off += arHeaderLen + n + n%2
+	}
+	return nil, ErrUnknownFormat
+}
+
+// isArchiveIndex checks for the members that hold the symbol table or the
+// long names rather than object files
+func isArchiveIndex(name string) bool {
+	switch name {
+	case "/", "//", "/SYM64/", "__.SYMDEF", "__.SYMDEF SORTED", "__.SYMDEF_64", "ARFILENAMES/":
+		return true
+	}
+	return false
+}
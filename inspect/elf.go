@@ -0,0 +1,290 @@
+package inspect
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+// ELF flags of arm executables (EF_ARM_ABI_FLOAT_SOFT, EF_ARM_ABI_FLOAT_HARD)
+const (
+	efARMFloatSoft = 0x200
+	efARMFloatHard = 0x400
+)
+
+// elfArch maps ELF machines to normalized architectures, the ones that vary
+// with the class or byte order are handled in inspectELF
+var elfArch = map[elf.Machine]string{
+	elf.EM_X86_64:    "x64",
+	elf.EM_386:       "x32",
+	elf.EM_ARM:       "arm",
+	elf.EM_AARCH64:   "arm64",
+	elf.EM_IA_64:     "ia64",
+	elf.EM_LOONGARCH: "loong64",
+	elf.EM_S390:      "s390x",
+	elf.EM_SPARCV9:   "sparc64",
+	elf.EM_SPARC:     "sparc",
+	elf.EM_68K:       "m68k",
+	elf.EM_AVR:       "avr",
+	elf.EM_MSP430:    "msp430",
+	elf.EM_XTENSA:    "xtensa",
+}
+
+// elfOSABI maps the EI_OSABI header byte to operating systems, most linux
+// binaries use ELFOSABI_NONE
+var elfOSABI = map[elf.OSABI]string{
+	elf.ELFOSABI_LINUX:   "linux",
+	elf.ELFOSABI_FREEBSD: "freebsd",
+	elf.ELFOSABI_NETBSD:  "netbsd",
+	elf.ELFOSABI_OPENBSD: "openbsd",
+	elf.ELFOSABI_SOLARIS: "solaris",
+	elf.ELFOSABI_AIX:     "aix",
+	elf.ELFOSABI_HPUX:    "hpux",
+}
+
+func inspectELF(r io.ReaderAt) (*Info, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	i := &Info{}
+	t := &i.Target
+	t.ObjectFormat = triplet.ELF
+	t.Endian = triplet.LittleEndian
+	if f.ByteOrder == binary.BigEndian {
+		t.Endian = triplet.BigEndian
+	}
+	t.PointerWidth = 32
+	if f.Class == elf.ELFCLASS64 {
+		t.PointerWidth = 64
+	}
+	t.Arch = elfArch[f.Machine]
+	switch f.Machine {
+	case elf.EM_X86_64:
+		if f.Class == elf.ELFCLASS32 {
+			t.Environment = "gnux32"
+		}
+	case elf.EM_AARCH64:
+		if f.Class == elf.ELFCLASS32 {
+			t.Environment = "gnuilp32"
+		}
+	case elf.EM_PPC64:
+		t.Arch = "ppc64"
+		if t.Endian == triplet.LittleEndian {
+			t.Arch = "ppc64le"
+		}
+	case elf.EM_PPC:
+		t.Arch = "powerpc"
+		if t.Endian == triplet.LittleEndian {
+			t.Arch = "powerpcle"
+		}
+	case elf.EM_MIPS:
+		t.Arch = "mips"
+		if f.Class == elf.ELFCLASS64 {
+			t.Arch = "mips64"
+		}
+		if t.Endian == triplet.LittleEndian {
+			t.Arch += "el"
+		}
+	case elf.EM_RISCV:
+		t.Arch = "riscv" + strconv.Itoa(t.PointerWidth)
+	case elf.EM_S390:
+		if f.Class == elf.ELFCLASS32 {
+			t.Arch = "s390"
+		}
+	case elf.EM_ARM:
+		flags := elfFlags(r, f)
+		switch {
+		case flags&efARMFloatHard != 0:
+			t.FloatABI = triplet.FloatHard
+		case flags&efARMFloatSoft != 0:
+			t.FloatABI = triplet.FloatSoft
+		}
+	}
+	if t.Arch == "" {
+		t.Arch = strings.ToLower(strings.TrimPrefix(f.Machine.String(), "EM_"))
+	}
+	t.OS = elfOSABI[f.OSABI]
+
+	for _, p := range f.Progs {
+		if p.Type == elf.PT_INTERP {
+			buf := make([]byte, p.Filesz)
+			if _, err := p.ReadAt(buf, 0); err == nil {
+				i.Interpreter = string(bytes.TrimRight(buf, "\x00"))
+			}
+		}
+	}
+	if i.Interpreter != "" {
+		os, libc := fromInterpreter(i.Interpreter)
+		if t.OS == "" {
+			t.OS = os
+		}
+		t.LibC = libc
+	}
+	i.Libraries, _ = f.ImportedLibraries()
+	readELFNotes(f, t)
+
+	switch f.Type {
+	case elf.ET_REL:
+		i.Kind = Object
+	case elf.ET_EXEC:
+		i.Kind = Executable
+		i.Static = i.Interpreter == "" && len(i.Libraries) == 0
+	case elf.ET_DYN:
+		// PIE executables are ET_DYN with an interpreter
+		i.Kind = SharedLibrary
+		if i.Interpreter != "" {
+			i.Kind = Executable
+		}
+	}
+
+	for _, lib := range i.Libraries {
+		switch {
+		case t.LibC != "" || t.OS != "" && t.OS != "linux":
+		case lib == "libc.so.6":
+			t.LibC = "glibc"
+		case strings.HasPrefix(lib, "libc.musl-"):
+			t.LibC = "musl"
+		}
+	}
+	if t.LibC == "glibc" {
+		t.LibCVersion = glibcVersion(f)
+	}
+	if t.OS == "" && (t.LibC == "glibc" || t.LibC == "musl") {
+		t.OS = "linux"
+	}
+	i.withEnvironment()
+	return i, nil
+}
+
+// elfFlags reads e_flags, which debug/elf does not expose
+func elfFlags(r io.ReaderAt, f *elf.File) uint32 {
+	off := int64(36) // ELFCLASS32
+	if f.Class == elf.ELFCLASS64 {
+		off = 48
+	}
+	buf := make([]byte, 4)
+	if _, err := r.ReadAt(buf, off); err != nil {
+		return 0
+	}
+	return f.ByteOrder.Uint32(buf)
+}
+
+// fromInterpreter derives the operating system and the C library from the
+// path of the ELF program interpreter
+func fromInterpreter(interp string) (os, libc string) {
+	base := interp[strings.LastIndexByte(interp, '/')+1:]
+	switch {
+	case strings.HasPrefix(base, "ld-musl"):
+		return "linux", "musl"
+	case strings.HasPrefix(base, "ld-uClibc"):
+		return "linux", "uclibc"
+	case strings.HasPrefix(base, "linker"):
+		return "android", "bionic"
+	case strings.HasPrefix(base, "ld-elf"):
+		return "freebsd", ""
+	case base == "ld.elf_so":
+		return "netbsd", ""
+	case interp == "/usr/libexec/ld.so":
+		return "openbsd", ""
+	case strings.HasPrefix(interp, "/usr/lib/") && base == "ld.so.1":
+		return "solaris", ""
+	case strings.HasPrefix(base, "ld-linux"), strings.HasPrefix(base, "ld64.so"), strings.HasPrefix(base, "ld.so"):
+		return "linux", "glibc"
+	}
+	return "", ""
+}
+
+// glibcVersion returns the newest GLIBC_x.y symbol version that the binary
+// requires, that is the minimum glibc version it runs with
+func glibcVersion(f *elf.File) string {
+	syms, err := f.ImportedSymbols()
+	if err != nil {
+		return ""
+	}
+	ret := ""
+	for _, s := range syms {
+		v, ok := strings.CutPrefix(s.Version, "GLIBC_")
+		if ok && v != "PRIVATE" && triplet.CompareVersions(v, ret) > 0 {
+			ret = v
+		}
+	}
+	return ret
+}
+
+// readELFNotes finds the OS identification notes: the GNU ABI tag with the
+// minimum kernel version, the Android API level, and the BSD version notes
+func readELFNotes(f *elf.File, t *triplet.Target) {
+	for _, s := range f.Sections {
+		if s.Type != elf.SHT_NOTE {
+			continue
+		}
+		data, err := s.Data()
+		if err != nil {
+			continue
+		}
+		for len(data) >= 12 {
+			namesz := f.ByteOrder.Uint32(data[0:])
+			descsz := f.ByteOrder.Uint32(data[4:])
+			typ := f.ByteOrder.Uint32(data[8:])
+			nameEnd := 12 + align4(namesz)
+			descEnd := nameEnd + align4(descsz)
+			if descEnd > uint64(len(data)) {
+				break
+			}
+			name := string(bytes.TrimRight(data[12:12+namesz], "\x00"))
+			desc := data[nameEnd : nameEnd+uint64(descsz)]
+			data = data[descEnd:]
+			if typ != 1 || len(desc) < 4 {
+				continue
+			}
+			v := f.ByteOrder.Uint32(desc)
+			switch name {
+			case "GNU":
+				// NT_GNU_ABI_TAG: os, major, minor, patch
+				if v == 0 && len(desc) >= 16 {
+					if t.OS == "" {
+						t.OS = "linux"
+					}
+					if t.LibC == "" {
+						t.LibC = "glibc"
+					}
+					t.OSVersion = joinVersion(f.ByteOrder.Uint32(desc[4:]), f.ByteOrder.Uint32(desc[8:]), f.ByteOrder.Uint32(desc[12:]))
+				}
+			case "Android":
+				t.OS, t.LibC = "android", "bionic"
+				t.OSVersion = strconv.Itoa(int(v))
+			case "FreeBSD":
+				// __FreeBSD_version: 1400097 is 14.0
+				t.OS = "freebsd"
+				t.OSVersion = joinVersion(v/100000, v/1000%100, 0)
+			case "NetBSD":
+				// __NetBSD_Version__: 1000000000 is 10.0
+				t.OS = "netbsd"
+				t.OSVersion = joinVersion(v/100000000, v/1000000%100, 0)
+			case "OpenBSD":
+				t.OS = "openbsd"
+			}
+		}
+	}
+}
+
+func align4(n uint32) uint64 {
+	return (uint64(n) + 3) &^ 3
+}
+
+// joinVersion formats a version, the patch level is omitted when zero
+func joinVersion(major, minor, patch uint32) string {
+	s := strconv.Itoa(int(major)) + "." + strconv.Itoa(int(minor))
+	if patch != 0 {
+		s += "." + strconv.Itoa(int(patch))
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+// Package inspect reads the headers of ELF, PE/COFF, Mach-O and WebAssembly
+// binaries and static archives to find out the target they were built for,
+// without running them.
+package inspect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+// ErrUnknownFormat is returned for files that are not recognized as binaries
+var ErrUnknownFormat = errors.New("unknown binary format")
+
+// Kind is the kind of a binary artifact
+type Kind string
+
+const (
+	Executable    Kind = "executable"
+	SharedLibrary Kind = "shared-library"
+	Object        Kind = "object"
+	Archive       Kind = "archive"
+)
+
+// Info describes a binary artifact. Target fields that can not be derived
+// from the headers are left empty.
+type Info struct {
+	Kind        Kind           `json:"kind" yaml:"kind"`
+	Target      triplet.Target `json:"target" yaml:"target"`
+	Interpreter string         `json:"interpreter,omitempty" yaml:"interpreter,omitempty"` // ELF program interpreter
+	Static      bool           `json:"static,omitempty" yaml:"static,omitempty"`           // executable without dynamic dependencies
+	Subsystem   string         `json:"subsystem,omitempty" yaml:"subsystem,omitempty"`     // PE subsystem: console, windows, efi-application, ...
+	SDKVersion  string         `json:"sdk-version,omitempty" yaml:"sdk-version,omitempty"` // Mach-O SDK version
+	Libraries   []string       `json:"libraries,omitempty" yaml:"libraries,omitempty"`     // shared libraries the artifact depends on
+	Slices      []*Info        `json:"slices,omitempty" yaml:"slices,omitempty"`           // architectures of a universal Mach-O binary
+}
+
+// File inspects the binary file
+func File(fn string) (*Info, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	info, err := Reader(f, st.Size())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	return info, nil
+}
+
+// Reader inspects the binary read from r
+func Reader(r io.ReaderAt, size int64) (*Info, error) {
+	magic := make([]byte, 8)
+	n, _ := r.ReadAt(magic, 0)
+	magic = magic[:n]
+	switch {
+	case bytes.HasPrefix(magic, []byte("\x7fELF")):
+		return inspectELF(r)
+	case bytes.HasPrefix(magic, []byte("MZ")):
+		return inspectPE(r)
+	case bytes.HasPrefix(magic, []byte("\x00asm")):
+		return inspectWasm(r, size)
+	case bytes.HasPrefix(magic, []byte("!<arch>\n")):
+		return inspectArchive(r, size)
+	case isMachO(magic):
+		return inspectMachO(r)
+	case isCOFF(magic):
+		return inspectPE(r)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// Matches checks if the artifact was built for the target. Versions are not
+// compared, they describe the minimum requirements of the artifact rather
+// than the target itself, and unknown target fields match anything. A
+// universal binary matches when any of its slices does.
+func (i *Info) Matches(t triplet.Target) bool {
+	if len(i.Slices) > 0 {
+		for _, s := range i.Slices {
+			if s.Matches(t) {
+				return true
+			}
+		}
+		return false
+	}
+	t.OSVersion, t.LibCVersion = "", ""
+	for _, f := range []*string{&t.OS, &t.Arch, &t.ObjectFormat, &t.Environment, &t.LibC} {
+		if *f == "unknown" {
+			*f = ""
+		}
+	}
+	return i.Target.Match(t)
+}
+
+// withEnvironment sets the environment that GNU triples spell for the C
+// library, with the arm float ABI suffix or the x32 and ilp32 ABI suffix
+// detected from the ELF class
+func (i *Info) withEnvironment() {
+	t := &i.Target
+	env := ""
+	switch t.LibC {
+	case "glibc":
+		env = "gnu"
+	case "musl":
+		env = "musl"
+	case "bionic":
+		env = "android"
+		if t.Arch == "arm" {
+			t.Environment = "androideabi"
+			return
+		}
+	default:
+		return
+	}
+	switch t.Environment {
+	case "gnux32", "muslx32":
+		env += "x32"
+	case "gnuilp32":
+		env += "ilp32"
+	}
+	if t.Arch == "arm" {
+		switch t.FloatABI {
+		case triplet.FloatHard:
+			env += "eabihf"
+		case triplet.FloatSoft, triplet.FloatSoftFP:
+			env += "eabi"
+		default:
+			return
+		}
+	}
+	t.Environment = env
+}
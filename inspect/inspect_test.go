@@ -0,0 +1,229 @@
+package inspect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+func TestFile_Self(t *testing.T) {
+	fn, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	i, err := File(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, err := triplet.FromGo(runtime.GOOS, runtime.GOARCH, "", "")
+	if err != nil {
+		t.Skip(err)
+	}
+	if i.Kind != Executable || !i.Matches(host) {
+		t.Errorf("File(%s) = %s %s, want a %s executable", fn, i.Kind, i.Target.Text(), host.Text())
+	}
+}
+
+// TestFile_GoCross inspects binaries that the go toolchain cross compiles
+func TestFile_GoCross(t *testing.T) {
+	if testing.Short() {
+		t.Skip("cross compiles with the go tool")
+	}
+	gotool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip(err)
+	}
+	dir := t.TempDir()
+	for fn, src := range map[string]string{
+		"main.go": "package main\n\nfunc main() { println(\"hello\") }\n",
+		"go.mod":  "module hello\n\ngo 1.21\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, fn), []byte(src), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		goos, goarch string
+		text         string
+		kind         Kind
+	}{
+		{"linux", "arm64", "arm64-*-elf", Executable},
+		{"linux", "mips64", "mips64-*-elf+endian=big", Executable},
+		{"android", "arm64", "arm64-android-elf-bionic-android", Executable},
+		{"freebsd", "amd64", "x64-freebsd", Executable},
+		{"windows", "amd64", "x64-windows", Executable},
+		{"windows", "arm64", "arm64-windows", Executable},
+		{"darwin", "arm64", "arm64-darwin", Executable},
+		{"wasip1", "wasm", "wasm32-wasi.0.1-wasm", Executable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.goarch, func(t *testing.T) {
+			out := filepath.Join(dir, tt.goos+"-"+tt.goarch)
+			cmd := exec.Command(gotool, "build", "-o", out, ".")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GOOS="+tt.goos, "GOARCH="+tt.goarch, "CGO_ENABLED=0")
+			if b, err := cmd.CombinedOutput(); err != nil {
+				t.Skipf("go build: %v\n%s", err, b)
+			}
+			i, err := File(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := triplet.MustParsePattern(tt.text)
+			if !want.Match(i.Target) || !strings.HasPrefix(i.Target.Text(), strings.Split(tt.text, "+")[0]) || i.Kind != tt.kind {
+				t.Errorf("File() = %s %s, want %s %s", i.Kind, i.Target.Text(), tt.kind, tt.text)
+			}
+			g, _ := triplet.FromGo(tt.goos, tt.goarch, "", "")
+			if !i.Matches(g) {
+				t.Errorf("%s does not match %s", i.Target.Text(), g.Text())
+			}
+			other, _ := triplet.FromGo("linux", "riscv64", "", "")
+			if i.Matches(other) {
+				t.Errorf("%s matches %s", i.Target.Text(), other.Text())
+			}
+		})
+	}
+}
+
+func TestFromInterpreter(t *testing.T) {
+	tests := []struct{ interp, os, libc string }{
+		{"/lib64/ld-linux-x86-64.so.2", "linux", "glibc"},
+		{"/lib/ld-linux-aarch64.so.1", "linux", "glibc"},
+		{"/lib/ld-linux-armhf.so.3", "linux", "glibc"},
+		{"/lib64/ld64.so.2", "linux", "glibc"},
+		{"/lib/ld-musl-aarch64.so.1", "linux", "musl"},
+		{"/system/bin/linker64", "android", "bionic"},
+		{"/libexec/ld-elf.so.1", "freebsd", ""},
+		{"/usr/libexec/ld.elf_so", "netbsd", ""},
+		{"/usr/libexec/ld.so", "openbsd", ""},
+		{"/usr/lib/amd64/ld.so.1", "solaris", ""},
+		{"/opt/custom/loader", "", ""},
+	}
+	for _, tt := range tests {
+		if os, libc := fromInterpreter(tt.interp); os != tt.os || libc != tt.libc {
+			t.Errorf("fromInterpreter(%q) = %s, %s, want %s, %s", tt.interp, os, libc, tt.os, tt.libc)
+		}
+	}
+}
+
+func TestInfo_withEnvironment(t *testing.T) {
+	tests := []struct {
+		target triplet.Target
+		want   string
+	}{
+		{triplet.Target{Arch: "x64", LibC: "glibc"}, "gnu"},
+		{triplet.Target{Arch: "x64", LibC: "glibc", Environment: "gnux32"}, "gnux32"},
+		{triplet.Target{Arch: "x64", LibC: "musl", Environment: "gnux32"}, "muslx32"},
+		{triplet.Target{Arch: "arm64", LibC: "glibc", Environment: "gnuilp32"}, "gnuilp32"},
+		{triplet.Target{Arch: "arm", LibC: "glibc", FloatABI: triplet.FloatHard}, "gnueabihf"},
+		{triplet.Target{Arch: "arm", LibC: "bionic"}, "androideabi"},
+		{triplet.Target{Arch: "x64", Environment: "gnux32"}, "gnux32"},
+	}
+	for _, tt := range tests {
+		i := &Info{Target: tt.target}
+		i.withEnvironment()
+		if i.Target.Environment != tt.want {
+			t.Errorf("withEnvironment(%s) = %q, want %q", tt.target.Text(), i.Target.Environment, tt.want)
+		}
+	}
+}
+
+// wasmModule assembles a module from the raw section contents
+func wasmModule(version uint32, sections ...[]byte) []byte {
+	b := []byte{0, 'a', 's', 'm', byte(version), byte(version >> 8), byte(version >> 16), byte(version >> 24)}
+	for _, s := range sections {
+		b = append(b, s...)
+	}
+	return b
+}
+
+func wasmSection(id byte, content ...[]byte) []byte {
+	c := bytes.Join(content, nil)
+	return append([]byte{id, byte(len(c))}, c...)
+}
+
+func wasmName(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+func TestReader_Wasm(t *testing.T) {
+	funcImport := func(module, field string) []byte {
+		return bytes.Join([][]byte{wasmName(module), wasmName(field), {0, 0}}, nil)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		text string
+		kind Kind
+	}{
+		{"wasi", wasmModule(1,
+			wasmSection(wasmImport, []byte{1}, funcImport("wasi_snapshot_preview1", "fd_write")),
+			wasmSection(wasmExport, []byte{1}, wasmName("_start"), []byte{0, 0}),
+		), "wasm32-wasi.0.1-wasm", Executable},
+		{"wasi-threads", wasmModule(1,
+			wasmSection(wasmImport, []byte{2}, funcImport("wasi_snapshot_preview1", "fd_write"), funcImport("wasi", "thread-spawn")),
+		), "wasm32-wasi.0.1-wasm-*-threads", SharedLibrary},
+		{"emscripten", wasmModule(1,
+			wasmSection(wasmImport, []byte{1}, funcImport("env", "emscripten_memcpy_js")),
+		), "wasm32-emscripten-wasm", SharedLibrary},
+		{"memory64", wasmModule(1,
+			wasmSection(wasmMemory, []byte{1, 4, 1}),
+		), "wasm64-*-wasm", SharedLibrary},
+		{"object", wasmModule(1,
+			wasmSection(wasmCustom, wasmName("linking"), []byte{2}),
+		), "wasm32-*-wasm", Object},
+		{"component", wasmModule(0x0001000d), "wasm32-wasi.0.2-wasm", Executable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := Reader(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := i.Target.Text(); got != tt.text || i.Kind != tt.kind {
+				t.Errorf("Reader() = %s %s, want %s %s", i.Kind, got, tt.kind, tt.text)
+			}
+		})
+	}
+}
+
+func TestReader_Archive(t *testing.T) {
+	obj := wasmModule(1, wasmSection(wasmCustom, wasmName("linking"), []byte{2}))
+	member := func(name string, data []byte) []byte {
+		h := fmt.Sprintf("%-16s%-12d%-6d%-6d%-8s%-10d`\n", name, 0, 0, 0, "644", len(data))
+		b := append([]byte(h), data...)
+		if len(data)%2 != 0 {
+			b = append(b, '\n')
+		}
+		return b
+	}
+	ar := bytes.Join([][]byte{[]byte(arMagic),
+		member("/", []byte{0, 0, 0, 0}),
+		member("#1/8", append([]byte("long.o\x00\x00"), obj...)),
+	}, nil)
+	i, err := Reader(bytes.NewReader(ar), int64(len(ar)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Kind != Archive || i.Target.Arch != "wasm32" {
+		t.Errorf("Reader() = %s %s, want a wasm32 archive", i.Kind, i.Target.Text())
+	}
+
+	// a negative member size would move back to the same header
+	bad := []byte(arMagic + fmt.Sprintf("%-16s%-12d%-6d%-6d%-8s%-10d`\n", "a.o", 0, 0, 0, "644", -60))
+	if _, err := Reader(bytes.NewReader(bad), int64(len(bad))); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Reader(negative size) error = %v, want ErrUnknownFormat", err)
+	}
+
+	if _, err := Reader(strings.NewReader("#!/bin/sh\n"), 10); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Reader(script) error = %v, want ErrUnknownFormat", err)
+	}
+}
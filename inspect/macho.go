@@ -0,0 +1,168 @@
+package inspect
+
+import (
+	"debug/macho"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+// Mach-O load commands that carry the minimum OS version
+const (
+	lcVersionMinMacOSX   = 0x24
+	lcVersionMinIPhoneOS = 0x25
+	lcVersionMinTVOS     = 0x2f
+	lcVersionMinWatchOS  = 0x30
+	lcBuildVersion       = 0x32
+)
+
+// machoArch maps Mach-O cpu types to normalized architectures
+var machoArch = map[macho.Cpu]string{
+	macho.CpuAmd64: "x64",
+	macho.Cpu386:   "x32",
+	macho.CpuArm64: "arm64",
+	macho.CpuArm:   "arm",
+	macho.CpuPpc64: "ppc64",
+	macho.CpuPpc:   "powerpc",
+}
+
+// machoPlatforms maps the LC_BUILD_VERSION platforms to the operating system
+// and environment, as spelled in LLVM triples
+var machoPlatforms = map[uint32][2]string{
+	1:  {"darwin", ""},
+	2:  {"ios", ""},
+	3:  {"tvos", ""},
+	4:  {"watchos", ""},
+	5:  {"bridgeos", ""},
+	6:  {"ios", "macabi"},
+	7:  {"ios", "simulator"},
+	8:  {"tvos", "simulator"},
+	9:  {"watchos", "simulator"},
+	10: {"driverkit", ""},
+	11: {"xros", ""},
+	12: {"xros", "simulator"},
+}
+
+func isMachO(magic []byte) bool {
+	if len(magic) < 4 {
+		return false
+	}
+	switch binary.BigEndian.Uint32(magic) {
+	case macho.Magic32, macho.Magic64, macho.MagicFat:
+		return true
+	}
+	switch binary.LittleEndian.Uint32(magic) {
+	case macho.Magic32, macho.Magic64:
+		return true
+	}
+	return false
+}
+
+func inspectMachO(r io.ReaderAt) (*Info, error) {
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, err
+	}
+	if binary.BigEndian.Uint32(magic) != macho.MagicFat {
+		f, err := macho.NewFile(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return machoInfo(f), nil
+	}
+
+	ff, err := macho.NewFatFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer ff.Close()
+	i := &Info{}
+	for _, a := range ff.Arches {
+		i.Slices = append(i.Slices, machoInfo(a.File))
+	}
+	if len(i.Slices) > 0 {
+		// the common fields are taken from the first slice, the
+		// architecture is not
+		first := *i.Slices[0]
+		i.Kind, i.Target, i.SDKVersion, i.Libraries = first.Kind, first.Target, first.SDKVersion, first.Libraries
+		i.Target.Arch, i.Target.SubArch, i.Target.PointerWidth = "", "", 0
+	}
+	return i, nil
+}
+
+func machoInfo(f *macho.File) *Info {
+	i := &Info{}
+	t := &i.Target
+	t.ObjectFormat = triplet.MachO
+	t.Arch = machoArch[f.Cpu]
+	if t.Arch == "" {
+		t.Arch = "0x" + strconv.FormatUint(uint64(f.Cpu), 16)
+	}
+	if t.Arch == "arm64" && f.SubCpu&0xff == 2 {
+		// CPU_SUBTYPE_ARM64E
+		t.SubArch = "e"
+	}
+	t.Endian = triplet.LittleEndian
+	if f.ByteOrder == binary.BigEndian {
+		t.Endian = triplet.BigEndian
+	}
+	t.PointerWidth = 32
+	if f.Magic == macho.Magic64 {
+		t.PointerWidth = 64
+	}
+
+	switch f.Type {
+	case macho.TypeExec:
+		i.Kind = Executable
+	case macho.TypeDylib, macho.TypeBundle:
+		i.Kind = SharedLibrary
+	case macho.TypeObj:
+		i.Kind = Object
+	}
+	i.Libraries, _ = f.ImportedLibraries()
+
+	for _, l := range f.Loads {
+		raw := l.Raw()
+		if len(raw) < 16 {
+			continue
+		}
+		switch cmd := f.ByteOrder.Uint32(raw); cmd {
+		case lcBuildVersion:
+			// platform, minos, sdk
+			p := machoPlatforms[f.ByteOrder.Uint32(raw[8:])]
+			t.OS, t.Environment = p[0], p[1]
+			t.OSVersion = machoVersion(f.ByteOrder.Uint32(raw[12:]))
+			if len(raw) >= 20 {
+				i.SDKVersion = machoVersion(f.ByteOrder.Uint32(raw[16:]))
+			}
+		case lcVersionMinMacOSX, lcVersionMinIPhoneOS, lcVersionMinTVOS, lcVersionMinWatchOS:
+			// version, sdk
+			t.OS = map[uint32]string{
+				lcVersionMinMacOSX:   "darwin",
+				lcVersionMinIPhoneOS: "ios",
+				lcVersionMinTVOS:     "tvos",
+				lcVersionMinWatchOS:  "watchos",
+			}[cmd]
+			t.OSVersion = machoVersion(f.ByteOrder.Uint32(raw[8:]))
+			i.SDKVersion = machoVersion(f.ByteOrder.Uint32(raw[12:]))
+		}
+	}
+	if t.OS == "" && i.Kind != Object {
+		for _, lib := range i.Libraries {
+			if strings.HasPrefix(lib, "/usr/lib/libSystem") {
+				t.OS = "darwin"
+			}
+		}
+	}
+	return i
+}
+
+// machoVersion decodes the xxxx.yy.zz nibble encoded versions, the patch
+// level is omitted when zero
+func machoVersion(v uint32) string {
+	return joinVersion(v>>16, v>>8&0xff, v&0xff)
+}
@@ -0,0 +1,156 @@
+package inspect
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+// peArch maps PE/COFF machines to normalized architectures
+var peArch = map[uint16]string{
+	pe.IMAGE_FILE_MACHINE_AMD64: "x64",
+	pe.IMAGE_FILE_MACHINE_I386:  "x32",
+	pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+	pe.IMAGE_FILE_MACHINE_ARMNT: "arm",
+	pe.IMAGE_FILE_MACHINE_ARM:   "arm",
+}
+
+// peSubsystems names the PE subsystems
+var peSubsystems = map[uint16]string{
+	pe.IMAGE_SUBSYSTEM_NATIVE:                   "native",
+	pe.IMAGE_SUBSYSTEM_WINDOWS_GUI:              "windows",
+	pe.IMAGE_SUBSYSTEM_WINDOWS_CUI:              "console",
+	pe.IMAGE_SUBSYSTEM_POSIX_CUI:                "posix",
+	pe.IMAGE_SUBSYSTEM_WINDOWS_CE_GUI:           "windows-ce",
+	pe.IMAGE_SUBSYSTEM_EFI_APPLICATION:          "efi-application",
+	pe.IMAGE_SUBSYSTEM_EFI_BOOT_SERVICE_DRIVER:  "efi-boot-service-driver",
+	pe.IMAGE_SUBSYSTEM_EFI_RUNTIME_DRIVER:       "efi-runtime-driver",
+	pe.IMAGE_SUBSYSTEM_EFI_ROM:                  "efi-rom",
+	pe.IMAGE_SUBSYSTEM_XBOX:                     "xbox",
+	pe.IMAGE_SUBSYSTEM_WINDOWS_BOOT_APPLICATION: "windows-boot-application",
+}
+
+// isCOFF checks for the machine field of a COFF object file, which has no
+// magic of its own
+func isCOFF(magic []byte) bool {
+	if len(magic) < 2 {
+		return false
+	}
+	_, ok := peArch[binary.LittleEndian.Uint16(magic)]
+	return ok
+}
+
+func inspectPE(r io.ReaderAt) (*Info, error) {
+	f, err := pe.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	i := &Info{Kind: Object}
+	t := &i.Target
+	t.OS = "windows"
+	t.ObjectFormat = triplet.PE
+	t.Arch = peArch[f.Machine]
+	if t.Arch == "" {
+		t.Arch = "0x" + strconv.FormatUint(uint64(f.Machine), 16)
+	}
+	t.Endian = triplet.LittleEndian
+	t.PointerWidth = 32
+	if t.Arch == "x64" || t.Arch == "arm64" {
+		t.PointerWidth = 64
+	}
+	if t.Arch == "arm" {
+		t.SubArch = "v7"
+	}
+
+	var subsystem, major, minor uint16
+	switch h := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		subsystem, major, minor = h.Subsystem, h.MajorSubsystemVersion, h.MinorSubsystemVersion
+	case *pe.OptionalHeader64:
+		subsystem, major, minor = h.Subsystem, h.MajorSubsystemVersion, h.MinorSubsystemVersion
+	default:
+		// COFF object
+		return i, nil
+	}
+	i.Kind = Executable
+	if f.Characteristics&pe.IMAGE_FILE_DLL != 0 {
+		i.Kind = SharedLibrary
+	}
+	i.Subsystem = peSubsystems[subsystem]
+	if strings.HasPrefix(i.Subsystem, "efi-") {
+		t.OS = "uefi"
+	} else {
+		t.OSVersion = strconv.Itoa(int(major)) + "." + strconv.Itoa(int(minor))
+	}
+
+	i.Libraries = peImports(f)
+	gnu, msvc := false, hasRichHeader(r)
+	for _, lib := range i.Libraries {
+		lib = strings.ToLower(lib)
+		switch {
+		case lib == "cygwin1.dll":
+			t.OS = "cygwin"
+		case lib == "msys-2.0.dll":
+			t.OS = "msys"
+		case strings.HasPrefix(lib, "vcruntime"), strings.HasPrefix(lib, "msvcp"):
+			msvc = true
+		case lib == "msvcrt.dll", strings.HasPrefix(lib, "libgcc_s"), strings.HasPrefix(lib, "libstdc++"),
+			strings.HasPrefix(lib, "libwinpthread"), strings.HasPrefix(lib, "libc++"):
+			gnu = true
+		}
+	}
+	i.Static = len(i.Libraries) == 0
+	switch {
+	case t.OS == "cygwin" || t.OS == "msys":
+		t.Environment, t.LibC = "cygnus", "glibc"
+	case msvc:
+		t.Environment, t.LibC = "msvc", "msvcrt"
+	case gnu:
+		t.Environment, t.LibC = "gnu", "mingw"
+	}
+	return i, nil
+}
+
+// peImports lists the imported DLLs. debug/pe does not implement
+// ImportedLibraries, the DLL names come with the symbols as name:dll.
+func peImports(f *pe.File) []string {
+	syms, err := f.ImportedSymbols()
+	if err != nil {
+		return nil
+	}
+	ret := []string{}
+	seen := map[string]bool{}
+	for _, s := range syms {
+		_, dll, ok := strings.Cut(s, ":")
+		if ok && !seen[strings.ToLower(dll)] {
+			seen[strings.ToLower(dll)] = true
+			ret = append(ret, dll)
+		}
+	}
+	return ret
+}
+
+// hasRichHeader checks for the "Rich" signature that the Microsoft linker
+// leaves between the DOS stub and the PE header
+func hasRichHeader(r io.ReaderAt) bool {
+	buf := make([]byte, 0x40)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return false
+	}
+	lfanew := int64(binary.LittleEndian.Uint32(buf[0x3c:]))
+	if lfanew <= 0x40 || lfanew > 0x1000 {
+		return false
+	}
+	stub := make([]byte, lfanew-0x40)
+	if _, err := r.ReadAt(stub, 0x40); err != nil {
+		return false
+	}
+	return bytes.Contains(stub, []byte("Rich"))
+}
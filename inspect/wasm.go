@@ -0,0 +1,224 @@
+package inspect
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+)
+
+// WebAssembly section ids
+const (
+	wasmCustom = 0
+	wasmImport = 2
+	wasmMemory = 5
+	wasmExport = 7
+)
+
+var errWasmMalformed = errors.New("malformed wasm module")
+
+// inspectWasm reads the module header and the import, memory and export
+// sections. Components (the binary format of WASI preview 2) are recognized
+// by the layer in the version field.
+func inspectWasm(r io.ReaderAt, size int64) (*Info, error) {
+	i := &Info{}
+	t := &i.Target
+	t.Arch, t.ObjectFormat = "wasm32", triplet.Wasm
+	t.Endian, t.PointerWidth = triplet.LittleEndian, 32
+
+	br := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	hdr := make([]byte, 8)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, err
+	}
+	switch binary.LittleEndian.Uint32(hdr[4:]) {
+	case 1:
+	case 0x0001000d:
+		// component, layer 1
+		t.OS, t.OSVersion = "wasi", "0.2"
+		i.Kind = Executable
+		return i, nil
+	default:
+		return nil, errWasmMalformed
+	}
+
+	emscripten, wasi, threads, start, linking := false, false, false, false, false
+	for {
+		id, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		n, err := readULEB(br)
+		if err != nil {
+			return nil, err
+		}
+		sec := io.LimitReader(br, int64(n))
+		s := bufio.NewReader(sec)
+		switch id {
+		case wasmCustom:
+			if name, err := readName(s); err == nil && strings.HasPrefix(name, "linking") {
+				linking = true
+			}
+		case wasmImport:
+			count, err := readULEB(s)
+			if err != nil {
+				return nil, err
+			}
+			for ; count > 0; count-- {
+				module, err1 := readName(s)
+				field, err2 := readName(s)
+				if err := errors.Join(err1, err2); err != nil {
+					return nil, err
+				}
+				memory64, err := skipImportDesc(s)
+				if err != nil {
+					return nil, err
+				}
+				if memory64 {
+					t.Arch, t.PointerWidth = "wasm64", 64
+				}
+				switch {
+				case module == "wasi_snapshot_preview1" || module == "wasi_unstable":
+					wasi = true
+				case module == "wasi" && field == "thread-spawn":
+					threads = true
+				case module == "env" && strings.HasPrefix(field, "emscripten_"):
+					emscripten = true
+				}
+			}
+		case wasmMemory:
+			count, err := readULEB(s)
+			if err != nil {
+				return nil, err
+			}
+			for ; count > 0; count-- {
+				memory64, err := readLimits(s)
+				if err != nil {
+					return nil, err
+				}
+				if memory64 {
+					t.Arch, t.PointerWidth = "wasm64", 64
+				}
+			}
+		case wasmExport:
+			count, err := readULEB(s)
+			if err != nil {
+				return nil, err
+			}
+			for ; count > 0; count-- {
+				name, err := readName(s)
+				if err != nil {
+					return nil, err
+				}
+				if _, err := s.ReadByte(); err != nil {
+					return nil, err
+				}
+				if _, err := readULEB(s); err != nil {
+					return nil, err
+				}
+				start = start || name == "_start"
+			}
+		}
+		// skip the rest of the section
+		if _, err := io.Copy(io.Discard, s); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case emscripten:
+		t.OS = "emscripten"
+	case wasi || threads:
+		t.OS, t.OSVersion = "wasi", "0.1"
+		if threads {
+			t.Environment = "threads"
+		}
+	}
+	switch {
+	case linking:
+		i.Kind = Object
+	case start:
+		i.Kind = Executable
+	default:
+		i.Kind = SharedLibrary
+	}
+	return i, nil
+}
+
+func readULEB(r io.ByteReader) (uint64, error) {
+	var v uint64
+	for shift := 0; shift < 64; shift += 7 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, errWasmMalformed
+}
+
+func readName(r *bufio.Reader) (string, error) {
+	n, err := readULEB(r)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// readLimits reads the limits of a memory or a table, the result tells if
+// the memory uses 64-bit addressing
+func readLimits(r *bufio.Reader) (bool, error) {
+	flags, err := r.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	if _, err := readULEB(r); err != nil {
+		return false, err
+	}
+	if flags&1 != 0 {
+		if _, err := readULEB(r); err != nil {
+			return false, err
+		}
+	}
+	return flags&4 != 0, nil
+}
+
+// skipImportDesc skips the description of an import, the result tells if
+// it is a 64-bit memory
+func skipImportDesc(r *bufio.Reader) (bool, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	switch kind {
+	case 0: // function: type index
+		_, err = readULEB(r)
+	case 1: // table: reference type, limits
+		if _, err = r.ReadByte(); err == nil {
+			_, err = readLimits(r)
+		}
+	case 2: // memory: limits
+		return readLimits(r)
+	case 3: // global: value type, mutability
+		_, err = r.Discard(2)
+	case 4: // tag: attribute, type index
+		if _, err = r.ReadByte(); err == nil {
+			_, err = readULEB(r)
+		}
+	default:
+		err = errWasmMalformed
+	}
+	return false, err
+}
@@ -0,0 +1,44 @@
+// Package testutil provides the fixtures shared by the package tests.
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// Scripts writes executable shell scripts named by the map keys into a
+// temporary directory and returns the directory. The test is skipped on
+// windows.
+func Scripts(t testing.TB, scripts map[string]string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tools are shell scripts")
+	}
+	dir := t.TempDir()
+	for n, s := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, n), []byte(s), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// CTest returns a fake ctest script that saves its arguments to ctest.args
+// next to itself, writes the JUnit report to the --output-junit file, and
+// exits with the specified code
+func CTest(junit string, code int) string {
+	return fmt.Sprintf(`#!/bin/sh
+echo "$@" > "$(dirname "$0")/ctest.args"
+while [ $# -gt 0 ]; do
+  [ "$1" = "--output-junit" ] && out=$2
+  shift
+done
+cat > "$out" <<'XML'
+%s
+XML
+exit %d
+`, junit, code)
+}
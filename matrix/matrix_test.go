@@ -0,0 +1,177 @@
+package matrix
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/adnsv/go-build/host"
+	"github.com/adnsv/go-build/internal/testutil"
+)
+
+func testChains() []*toolchain.Chain {
+	native := triplet.Full{Target: host.Current().Target()}
+	return []*toolchain.Chain{
+		{Name: "gcc-12", Compiler: "gcc", Version: "12.2.0", Target: native},
+		{Compiler: "clang", Version: "17.0.6", Target: native},
+	}
+}
+
+func TestLoadSpec(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "matrix.yaml")
+	os.WriteFile(fn, []byte(`source: src
+build-root: out
+toolchains:
+  - compiler: gcc
+build-types: [Debug, release]
+options:
+  - name: asan
+    defines: {SANITIZE: address}
+test: true
+test-timeout: 30s
+`), 0666)
+	s, err := LoadSpec(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Source != filepath.Join(dir, "src") || s.BuildRoot != filepath.Join(dir, "out") {
+		t.Errorf("paths = %s, %s", s.Source, s.BuildRoot)
+	}
+	if s.TestTimeout != 30*time.Second || !s.Test {
+		t.Errorf("test settings = %v, %v", s.Test, s.TestTimeout)
+	}
+
+	jobs, err := s.Expand(testChains())
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, j := range jobs {
+		names = append(names, j.Name)
+	}
+	if got := strings.Join(names, ","); got != "gcc-12-debug-asan,gcc-12-release-asan" {
+		t.Errorf("Expand() = %s", got)
+	}
+	if jobs[0].BuildDir != filepath.Join(dir, "out", "gcc-12-debug-asan") {
+		t.Errorf("BuildDir = %s", jobs[0].BuildDir)
+	}
+}
+
+func TestSpec_Expand(t *testing.T) {
+	tests := []struct {
+		name string
+		spec Spec
+		want string
+	}{
+		{"defaults", Spec{}, "gcc-12-release,clang-17.0.6-" + host.Current().Target().Text() + "-release"},
+		{"duplicates", Spec{BuildTypes: []string{"Debug"}, Options: []*OptionSet{{Name: "a b"}, {Name: "a/b"}}},
+			"gcc-12-debug-a-b,gcc-12-debug-a-b-2,clang-17.0.6-" + host.Current().Target().Text() + "-debug-a-b,clang-17.0.6-" + host.Current().Target().Text() + "-debug-a-b-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := tt.spec.Expand(testChains())
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, j := range jobs {
+				names = append(names, j.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("Expand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (&Spec{BuildTypes: []string{"Fast"}}).Expand(testChains()); err == nil {
+		t.Errorf("Expand() accepted an unknown build type")
+	}
+	if _, err := (&Spec{}).Expand(nil); err == nil {
+		t.Errorf("Expand() accepted an empty matrix")
+	}
+}
+
+// fakeTools writes cmake and ctest shell scripts, configuring fails when the
+// BROKEN variable is defined
+func fakeTools(t *testing.T) string {
+	return testutil.Scripts(t, map[string]string{
+		"cmake": `#!/bin/sh
+for a in "$@"; do
+  case $a in -DBROKEN=*) echo "broken configuration"; exit 1 ;; esac
+done
+echo "cmake $*"
+`,
+		"ctest": testutil.CTest(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="demo" tests="2" failures="0" skipped="0">
+	<testcase name="unit" classname="unit" time="0.25" status="run"/>
+	<testcase name="smoke" classname="smoke" time="0.5" status="run"/>
+</testsuite>`, 0),
+	})
+}
+
+func TestSpec_Run(t *testing.T) {
+	tools := fakeTools(t)
+	s := &Spec{
+		Source:      t.TempDir(),
+		BuildRoot:   t.TempDir(),
+		CMake:       filepath.Join(tools, "cmake"),
+		BuildTypes:  []string{"Debug"},
+		Options:     []*OptionSet{{Name: "default"}, {Name: "broken", Defines: map[string]string{"BROKEN": "ON"}}},
+		Test:        true,
+		Concurrency: 2,
+	}
+	jobs, err := s.Expand(testChains()[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.Run(jobs, nil)
+	if len(r.Results) != 2 || r.Failed() != 1 {
+		t.Fatalf("Run() = %d results, %d failed", len(r.Results), r.Failed())
+	}
+
+	ok, broken := r.Results[0], r.Results[1]
+	if !ok.Passed() || ok.Tests == nil || ok.Tests.Passed != 2 {
+		t.Errorf("%s: passed = %v, tests = %+v", ok.Name, ok.Passed(), ok.Tests)
+	}
+	if st := broken.Step(StepGenerate); st == nil || st.Passed || st.Skipped {
+		t.Errorf("%s: generate = %+v", broken.Name, st)
+	}
+	if st := broken.Step(StepBuild); st == nil || !st.Skipped {
+		t.Errorf("%s: build = %+v, want skipped", broken.Name, st)
+	}
+	if log, err := os.ReadFile(broken.Log); err != nil || !strings.Contains(string(log), "broken configuration") {
+		t.Errorf("log = %q, %v", log, err)
+	}
+
+	buf := &bytes.Buffer{}
+	r.PrintSummary(buf)
+	rows := []string{}
+	for _, ln := range strings.Split(buf.String(), "\n") {
+		if f := strings.Fields(ln); len(f) == 5 {
+			rows = append(rows, strings.Join(f[:4], " "))
+		}
+	}
+	want := "JOB GENERATE BUILD TEST|gcc-12-debug-default ok ok 2/2|gcc-12-debug-broken FAILED skipped skipped"
+	if got := strings.Join(rows, "|"); got != want || !strings.Contains(buf.String(), "1 of 2 passed") {
+		t.Errorf("summary:\n%s", buf)
+	}
+
+	buf.Reset()
+	if err := r.WriteJUnit(buf); err != nil {
+		t.Fatal(err)
+	}
+	var suites junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatal(err)
+	}
+	if suites.Tests != 7 || suites.Failures != 1 || suites.Skipped != 2 || len(suites.Suites) != 2 {
+		t.Errorf("junit = %d tests, %d failures, %d skipped, %d suites", suites.Tests, suites.Failures, suites.Skipped, len(suites.Suites))
+	}
+}
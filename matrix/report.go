@@ -0,0 +1,165 @@
+package matrix
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adnsv/go-build/cmake"
+)
+
+// PrintSummary prints a table with a row per job
+func (r *Report) PrintSummary(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tGENERATE\tBUILD\tTEST\tTIME")
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", res.Name,
+			stepStatus(res.Step(StepGenerate)),
+			stepStatus(res.Step(StepBuild)),
+			testStatus(res.Step(StepTest), res.Tests),
+			res.Duration.Round(time.Millisecond))
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "%d of %d passed in %s\n", len(r.Results)-r.Failed(), len(r.Results), r.Duration.Round(time.Millisecond))
+	for _, res := range r.Results {
+		for _, s := range res.Steps {
+			if !s.Passed && !s.Skipped {
+				fmt.Fprintf(w, "- %s: %s failed: %s", res.Name, s.Name, s.Error)
+				if res.Log != "" {
+					fmt.Fprintf(w, " (see %s)", res.Log)
+				}
+				fmt.Fprintln(w)
+			}
+		}
+	}
+}
+
+func stepStatus(s *Step) string {
+	switch {
+	case s == nil:
+		return "-"
+	case s.Skipped:
+		return "skipped"
+	case s.Passed:
+		return "ok"
+	}
+	return "FAILED"
+}
+
+func testStatus(s *Step, tr *cmake.TestResult) string {
+	if s == nil || tr == nil || len(tr.Tests) == 0 {
+		return stepStatus(s)
+	}
+	ret := fmt.Sprintf("%d/%d", tr.Passed, len(tr.Tests)-tr.Skipped)
+	if tr.Failed > 0 {
+		ret += " FAILED"
+	}
+	return ret
+}
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	buf, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(buf, '\n'))
+	return err
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// WriteJUnit writes the report in the JUnit XML format with a test suite
+// per job. The generate and build steps are test cases of the suite, and so
+// are the tests that ctest ran.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	all := junitSuites{Name: "matrix", Time: junitTime(r.Duration)}
+	for _, res := range r.Results {
+		suite := junitSuite{Name: res.Name, Time: junitTime(res.Duration)}
+		add := func(c junitCase) {
+			suite.Tests++
+			if c.Failure != nil {
+				suite.Failures++
+			}
+			if c.Skipped != nil {
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		for _, s := range res.Steps {
+			if s.Name == StepTest && res.Tests != nil && len(res.Tests.Tests) > 0 {
+				// reported per test below
+				continue
+			}
+			c := junitCase{Name: s.Name, ClassName: res.Name, Time: junitTime(s.Duration)}
+			switch {
+			case s.Skipped:
+				c.Skipped = &junitMessage{Message: s.Error}
+			case !s.Passed:
+				c.Failure = &junitMessage{Message: s.Error}
+			}
+			add(c)
+		}
+		if res.Tests != nil {
+			for _, t := range res.Tests.Tests {
+				c := junitCase{Name: t.Name, ClassName: res.Name + ".ctest", Time: junitTime(t.Duration)}
+				switch t.Status {
+				case cmake.TestFailed:
+					c.Failure = &junitMessage{Message: t.Message}
+					c.SystemOut = t.Output
+				case cmake.TestSkipped:
+					c.Skipped = &junitMessage{Message: t.Message}
+				}
+				add(c)
+			}
+		}
+		all.Tests += suite.Tests
+		all.Failures += suite.Failures
+		all.Skipped += suite.Skipped
+		all.Suites = append(all.Suites, suite)
+	}
+	buf, err := xml.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header+strings.TrimSpace(string(buf))+"\n")
+	return err
+}
@@ -0,0 +1,258 @@
+package matrix
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adnsv/go-build/cmake"
+	"github.com/adnsv/go-build/env"
+)
+
+// LogFileName is the name of the log that each job writes into its build
+// directory
+const LogFileName = "matrix.log"
+
+// Step names
+const (
+	StepGenerate = "generate"
+	StepBuild    = "build"
+	StepTest     = "test"
+)
+
+// Step is the outcome of a generate, build or test step
+type Step struct {
+	Name     string        `json:"name" yaml:"name"`
+	Passed   bool          `json:"passed" yaml:"passed"`
+	Skipped  bool          `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	Error    string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+}
+
+// Result is the outcome of a job
+type Result struct {
+	Name      string            `json:"name" yaml:"name"`
+	Chain     string            `json:"chain" yaml:"chain"`
+	BuildType string            `json:"build-type" yaml:"build-type"`
+	Options   string            `json:"options,omitempty" yaml:"options,omitempty"`
+	BuildDir  string            `json:"build-dir" yaml:"build-dir"`
+	Log       string            `json:"log,omitempty" yaml:"log,omitempty"`
+	Steps     []*Step           `json:"steps" yaml:"steps"`
+	Tests     *cmake.TestResult `json:"tests,omitempty" yaml:"tests,omitempty"`
+	Duration  time.Duration     `json:"duration" yaml:"duration"`
+}
+
+// Passed returns true if none of the steps failed
+func (r *Result) Passed() bool {
+	for _, s := range r.Steps {
+		if !s.Passed && !s.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// Step returns the step with the specified name
+func (r *Result) Step(name string) *Step {
+	for _, s := range r.Steps {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Report is the outcome of a matrix run, the results are in job order
+type Report struct {
+	Results  []*Result     `json:"results" yaml:"results"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+}
+
+// Failed returns the number of jobs that failed
+func (r *Report) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if !res.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Run runs the jobs, at most Concurrency of them at the same time. Each job
+// generates, builds and optionally tests in its own build directory and logs
+// the tool output there.
+func (s *Spec) Run(jobs []*Job, feedback func(string)) *Report {
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	concurrency = min(concurrency, len(jobs))
+	parallel := s.Jobs
+	if parallel <= 0 {
+		parallel = max(1, runtime.NumCPU()/max(1, concurrency))
+	}
+	var mu sync.Mutex
+	say := func(format string, args ...any) {
+		if feedback != nil {
+			mu.Lock()
+			feedback(fmt.Sprintf(format, args...))
+			mu.Unlock()
+		}
+	}
+
+	start := time.Now()
+	report := &Report{Results: make([]*Result, len(jobs))}
+	queue := make(chan int)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				j := jobs[i]
+				if s.FailFast && failed.Load() {
+					report.Results[i] = s.skipped(j, "skipped after an earlier failure")
+					continue
+				}
+				say("%s: started", j.Name)
+				r := s.runJob(j, parallel)
+				report.Results[i] = r
+				status := "passed"
+				if !r.Passed() {
+					failed.Store(true)
+					status = "FAILED"
+				}
+				say("%s: %s in %s", j.Name, status, r.Duration.Round(time.Millisecond))
+			}
+		}()
+	}
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	report.Duration = time.Since(start)
+	return report
+}
+
+func (s *Spec) newResult(j *Job) *Result {
+	r := &Result{
+		Name:      j.Name,
+		Chain:     chainName(j.Chain),
+		BuildType: j.BuildType.String(),
+		BuildDir:  j.BuildDir,
+		Steps:     []*Step{},
+	}
+	if j.Options != nil {
+		r.Options = j.Options.Name
+	}
+	return r
+}
+
+func (s *Spec) steps() []string {
+	if s.Test {
+		return []string{StepGenerate, StepBuild, StepTest}
+	}
+	return []string{StepGenerate, StepBuild}
+}
+
+func (s *Spec) skipped(j *Job, reason string) *Result {
+	r := s.newResult(j)
+	for _, name := range s.steps() {
+		r.Steps = append(r.Steps, &Step{Name: name, Skipped: true, Error: reason})
+	}
+	return r
+}
+
+func (s *Spec) runJob(j *Job, parallel int) *Result {
+	start := time.Now()
+	r := s.newResult(j)
+	defer func() { r.Duration = time.Since(start) }()
+
+	fail := func(err error) *Result {
+		for _, name := range s.steps() {
+			r.Steps = append(r.Steps, &Step{Name: name, Skipped: true, Error: err.Error()})
+		}
+		r.Steps[0].Skipped = false
+		return r
+	}
+	if err := os.MkdirAll(j.BuildDir, 0777); err != nil {
+		return fail(err)
+	}
+	r.Log = filepath.Join(j.BuildDir, LogFileName)
+	log, err := os.Create(r.Log)
+	if err != nil {
+		return fail(err)
+	}
+	defer log.Close()
+
+	defines := []string{}
+	if j.Options != nil {
+		for k, v := range j.Options.Defines {
+			defines = append(defines, "-D"+k+"="+v)
+		}
+		sort.Strings(defines)
+	}
+	b, err := cmake.NewBuilderFor(j.Chain, cmake.Options{
+		CMakeCmd:      s.CMake,
+		Stdout:        log,
+		Stderr:        log,
+		SourceDir:     s.Source,
+		BuildDir:      j.BuildDir,
+		BuildType:     j.BuildType,
+		BuildTargets:  s.Targets,
+		GenerateFlags: defines,
+	})
+	if err != nil {
+		return fail(err)
+	}
+	b.Jobs = parallel
+	// the build directories belong to the matrix, reconfigure rather than
+	// fail when the chain or the options change
+	b.StaleCache = cmake.StaleCacheReconfigure
+	if j.Options != nil && len(j.Options.Env) > 0 {
+		b.Env = env.Join(env.Merge(env.Split(b.Env), env.Split(j.Options.Env)))
+	}
+
+	run := map[string]func() error{
+		StepGenerate: b.Generate,
+		StepBuild:    b.Build,
+		StepTest: func() error {
+			tr, err := b.Test(cmake.TestOptions{
+				Filter:      s.TestFilter,
+				Timeout:     s.TestTimeout,
+				JUnitOutput: filepath.Join(j.BuildDir, "ctest-junit.xml"),
+			})
+			r.Tests = tr
+			return err
+		},
+	}
+	var failed *Step
+	for _, name := range s.steps() {
+		st := &Step{Name: name}
+		r.Steps = append(r.Steps, st)
+		if failed != nil {
+			st.Skipped = true
+			st.Error = failed.Name + " failed"
+			continue
+		}
+		fmt.Fprintf(log, "=== %s\n", name)
+		t := time.Now()
+		err := run[name]()
+		st.Duration = time.Since(t)
+		if err != nil {
+			st.Error = err.Error()
+			failed = st
+			continue
+		}
+		st.Passed = true
+	}
+	return r
+}
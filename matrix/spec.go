@@ -0,0 +1,165 @@
+// Package matrix builds a CMake project with every combination of
+// toolchains, build types and option sets listed in a spec, each in its own
+// build directory, and reports the results.
+package matrix
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adnsv/go-build/cmake"
+	"github.com/adnsv/go-build/compiler/registry"
+	"github.com/adnsv/go-build/compiler/toolchain"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes a build matrix
+type Spec struct {
+	Source      string              `json:"source,omitempty" yaml:"source,omitempty"`           // CMake source directory, relative to the spec file
+	BuildRoot   string              `json:"build-root,omitempty" yaml:"build-root,omitempty"`   // parent of the build directories, defaults to build/matrix in the source directory
+	CMake       string              `json:"cmake,omitempty" yaml:"cmake,omitempty"`             // cmake executable
+	Toolchains  []registry.Selector `json:"toolchains,omitempty" yaml:"toolchains,omitempty"`   // all toolchains when empty
+	BuildTypes  []string            `json:"build-types,omitempty" yaml:"build-types,omitempty"` // defaults to Release
+	Options     []*OptionSet        `json:"options,omitempty" yaml:"options,omitempty"`
+	Targets     []string            `json:"targets,omitempty" yaml:"targets,omitempty"`
+	Test        bool                `json:"test,omitempty" yaml:"test,omitempty"` // runs ctest after building
+	TestFilter  string              `json:"test-filter,omitempty" yaml:"test-filter,omitempty"`
+	TestTimeout time.Duration       `json:"test-timeout,omitempty" yaml:"test-timeout,omitempty"`
+	Concurrency int                 `json:"concurrency,omitempty" yaml:"concurrency,omitempty"` // combinations built at the same time, defaults to the number of CPUs
+	Jobs        int                 `json:"jobs,omitempty" yaml:"jobs,omitempty"`               // parallel jobs of each build, defaults to a share of the CPUs
+	FailFast    bool                `json:"fail-fast,omitempty" yaml:"fail-fast,omitempty"`     // skips the remaining combinations after a failure
+}
+
+// OptionSet is a named set of cache variables and environment variables
+type OptionSet struct {
+	Name    string            `json:"name" yaml:"name"`
+	Defines map[string]string `json:"defines,omitempty" yaml:"defines,omitempty"` // -D<name>=<value>
+	Env     []string          `json:"env,omitempty" yaml:"env,omitempty"`         // KEY=VALUE pairs
+}
+
+// Job is a single combination of the matrix
+type Job struct {
+	Name      string
+	Chain     *toolchain.Chain
+	BuildType cmake.BuildType
+	Options   *OptionSet
+	BuildDir  string
+}
+
+// LoadSpec reads a YAML spec. Relative source and build root paths are
+// resolved against the directory of the spec file.
+func LoadSpec(fn string) (*Spec, error) {
+	buf, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spec{}
+	if err := yaml.Unmarshal(buf, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	dir := filepath.Dir(fn)
+	if s.Source == "" {
+		s.Source = dir
+	} else if !filepath.IsAbs(s.Source) {
+		s.Source = filepath.Join(dir, s.Source)
+	}
+	if s.BuildRoot != "" && !filepath.IsAbs(s.BuildRoot) {
+		s.BuildRoot = filepath.Join(dir, s.BuildRoot)
+	}
+	return s, nil
+}
+
+// Expand returns the jobs for the combinations of the matching chains, the
+// build types and the option sets
+func (s *Spec) Expand(chains []*toolchain.Chain) ([]*Job, error) {
+	types := []cmake.BuildType{}
+	for _, t := range s.BuildTypes {
+		bt, err := cmake.ParseBuildType(t)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, bt)
+	}
+	if len(types) == 0 {
+		types = []cmake.BuildType{cmake.Release}
+	}
+	options := s.Options
+	if len(options) == 0 {
+		options = []*OptionSet{{}}
+	}
+	root := s.BuildRoot
+	if root == "" {
+		root = filepath.Join(s.Source, "build", "matrix")
+	}
+
+	ret := []*Job{}
+	used := map[string]bool{}
+	for _, tc := range chains {
+		if !s.selects(tc) {
+			continue
+		}
+		for _, bt := range types {
+			for _, o := range options {
+				base := chainName(tc) + "-" + strings.ToLower(bt.String())
+				if o.Name != "" {
+					base += "-" + safeName(o.Name)
+				}
+				name := base
+				for i := 2; used[name]; i++ {
+					name = fmt.Sprintf("%s-%d", base, i)
+				}
+				used[name] = true
+				ret = append(ret, &Job{
+					Name:      name,
+					Chain:     tc,
+					BuildType: bt,
+					Options:   o,
+					BuildDir:  filepath.Join(root, name),
+				})
+			}
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no toolchains match the matrix")
+	}
+	return ret, nil
+}
+
+func (s *Spec) selects(tc *toolchain.Chain) bool {
+	if len(s.Toolchains) == 0 {
+		return true
+	}
+	for i := range s.Toolchains {
+		if s.Toolchains[i].Match(tc) {
+			return true
+		}
+	}
+	return false
+}
+
+// chainName names the jobs of a chain after its registry name, or after the
+// compiler, version and target
+func chainName(tc *toolchain.Chain) string {
+	if tc.Name != "" {
+		return safeName(tc.Name)
+	}
+	t := tc.Target.Original
+	if t == "" {
+		t = tc.Target.Text()
+	}
+	return safeName(tc.Compiler + "-" + tc.Version + "-" + t)
+}
+
+// safeName replaces the characters that are not safe in directory names
+func safeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '-'
+	}, s)
+}
@@ -6,6 +6,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/adnsv/go-build/compiler/triplet"
+	"github.com/adnsv/go-build/diag"
 )
 
 type runner struct {
@@ -14,6 +17,11 @@ type runner struct {
 	Stdout      io.Writer
 	Stderr      io.Writer
 	Environment []string
+
+	// Diagnostics, when set, parses the compiler diagnostics in the output.
+	// The tools then run with the locale forced to English, see
+	// diag.LocaleEnv.
+	Diagnostics *diag.Parser
 }
 
 func (r *runner) run(args ...string) error {
@@ -25,6 +33,10 @@ func (r *runner) run(args ...string) error {
 	if c.Stdout != nil {
 		fmt.Fprintln(c.Stdout, c)
 	}
+	if r.Diagnostics != nil {
+		c.Env = diag.ForceLocale(r.Environment, nil)
+		return r.Diagnostics.Run(c)
+	}
 	err := c.Run()
 	return err
 }
@@ -59,16 +71,46 @@ func (ar *AR) Make(aout string, objs []string) error {
 type LD struct {
 	runner
 	Flags []string
+
+	// Target selects the command line style: msvc link.exe when the OS is
+	// not specified or the target uses msvc, a gcc-compatible driver
+	// otherwise
+	Target triplet.Target
 }
 
+// Make links the executable. The executable suffix is added when exe does
+// not have it. Libraries that are given by name (no directory and no
+// extension) are passed as name.lib to link.exe and as -lname to the
+// gcc-compatible drivers, other libs are passed as they are.
 func (ld *LD) Make(exe string, subsystem string, libs []string) error {
 	ff := append(ld.Flags, []string{}...)
+	msvc := ld.Target.OS == "" || ld.Target.IsMSVC()
+	if ld.Target.OS != "" {
+		exe = ld.Target.Executable(exe)
+	}
 	if subsystem != "" {
-		ff = append(ff, "/SUBSYSTEM:"+subsystem)
+		switch {
+		case msvc:
+			ff = append(ff, "/SUBSYSTEM:"+subsystem)
+		case ld.Target.OS == "windows":
+			ff = append(ff, "-Wl,--subsystem,"+strings.ToLower(subsystem))
+		}
 	}
 	//ff = append(ff, "/IMPLIB:"+mainlib)
-	ff = append(ff, libs...)
-	ff = append(ff, "/OUT:"+exe)
+	for _, lib := range libs {
+		if strings.ContainsAny(lib, `/\`) || filepath.Ext(lib) != "" || strings.HasPrefix(lib, "-") {
+			ff = append(ff, lib)
+		} else if msvc {
+			ff = append(ff, lib+".lib")
+		} else {
+			ff = append(ff, "-l"+lib)
+		}
+	}
+	if msvc {
+		ff = append(ff, "/OUT:"+exe)
+	} else {
+		ff = append(ff, "-o", exe)
+	}
 	return ld.run(ff...)
 }
 